@@ -0,0 +1,137 @@
+package apierrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code identifies the kind of failure so clients can branch on it
+type Code string
+
+const (
+	CodeInvalidInput        Code = "invalid_input"
+	CodeUnauthorized        Code = "unauthorized"
+	CodeNotFound            Code = "not_found"
+	CodeConflict            Code = "conflict"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeInternal            Code = "internal_error"
+)
+
+// statuses maps the codes to their HTTP status, in the order codes are
+// looked up by status
+var statuses = []struct {
+	code   Code
+	status int
+}{
+	{CodeInvalidInput, http.StatusBadRequest},
+	{CodeUnauthorized, http.StatusUnauthorized},
+	{CodeNotFound, http.StatusNotFound},
+	{CodeConflict, http.StatusConflict},
+	{CodeUpstreamUnavailable, http.StatusServiceUnavailable},
+	{CodeInternal, http.StatusInternalServerError},
+}
+
+// Error is a failure reported to clients. Message and Details are returned
+// as is, while the wrapped cause is only logged so database and upstream
+// errors never leak.
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	Err     error
+
+	// status overrides the status of Code, for errors converted from Echo
+	// with a status that has no dedicated code
+	status int
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the error
+func (e *Error) Status() int {
+	if e.status != 0 {
+		return e.status
+	}
+	for _, s := range statuses {
+		if s.code == e.Code {
+			return s.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// WithDetail adds a machine-readable detail to the error
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// InvalidInput reports a request that can't be served as sent
+func InvalidInput(message string) *Error {
+	return &Error{Code: CodeInvalidInput, Message: message}
+}
+
+// InvalidParameter reports an invalid query, path or body parameter
+func InvalidParameter(name, message string) *Error {
+	return InvalidInput(message).WithDetail("parameter", name)
+}
+
+// Unauthorized reports a missing or invalid API key
+func Unauthorized(message string) *Error {
+	return &Error{Code: CodeUnauthorized, Message: message}
+}
+
+// NotFound reports that the requested resource does not exist
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+// Conflict reports a request clashing with the current state, such as an
+// operation that is already running
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+// UpstreamUnavailable reports a failure of the external stocks API
+func UpstreamUnavailable(message string, err error) *Error {
+	return &Error{Code: CodeUpstreamUnavailable, Message: message, Err: err}
+}
+
+// Internal reports an unexpected failure, err is kept for the logs
+func Internal(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// Wrap passes domain errors through and turns any other error into an
+// internal error with message
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	return Internal(message, err)
+}
+
+// As returns the domain error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var apiErr *Error
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
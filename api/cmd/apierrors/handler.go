@@ -0,0 +1,87 @@
+package apierrors
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Envelope is the body of every error response
+type Envelope struct {
+	Error Body `json:"error"`
+}
+
+// Body describes an error to clients
+type Body struct {
+	Code      Code                   `json:"code"`
+	Message   string                 `json:"message"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// HTTPErrorHandler renders the errors returned by handlers and middleware as
+// an Envelope. Unexpected errors are logged and reported without their cause.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	apiErr := FromError(err)
+	if apiErr.Err != nil {
+		c.Logger().Errorf("%s %s: %v", c.Request().Method, c.Request().URL.Path, apiErr)
+	}
+
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status())
+	} else {
+		err = c.JSON(apiErr.Status(), Envelope{Error: Body{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			RequestID: requestID,
+			Details:   apiErr.Details,
+		}})
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// FromError converts any error to a domain error. Echo errors such as unknown
+// routes or methods keep their status, anything else is an internal error.
+func FromError(err error) *Error {
+	if apiErr, ok := As(err); ok {
+		return apiErr
+	}
+
+	if he, ok := err.(*echo.HTTPError); ok {
+		message, ok := he.Message.(string)
+		if !ok {
+			message = http.StatusText(he.Code)
+		}
+		return &Error{Code: codeForStatus(he.Code), Message: message, Err: he.Internal, status: he.Code}
+	}
+
+	return Internal("Internal server error", err)
+}
+
+// codeForStatus returns the code matching an HTTP status, deriving one from
+// the status text for statuses without a dedicated code
+func codeForStatus(status int) Code {
+	for _, s := range statuses {
+		if s.status == status {
+			return s.code
+		}
+	}
+
+	if status >= http.StatusInternalServerError || http.StatusText(status) == "" {
+		return CodeInternal
+	}
+
+	return Code(strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"))
+}
@@ -3,12 +3,19 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/cache"
 	"stonks-api/cmd/database"
+	"stonks-api/internal/docs"
+	"stonks-api/internal/graphql"
+	"stonks-api/internal/grpcapi"
 	"stonks-api/internal/recommendations"
 	"stonks-api/internal/stocks"
+	"stonks-api/internal/stocks/models"
 	"stonks-api/internal/stocks/services"
 	"syscall"
 	"time"
@@ -17,15 +24,23 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
 )
 
+// apiBasePath is the prefix of the versioned API routes
+const apiBasePath = "/api/v1/stonks-api"
+
 type application struct {
 	config          *Config
 	db              database.Database
+	readCache       *cache.ReadCache
 	server          *echo.Echo
+	grpcServer      *grpc.Server
 	env             string
 	stocks          *stocks.Module
 	recommendations *recommendations.Module
+	graphql         *graphql.Module
+	docs            *docs.Module
 }
 
 func (app *application) setup(env string) error {
@@ -47,30 +62,90 @@ func (app *application) setup(env string) error {
 		return err
 	}
 
+	// Responses are cached until a sync writes new data
+	app.readCache = cache.NewReadCache(
+		cache.NewMemoryBackend(app.config.Cache.MaxEntries),
+		time.Duration(app.config.Cache.TTLSeconds)*time.Second,
+	)
+
 	// Initialize modules
-	app.stocks = stocks.NewModule(app.db)
+	app.stocks = stocks.NewModule(app.db, app.readCache)
+
+	// Brokerage ratings are placed on the shared ladder before anything reads them
+	if ladderFile := app.config.Ratings.LadderFile; ladderFile != "" {
+		ladder, err := models.LoadRatingLadder(ladderFile)
+		if err != nil {
+			return fmt.Errorf("can't load rating ladder: %v", err)
+		}
+		app.stocks.StockService.SetRatingLadder(ladder)
+		fmt.Printf("Loaded rating ladder %s with %d brokerage overrides\n", ladderFile, len(ladder.BrokerageOverrides))
+	}
+	mappings, err := app.stocks.StockService.LoadRatingMappings()
+	if err != nil {
+		return fmt.Errorf("can't load rating mappings: %v", err)
+	}
+	fmt.Printf("Loaded %d rating mappings\n", len(mappings))
+
+	// The ladder may map ratings recorded as unmapped, the full scan stays
+	// off the startup path
+	go func() {
+		if err := app.stocks.StockService.RefreshUnmappedRatings(); err != nil {
+			fmt.Printf("Recording unmapped ratings failed: %v\n", err)
+		}
+	}()
 	apiConfig := services.ExternalAPIConfig{
 		URL:        app.config.ExternalStocksAPI.URL,
 		AuthHeader: app.config.ExternalStocksAPI.AuthHeader,
 		AuthToken:  app.config.ExternalStocksAPI.AuthToken,
 	}
 	app.stocks.StockService.SetExternalAPIConfig(apiConfig)
-	app.recommendations = recommendations.NewModule(app.db)
+	app.recommendations = recommendations.NewModule(app.db, app.readCache)
+	if scoringFile := app.config.Recommendations.ScoringFile; scoringFile != "" {
+		scoring, err := app.recommendations.RecommendationService.LoadScoringFile(scoringFile)
+		if err != nil {
+			return fmt.Errorf("can't load scoring config: %v", err)
+		}
+		fmt.Printf("Loaded scoring config %s version %s\n", scoringFile, scoring.Version)
+	}
+	app.recommendations.RecommendationService.SetSnapshotSize(app.config.Recommendations.SnapshotSize)
+
+	// Every successful sync records the recommendations it leads to
+	app.stocks.StockService.SetAfterSync(func(saved int) {
+		if _, err := app.recommendations.RecommendationService.TakeSnapshot(""); err != nil {
+			fmt.Printf("Recommendation snapshot after sync failed: %v\n", err)
+		}
+	})
+	app.graphql, err = graphql.NewModule(app.stocks.StockService, app.recommendations.RecommendationService)
+	if err != nil {
+		return fmt.Errorf("can't build GraphQL schema: %v", err)
+	}
+	app.docs, err = docs.NewModule()
+	if err != nil {
+		return fmt.Errorf("can't load API docs: %v", err)
+	}
 
 	// Setup HTTP server
 	app.server = echo.New()
 	app.server.HideBanner = true
+	app.server.HTTPErrorHandler = apierrors.HTTPErrorHandler
 
-	app.server.Use(middleware.Logger())
+	app.server.Use(middleware.RequestID())
+	app.server.Use(authMiddleware.Logger())
 	app.server.Use(middleware.Recover())
 	app.server.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{app.config.Server.AllowedOrigin},
-		AllowMethods: []string{http.MethodGet, http.MethodPost},
-		AllowHeaders: []string{echo.HeaderContentType, "X-API-Key"},
+		AllowOrigins:  []string{app.config.Server.AllowedOrigin},
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowHeaders:  []string{echo.HeaderContentType, "X-API-Key", "If-None-Match", echo.HeaderIfModifiedSince},
+		ExposeHeaders: []string{"Link", echo.HeaderContentDisposition, "ETag", echo.HeaderLastModified, "X-Cache", echo.HeaderXRequestID},
 	}))
 
 	app.setupRoutes()
 
+	app.grpcServer = grpcapi.NewGRPCServer(
+		grpcapi.NewServer(app.stocks.StockService, app.recommendations.RecommendationService),
+		app.config.Server.APIKey,
+	)
+
 	return nil
 }
 
@@ -83,12 +158,30 @@ func (app *application) setupRoutes() {
 			"env":     app.env,
 		})
 	})
-	apiV1 := app.server.Group("/api/v1/stonks-api")
-	apiV1.Use(authMiddleware.APIKeyAuth(app.config.Server.APIKey))
+
+	// API docs are public so they can be browsed
+	app.docs.RegisterRoutes(app.server.Group("/docs"))
+
+	apiV1 := app.server.Group(apiBasePath)
+	streamTokens := authMiddleware.NewStreamTokens(app.config.Server.APIKey, authMiddleware.DefaultStreamTokenTTL)
+	apiV1.Use(authMiddleware.APIKeyAuth(app.config.Server.APIKey, streamTokens))
+	apiV1.Use(authMiddleware.RequestValidation(app.docs.Spec, apiBasePath))
+	apiV1.Use(authMiddleware.HTTPCache(app.readCache))
 
 	// Register module routes
 	app.stocks.RegisterRoutes(apiV1)
 	app.recommendations.RegisterRoutes(apiV1)
+	app.graphql.RegisterRoutes(apiV1)
+	apiV1.POST("/stocks/live/token", streamTokens.Handler)
+
+	// Admin routes take the admin key instead of the read API key, and are
+	// never answered from the read cache
+	admin := app.server.Group(apiBasePath + "/admin")
+	admin.Use(authMiddleware.AdminKeyAuth(app.config.Server.AdminAPIKey))
+	admin.Use(authMiddleware.RequestValidation(app.docs.Spec, apiBasePath))
+
+	app.stocks.RegisterAdminRoutes(admin)
+	app.recommendations.RegisterAdminRoutes(admin)
 }
 
 func (app *application) startServer() error {
@@ -105,16 +198,51 @@ func (app *application) startServer() error {
 		}
 	}()
 
+	grpcAddr := app.config.GetGRPCAddress()
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return fmt.Errorf("can't listen on %s: %v", grpcAddr, err)
+	}
+
+	go func() {
+		fmt.Printf("Started gRPC server on %s\n", grpcAddr)
+
+		if err := app.grpcServer.Serve(listener); err != nil {
+			fmt.Printf("gRPC server stopped: %v\n", err)
+		}
+	}()
+
+	// Snapshots are also taken on a schedule when an interval is configured
+	snapshotCtx, stopSnapshots := context.WithCancel(context.Background())
+	defer stopSnapshots()
+	if minutes := app.config.Recommendations.SnapshotIntervalMinutes; minutes > 0 {
+		go app.recommendations.RecommendationService.RunSnapshotSchedule(snapshotCtx, time.Duration(minutes)*time.Minute)
+	}
+
 	<-quit
 	fmt.Println("Shutting down server")
+	stopSnapshots()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Open streams never finish on their own, cut them off at the deadline
+	stopped := make(chan struct{})
+	go func() {
+		app.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
 	if err := app.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("error during server shutdown: %v", err)
 	}
 
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		app.grpcServer.Stop()
+	}
+
 	// Close database connection
 	if app.db != nil {
 		if err := app.db.Close(); err != nil {
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL bounds how long a cached response is served
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxEntries bounds the size of the in-memory backend
+	DefaultMaxEntries = 1000
+
+	// versionKey stores the data version in the backend so instances sharing
+	// it agree on the version
+	versionKey = "read-cache:version"
+	// versionTTL keeps the version well past the entries; when it expires
+	// anyway the newest version an instance knows is stored again
+	versionTTL = 24 * time.Hour
+)
+
+// Backend stores cached responses. Implementations must be safe for
+// concurrent use; the in-memory backend is the default, a shared store such
+// as Redis can be plugged in for multiple instances.
+type Backend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Clear()
+}
+
+// ReadCache is a versioned cache of read results. The version is the time of
+// the last write to the underlying data, so keys built with Key never return
+// entries stored before an Invalidate. The version is kept in the backend, a
+// shared backend shares it between instances.
+type ReadCache struct {
+	backend Backend
+	ttl     time.Duration
+
+	mu           sync.RWMutex
+	lastModified time.Time
+}
+
+// NewReadCache creates a read cache on the backend. A non-positive ttl uses DefaultTTL.
+func NewReadCache(backend Backend, ttl time.Duration) *ReadCache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &ReadCache{
+		backend:      backend,
+		ttl:          ttl,
+		lastModified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// LastModified returns the time of the last invalidation, truncated to
+// seconds to match HTTP dates
+func (c *ReadCache) LastModified() time.Time {
+	c.mu.RLock()
+	local := c.lastModified
+	c.mu.RUnlock()
+
+	shared, ok := c.sharedVersion()
+	switch {
+	case ok && shared.After(local):
+		// Another instance invalidated since
+		c.mu.Lock()
+		if shared.After(c.lastModified) {
+			c.lastModified = shared
+		}
+		c.mu.Unlock()
+		return shared
+	case !ok || local.After(shared):
+		// Expired or overwritten by an instance behind, keep the newest
+		c.storeVersion(local)
+	}
+	return local
+}
+
+// sharedVersion reads the version stored in the backend
+func (c *ReadCache) sharedVersion() (time.Time, bool) {
+	value, ok := c.backend.Get(versionKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// storeVersion writes the version to the backend
+func (c *ReadCache) storeVersion(version time.Time) {
+	c.backend.Set(versionKey, []byte(strconv.FormatInt(version.Unix(), 10)), versionTTL)
+}
+
+// Version returns an opaque identifier of the current data version
+func (c *ReadCache) Version() string {
+	return strconv.FormatInt(c.LastModified().Unix(), 36)
+}
+
+// Key prefixes the key with the current version
+func (c *ReadCache) Key(key string) string {
+	return c.Version() + ":" + key
+}
+
+// Get returns the entry stored under the versioned key
+func (c *ReadCache) Get(key string) ([]byte, bool) {
+	return c.backend.Get(c.Key(key))
+}
+
+// Set stores the entry under the versioned key
+func (c *ReadCache) Set(key string, value []byte) {
+	c.SetVersion(c.Version(), key, value)
+}
+
+// SetVersion stores an entry computed from the given data version. Entries
+// of a version that has since been invalidated are never read back.
+func (c *ReadCache) SetVersion(version, key string, value []byte) {
+	c.backend.Set(version+":"+key, value, c.ttl)
+}
+
+// Invalidate moves to a new version and drops the stored entries
+func (c *ReadCache) Invalidate() {
+	// Move past invalidations made by other instances
+	c.LastModified()
+
+	c.mu.Lock()
+	now := time.Now().UTC().Truncate(time.Second)
+	// Versions have second precision, two writes within a second still
+	// need distinct versions
+	if !now.After(c.lastModified) {
+		now = c.lastModified.Add(time.Second)
+	}
+	c.lastModified = now
+	c.mu.Unlock()
+
+	c.backend.Clear()
+	c.storeVersion(now)
+}
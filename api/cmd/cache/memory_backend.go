@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryBackend is an in-process Backend. When full, expired entries are
+// dropped first and then the entry closest to expiry.
+type MemoryBackend struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryBackend creates an in-memory backend. A non-positive maxEntries uses DefaultMaxEntries.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		entries:    make(map[string]memoryEntry),
+	}
+}
+
+// Get returns the value stored under key unless it expired
+func (b *MemoryBackend) Get(key string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(b.entries, key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set stores value under key for ttl
+func (b *MemoryBackend) Set(key string, value []byte, ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.entries[key]; !ok && len(b.entries) >= b.maxEntries {
+		b.evict()
+	}
+
+	b.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+// Clear drops every entry
+func (b *MemoryBackend) Clear() {
+	b.mu.Lock()
+	b.entries = make(map[string]memoryEntry)
+	b.mu.Unlock()
+}
+
+// evict makes room for one entry, the caller holds the lock
+func (b *MemoryBackend) evict() {
+	now := time.Now()
+	var oldestKey string
+	var oldest time.Time
+
+	for key, entry := range b.entries {
+		if now.After(entry.expiresAt) {
+			delete(b.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+
+	if len(b.entries) >= b.maxEntries && oldestKey != "" {
+		delete(b.entries, oldestKey)
+	}
+}
@@ -25,7 +25,9 @@ type Config struct {
 		Host          string `json:"host"`
 		Port          int    `json:"port"`
 		APIKey        string `json:"APIKey"`
+		AdminAPIKey   string `json:"adminAPIKey"`
 		AllowedOrigin string `json:"allowedOrigin"`
+		GRPCPort      int    `json:"grpcPort"`
 	} `json:"server"`
 
 	ExternalStocksAPI struct {
@@ -33,6 +35,21 @@ type Config struct {
 		AuthHeader string `json:"authHeader"`
 		AuthToken  string `json:"authToken"`
 	} `json:"externalStocksAPI"`
+
+	Cache struct {
+		TTLSeconds int `json:"ttlSeconds"`
+		MaxEntries int `json:"maxEntries"`
+	} `json:"cache"`
+
+	Recommendations struct {
+		ScoringFile             string `json:"scoringFile"`
+		SnapshotSize            int    `json:"snapshotSize"`
+		SnapshotIntervalMinutes int    `json:"snapshotIntervalMinutes"`
+	} `json:"recommendations"`
+
+	Ratings struct {
+		LadderFile string `json:"ladderFile"`
+	} `json:"ratings"`
 }
 
 func LoadConfig(environment string) (*Config, error) {
@@ -131,6 +148,9 @@ func loadFromEnv() (*Config, error) {
 	}
 	config.Server.APIKey = serverAPIKey
 
+	// Admin routes are disabled without an admin key
+	config.Server.AdminAPIKey = os.Getenv("SERVER_ADMIN_API_KEY")
+
 	serverPortStr, err := getRequiredEnv("SERVER_PORT")
 	if err != nil {
 		return nil, err
@@ -141,6 +161,13 @@ func loadFromEnv() (*Config, error) {
 	}
 	config.Server.Port = serverPort
 
+	if grpcPortStr := os.Getenv("GRPC_PORT"); grpcPortStr != "" {
+		config.Server.GRPCPort, err = strconv.Atoi(grpcPortStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GRPC_PORT: %v", err)
+		}
+	}
+
 	// External API config
 	apiURL, err := getRequiredEnv("API_URL")
 	if err != nil {
@@ -160,6 +187,42 @@ func loadFromEnv() (*Config, error) {
 	}
 	config.ExternalStocksAPI.AuthToken = apiAuthToken
 
+	// Cache config, optional
+	if cacheTTL := os.Getenv("CACHE_TTL_SECONDS"); cacheTTL != "" {
+		config.Cache.TTLSeconds, err = strconv.Atoi(cacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_TTL_SECONDS: %v", err)
+		}
+	}
+
+	if cacheMaxEntries := os.Getenv("CACHE_MAX_ENTRIES"); cacheMaxEntries != "" {
+		config.Cache.MaxEntries, err = strconv.Atoi(cacheMaxEntries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_MAX_ENTRIES: %v", err)
+		}
+	}
+
+	// Recommendation scoring file, optional
+	config.Recommendations.ScoringFile = os.Getenv("SCORING_CONFIG_FILE")
+
+	// Recommendation snapshots, optional
+	if snapshotSize := os.Getenv("SNAPSHOT_SIZE"); snapshotSize != "" {
+		config.Recommendations.SnapshotSize, err = strconv.Atoi(snapshotSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SNAPSHOT_SIZE: %v", err)
+		}
+	}
+
+	if snapshotInterval := os.Getenv("SNAPSHOT_INTERVAL_MINUTES"); snapshotInterval != "" {
+		config.Recommendations.SnapshotIntervalMinutes, err = strconv.Atoi(snapshotInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SNAPSHOT_INTERVAL_MINUTES: %v", err)
+		}
+	}
+
+	// Rating ladder file, optional
+	config.Ratings.LadderFile = os.Getenv("RATING_LADDER_FILE")
+
 	return config, nil
 }
 
@@ -185,3 +248,12 @@ func (c *Config) GetConnectionString() string {
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
+
+// GetGRPCAddress returns the address of the gRPC server, on port 9090 unless configured
+func (c *Config) GetGRPCAddress() string {
+	port := c.Server.GRPCPort
+	if port == 0 {
+		port = 9090
+	}
+	return fmt.Sprintf("%s:%d", c.Server.Host, port)
+}
@@ -45,6 +45,9 @@ type Database interface {
 	// Exec executes raw SQL
 	Exec(sql string, values ...interface{}) error
 
+	// Raw starts a query from raw SQL, read the results with Scan
+	Raw(sql string, values ...interface{}) Query
+
 	// Ping checks database connectivity
 	Ping() error
 }
@@ -74,6 +77,9 @@ type Query interface {
 
 	// Updates updates records with the given values
 	Updates(values interface{}) error
+
+	// Scan copies the query results into dest
+	Scan(dest interface{}) error
 }
 
 // Transaction represents a database transaction
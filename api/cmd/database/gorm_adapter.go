@@ -93,6 +93,11 @@ func (g *GormAdapter) Exec(sql string, values ...interface{}) error {
 	return g.db.Exec(sql, values...).Error
 }
 
+// Raw starts a query from raw SQL
+func (g *GormAdapter) Raw(sql string, values ...interface{}) Query {
+	return &GormQueryAdapter{query: g.db.Raw(sql, values...)}
+}
+
 // Ping checks database connectivity
 func (g *GormAdapter) Ping() error {
 	db, err := g.db.DB()
@@ -188,3 +193,8 @@ func (t *GormTransactionAdapter) Model(value interface{}) Query {
 func (q *GormQueryAdapter) Updates(values interface{}) error {
 	return q.query.Updates(values).Error
 }
+
+// Scan copies the query results into dest
+func (q *GormQueryAdapter) Scan(dest interface{}) error {
+	return q.query.Scan(dest).Error
+}
@@ -16,6 +16,7 @@ type MockDatabase struct {
 	CloseFn       func() error
 	ModelFn       func(value interface{}) Query
 	ExecFn        func(sql string, values ...interface{}) error
+	RawFn         func(sql string, values ...interface{}) Query
 	PingFn        func() error
 }
 
@@ -131,6 +132,14 @@ func (m *MockDatabase) Exec(sql string, values ...interface{}) error {
 	return nil
 }
 
+// Raw starts a query from raw SQL
+func (m *MockDatabase) Raw(sql string, values ...interface{}) Query {
+	if m.RawFn != nil {
+		return m.RawFn(sql, values...)
+	}
+	return &MockQuery{}
+}
+
 // Ping checks database connectivity
 func (m *MockDatabase) Ping() error {
 	if m.PingFn != nil {
@@ -149,6 +158,7 @@ type MockQuery struct {
 	SelectFn  func(query interface{}, args ...interface{}) Query
 	CountFn   func() (int64, error)
 	UpdatesFn func(values interface{}) error
+	ScanFn    func(dest interface{}) error
 }
 
 // Find retrieves records matching the query conditions
@@ -215,6 +225,14 @@ func (m *MockQuery) Updates(values interface{}) error {
 	return nil
 }
 
+// Scan copies the query results into dest
+func (m *MockQuery) Scan(dest interface{}) error {
+	if m.ScanFn != nil {
+		return m.ScanFn(dest)
+	}
+	return nil
+}
+
 // MockTransaction provides a mock implementation of the Transaction interface for testing
 type MockTransaction struct {
 	CommitFn   func() error
@@ -306,6 +324,13 @@ func NewMockDatabaseWithError(err error) *MockDatabase {
 		ExecFn: func(sql string, values ...interface{}) error {
 			return err
 		},
+		RawFn: func(sql string, values ...interface{}) Query {
+			return &MockQuery{
+				ScanFn: func(dest interface{}) error {
+					return err
+				},
+			}
+		},
 		PingFn: func() error {
 			return err
 		},
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"stonks-api/cmd/cache"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxCachedBody bounds the size of a response kept in the read cache and
+	// of a response buffered to compute its ETag
+	maxCachedBody = 1 << 20
+
+	// nowRelativeKey flags requests whose response depends on the current time
+	nowRelativeKey = "http-cache:now-relative"
+)
+
+// NowRelative flags the response as computed over a window ending at the
+// time of the request. It changes without a write, so HTTPCache keeps it out
+// of the read cache and sends no Last-Modified for it.
+func NowRelative(c echo.Context) {
+	c.Set(nowRelativeKey, true)
+}
+
+// isNowRelative reports whether the handler flagged the response with NowRelative
+func isNowRelative(c echo.Context) bool {
+	flagged, _ := c.Get(nowRelativeKey).(bool)
+	return flagged
+}
+
+// HTTPCache adds ETag and Last-Modified validators to successful GET
+// responses, answers matching conditional requests with 304 Not Modified and
+// replays cached JSON bodies until the read cache is invalidated. The ETag is
+// derived from the response body. Responses flagged with NowRelative change
+// without a write, so they are never cached and only validated by their
+// ETag. Streaming requests are passed through untouched.
+func HTTPCache(readCache *cache.ReadCache) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet || isStreamRequest(req) {
+				return next(c)
+			}
+
+			// Representations differ by query string and negotiated format
+			key := req.URL.RequestURI() + "|" + req.Header.Get(echo.HeaderAccept)
+			version := readCache.Version()
+			lastModified := readCache.LastModified()
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+			if entry, ok := readCache.Get(key); ok {
+				if contentType, body, found := bytes.Cut(entry, []byte("\n")); found {
+					etag := entityTag(string(contentType), body)
+					setValidators(res.Header(), etag, lastModified)
+					res.Header().Set("X-Cache", "HIT")
+					if notModified(req, etag, lastModified) {
+						return c.NoContent(http.StatusNotModified)
+					}
+					return c.Blob(http.StatusOK, string(contentType), body)
+				}
+			}
+
+			buffer := &bufferWriter{ResponseWriter: res.Writer, context: c, lastModified: lastModified}
+			res.Writer = buffer
+
+			err := next(c)
+			res.Writer = buffer.ResponseWriter
+
+			if buffer.passThrough || !buffer.wroteHeader {
+				return err
+			}
+
+			body := buffer.body.Bytes()
+			contentType := res.Header().Get(echo.HeaderContentType)
+			nowRelative := isNowRelative(c)
+			if nowRelative {
+				lastModified = time.Time{}
+			}
+			if buffer.status != http.StatusOK {
+				buffer.ResponseWriter.WriteHeader(buffer.status)
+				_, writeErr := buffer.ResponseWriter.Write(body)
+				return errors.Join(err, writeErr)
+			}
+
+			etag := entityTag(contentType, body)
+			setValidators(res.Header(), etag, lastModified)
+			res.Header().Set("X-Cache", "MISS")
+
+			if err == nil && !nowRelative && strings.HasPrefix(contentType, echo.MIMEApplicationJSON) {
+				entry := append([]byte(contentType+"\n"), body...)
+				readCache.SetVersion(version, key, entry)
+			}
+
+			if notModified(req, etag, lastModified) {
+				res.Header().Del(echo.HeaderContentLength)
+				res.Status = http.StatusNotModified
+				buffer.ResponseWriter.WriteHeader(http.StatusNotModified)
+				return err
+			}
+
+			buffer.ResponseWriter.WriteHeader(http.StatusOK)
+			_, writeErr := buffer.ResponseWriter.Write(body)
+			return errors.Join(err, writeErr)
+		}
+	}
+}
+
+// entityTag derives a strong validator from the representation
+func entityTag(contentType string, body []byte) string {
+	h := fnv.New64a()
+	h.Write([]byte(contentType))
+	h.Write([]byte{0})
+	h.Write(body)
+	return fmt.Sprintf(`"%x"`, h.Sum64())
+}
+
+// setValidators writes the ETag, Last-Modified and Cache-Control headers,
+// skipping the empty ones. Clients may keep responses but must revalidate
+// them on every use.
+func setValidators(header http.Header, etag string, lastModified time.Time) {
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		header.Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	header.Set("Cache-Control", "private, no-cache")
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// when no entity tags are sent (RFC 9110 section 13.2.2)
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if header := req.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := req.Header.Get(echo.HeaderIfModifiedSince); header != "" && !lastModified.IsZero() {
+		if since, err := http.ParseTime(header); err == nil {
+			return !lastModified.After(since)
+		}
+	}
+
+	return false
+}
+
+// bufferWriter holds the response back until the handler is done so its
+// ETag can be computed from the body. Bodies above maxCachedBody and flushed
+// responses are passed through, without an ETag.
+type bufferWriter struct {
+	http.ResponseWriter
+	context      echo.Context
+	lastModified time.Time
+	status       int
+	wroteHeader  bool
+	body         bytes.Buffer
+	passThrough  bool
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.passThrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.body.Len()+len(b) > maxCachedBody {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// release sends the status and the buffered body and passes the rest through
+func (w *bufferWriter) release() error {
+	w.passThrough = true
+	if w.status == http.StatusOK && !isNowRelative(w.context) {
+		setValidators(w.ResponseWriter.Header(), "", w.lastModified)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	w.body.Reset()
+	return err
+}
+
+// Flush keeps streaming responses working through the buffer
+func (w *bufferWriter) Flush() {
+	if !w.passThrough {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := w.release(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *bufferWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
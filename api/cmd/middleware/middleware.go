@@ -1,30 +1,123 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"stonks-api/cmd/apierrors"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
 )
 
-// APIKeyAuth middleware checks for a valid API key in the X-API-Key header
-func APIKeyAuth(expectedAPIKey string) echo.MiddlewareFunc {
+// APIKeyAuth middleware checks for a valid API key in the X-API-Key header.
+// Browser EventSource and WebSocket clients cannot set headers, so streaming
+// requests may pass a token issued by StreamTokens in the stream_token query
+// parameter instead. The API key itself is never accepted in the URL.
+func APIKeyAuth(expectedAPIKey string, tokens *StreamTokens) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			apiKey := c.Request().Header.Get("X-API-Key")
+			if apiKey == "" && isStreamRequest(c.Request()) {
+				if token := c.QueryParam("stream_token"); token != "" {
+					if tokens == nil || !tokens.Valid(token) {
+						return apierrors.Unauthorized("Invalid or expired stream token")
+					}
+					return next(c)
+				}
+			}
 
 			if apiKey == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "API key is required",
-				})
+				return apierrors.Unauthorized("API key is required")
+			}
+
+			if !keysMatch(apiKey, expectedAPIKey) {
+				return apierrors.Unauthorized("Invalid API key")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// AdminKeyAuth middleware checks for a valid admin key in the X-Admin-Key
+// header. The admin key is separate from the read API key shipped to
+// browsers; without one configured every admin request is rejected.
+func AdminKeyAuth(expectedAdminKey string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if expectedAdminKey == "" {
+				return apierrors.Unauthorized("Admin API is disabled")
 			}
 
-			if apiKey != expectedAPIKey {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "Invalid API key",
-				})
+			adminKey := c.Request().Header.Get("X-Admin-Key")
+			if adminKey == "" {
+				return apierrors.Unauthorized("Admin key is required")
+			}
+
+			if !keysMatch(adminKey, expectedAdminKey) {
+				return apierrors.Unauthorized("Invalid admin key")
 			}
 
 			return next(c)
 		}
 	}
 }
+
+// keysMatch compares a key sent by a client with the expected one in
+// constant time, so response times don't leak how much of it matched
+func keysMatch(key, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(key), []byte(expected)) == 1
+}
+
+// isStreamRequest reports whether the request opens a long-lived stream, a
+// WebSocket upgrade or a Server-Sent Events subscription
+func isStreamRequest(req *http.Request) bool {
+	if strings.EqualFold(req.Header.Get(echo.HeaderUpgrade), "websocket") {
+		return true
+	}
+	return strings.Contains(req.Header.Get(echo.HeaderAccept), "text/event-stream")
+}
+
+// redactedParams are the query parameters kept out of the access log
+var redactedParams = []string{"api_key", "stream_token"}
+
+// Logger logs requests like Echo's logger, with credentials passed in the
+// query string redacted from the logged URI
+func Logger() echo.MiddlewareFunc {
+	return LoggerTo(os.Stdout)
+}
+
+// LoggerTo is Logger writing to output
+func LoggerTo(output io.Writer) echo.MiddlewareFunc {
+	config := echoMiddleware.DefaultLoggerConfig
+	config.Format = strings.Replace(config.Format, "${uri}", "${custom}", 1)
+	config.CustomTagFunc = func(c echo.Context, buf *bytes.Buffer) (int, error) {
+		return buf.WriteString(redactURI(c.Request().URL))
+	}
+	config.Output = output
+	return echoMiddleware.LoggerWithConfig(config)
+}
+
+// redactURI returns the request URI with the redacted parameters masked
+func redactURI(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.RequestURI()
+	}
+
+	masked := *u
+	masked.RawQuery = query.Encode()
+	return masked.RequestURI()
+}
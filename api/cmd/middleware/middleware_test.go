@@ -0,0 +1,146 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const apiKey = "secret-api-key"
+
+func newAuthServer(tokens *middleware.StreamTokens, log *bytes.Buffer) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+	e.Use(middleware.LoggerTo(log))
+	api := e.Group("", middleware.APIKeyAuth(apiKey, tokens))
+	api.GET("/stocks/live", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	api.POST("/stocks/live/token", tokens.Handler)
+	return e
+}
+
+func streamRequest(target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set(echo.HeaderAccept, "text/event-stream")
+	return req
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	tokens := middleware.NewStreamTokens(apiKey, time.Minute)
+
+	t.Run("Accepts the key in the header", func(t *testing.T) {
+		e := newAuthServer(tokens, &bytes.Buffer{})
+		req := streamRequest("/stocks/live")
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200 but got %d", rec.Code)
+		}
+	})
+
+	t.Run("Rejects the key in the query string", func(t *testing.T) {
+		e := newAuthServer(tokens, &bytes.Buffer{})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, streamRequest("/stocks/live?api_key="+apiKey))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 but got %d", rec.Code)
+		}
+	})
+
+	t.Run("Accepts an issued stream token", func(t *testing.T) {
+		e := newAuthServer(tokens, &bytes.Buffer{})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, streamRequest("/stocks/live?stream_token="+tokens.Issue().Token))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200 but got %d", rec.Code)
+		}
+	})
+
+	t.Run("Rejects stream tokens on other requests", func(t *testing.T) {
+		e := newAuthServer(tokens, &bytes.Buffer{})
+		req := httptest.NewRequest(http.MethodGet, "/stocks/live?stream_token="+tokens.Issue().Token, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 but got %d", rec.Code)
+		}
+	})
+
+	t.Run("Issues tokens only with the key", func(t *testing.T) {
+		e := newAuthServer(tokens, &bytes.Buffer{})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stocks/live/token", nil))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 but got %d", rec.Code)
+		}
+	})
+}
+
+func TestStreamTokens(t *testing.T) {
+	tokens := middleware.NewStreamTokens(apiKey, time.Minute)
+
+	t.Run("Issued tokens are valid until they expire", func(t *testing.T) {
+		token := tokens.Issue()
+		if !tokens.Valid(token.Token) {
+			t.Errorf("Expected token %q to be valid", token.Token)
+		}
+		if !token.ExpiresAt.After(time.Now()) || token.ExpiresAt.After(time.Now().Add(time.Minute)) {
+			t.Errorf("Expected expiry within a minute but got %v", token.ExpiresAt)
+		}
+	})
+
+	t.Run("Rejects expired tokens", func(t *testing.T) {
+		expired := middleware.NewStreamTokens(apiKey, time.Nanosecond).Issue()
+		if tokens.Valid(expired.Token) {
+			t.Errorf("Expected expired token %q to be rejected", expired.Token)
+		}
+	})
+
+	t.Run("Rejects forged tokens", func(t *testing.T) {
+		expiry, _, _ := strings.Cut(tokens.Issue().Token, ".")
+		other := middleware.NewStreamTokens("other-key", time.Minute).Issue().Token
+		_, otherSignature, _ := strings.Cut(other, ".")
+
+		for _, token := range []string{"", expiry, expiry + ".", expiry + "." + otherSignature, "9999999999." + otherSignature} {
+			if tokens.Valid(token) {
+				t.Errorf("Expected forged token %q to be rejected", token)
+			}
+		}
+	})
+}
+
+func TestLoggerRedactsCredentials(t *testing.T) {
+	tokens := middleware.NewStreamTokens(apiKey, time.Minute)
+	token := tokens.Issue().Token
+
+	for _, target := range []string{
+		"/stocks/live?api_key=" + apiKey + "&ticker=AAPL",
+		"/stocks/live?stream_token=" + token + "&ticker=AAPL",
+	} {
+		log := &bytes.Buffer{}
+		e := newAuthServer(tokens, log)
+		e.ServeHTTP(httptest.NewRecorder(), streamRequest(target))
+
+		logged := log.String()
+		if strings.Contains(logged, apiKey) || strings.Contains(logged, token) {
+			t.Errorf("Expected credentials to be redacted but got %s", logged)
+		}
+		if !strings.Contains(logged, `"uri":"/stocks/live?`) || !strings.Contains(logged, "ticker=AAPL") {
+			t.Errorf("Expected the redacted URI to be logged but got %s", logged)
+		}
+	}
+}
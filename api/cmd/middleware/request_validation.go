@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"stonks-api/cmd/apierrors"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+)
+
+// specPathParam matches the {name} path parameters of OpenAPI paths
+var specPathParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// RequestValidation middleware rejects requests whose parameters or body do
+// not match the operation documented for their route. basePath is the prefix
+// the routes are mounted under, matching the server URL of the spec. Routes
+// missing from the spec are passed through unchecked.
+func RequestValidation(spec *openapi3.T, basePath string) echo.MiddlewareFunc {
+	// Index the operations by method and Echo route pattern
+	routes := make(map[string]*routers.Route)
+	for path, pathItem := range spec.Paths {
+		echoPath := basePath + specPathParam.ReplaceAllString(path, ":$1")
+		for method, operation := range pathItem.Operations() {
+			routes[method+" "+echoPath] = &routers.Route{
+				Spec:      spec,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    method,
+				Operation: operation,
+			}
+		}
+	}
+
+	// The API key is checked by APIKeyAuth
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route, ok := routes[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return next(c)
+			}
+
+			pathParams := make(map[string]string, len(c.ParamNames()))
+			for i, name := range c.ParamNames() {
+				pathParams[name] = c.ParamValues()[i]
+			}
+
+			err := openapi3filter.ValidateRequest(c.Request().Context(), &openapi3filter.RequestValidationInput{
+				Request:    c.Request(),
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				return validationError(err)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// validationError describes a validation failure without the schema dump
+// kin-openapi appends to its errors
+func validationError(err error) *apierrors.Error {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return apierrors.InvalidInput("Invalid request: " + err.Error())
+	}
+
+	reason := requestErr.Reason
+	field := ""
+	var schemaErr *openapi3.SchemaError
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.As(requestErr.Err, &schemaErr):
+		reason = schemaErr.Reason
+		field = strings.Join(schemaErr.JSONPointer(), ".")
+	case errors.As(requestErr.Err, &parseErr):
+		reason = fmt.Sprintf("%v is %s", parseErr.Value, parseErr.Reason)
+	case requestErr.Err != nil:
+		reason = requestErr.Err.Error()
+	}
+
+	if requestErr.Parameter != nil {
+		return apierrors.InvalidParameter(requestErr.Parameter.Name, "Invalid "+requestErr.Parameter.Name+" parameter: "+reason)
+	}
+
+	if field != "" {
+		return apierrors.InvalidParameter(field, "Invalid request body: "+field+": "+reason)
+	}
+
+	return apierrors.InvalidInput("Invalid request body: " + reason)
+}
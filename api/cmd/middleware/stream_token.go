@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultStreamTokenTTL bounds how long a stream token can be used to open a
+// stream, it only has to outlive the connection attempt
+const DefaultStreamTokenTTL = time.Minute
+
+// StreamTokens issues and checks short-lived tokens that let browser
+// EventSource and WebSocket clients, which cannot set headers, open the live
+// feed without putting the API key in the URL
+type StreamTokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// StreamToken is a token returned to clients with its expiry
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewStreamTokens creates tokens signed with secret. A non-positive ttl uses
+// DefaultStreamTokenTTL.
+func NewStreamTokens(secret string, ttl time.Duration) *StreamTokens {
+	if ttl <= 0 {
+		ttl = DefaultStreamTokenTTL
+	}
+	return &StreamTokens{secret: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+// Issue returns a new token expiring after the ttl
+func (t *StreamTokens) Issue() StreamToken {
+	expiresAt := t.now().Add(t.ttl).UTC().Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return StreamToken{Token: expiry + "." + t.sign(expiry), ExpiresAt: expiresAt}
+}
+
+// Valid reports whether the token was issued here and has not expired
+func (t *StreamTokens) Valid(token string) bool {
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	seconds, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !t.now().Before(time.Unix(seconds, 0)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(t.sign(expiry)))
+}
+
+// Handler issues a token to a client authenticated with the API key
+func (t *StreamTokens) Handler(c echo.Context) error {
+	return c.JSON(http.StatusOK, t.Issue())
+}
+
+func (t *StreamTokens) sign(expiry string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte("stream-token:" + expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,5 @@
+-- Add zone-aware copies of the timestamp columns. CockroachDB cannot change the
+-- type of an indexed column in place, so the data is moved over in steps.
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS time_tz TIMESTAMPTZ;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS updated_at_tz TIMESTAMPTZ;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS created_at_tz TIMESTAMPTZ DEFAULT NOW();
@@ -0,0 +1,6 @@
+-- Existing values were written as UTC wall-clock times, so interpret them as UTC
+UPDATE stocks SET
+    time_tz = time AT TIME ZONE 'UTC',
+    updated_at_tz = updated_at AT TIME ZONE 'UTC',
+    created_at_tz = created_at AT TIME ZONE 'UTC'
+WHERE time_tz IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS stocks@idx_stocks_ticker_time;
+
+ALTER TABLE stocks DROP COLUMN IF EXISTS time;
+ALTER TABLE stocks DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE stocks DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,10 @@
+ALTER TABLE stocks RENAME COLUMN time_tz TO time;
+ALTER TABLE stocks RENAME COLUMN updated_at_tz TO updated_at;
+ALTER TABLE stocks RENAME COLUMN created_at_tz TO created_at;
+
+ALTER TABLE stocks ALTER COLUMN time SET NOT NULL;
+ALTER TABLE stocks ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE stocks ALTER COLUMN created_at SET NOT NULL;
+
+-- Recreate index for faster lookups by ticker and time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stocks_ticker_time ON stocks(ticker, time);
@@ -0,0 +1,2 @@
+-- Create trigram index for fuzzy company name search
+CREATE INDEX IF NOT EXISTS idx_stocks_company_trgm ON stocks USING GIN (company gin_trgm_ops);
@@ -0,0 +1,6 @@
+-- Create indexes backing the filters and sort orders of the stock listing
+CREATE INDEX IF NOT EXISTS idx_stocks_time ON stocks(time DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_stocks_brokerage_time ON stocks(brokerage, time DESC);
+CREATE INDEX IF NOT EXISTS idx_stocks_action_time ON stocks(action, time DESC);
+CREATE INDEX IF NOT EXISTS idx_stocks_rating_to_time ON stocks(rating_to, time DESC);
+CREATE INDEX IF NOT EXISTS idx_stocks_target_to ON stocks(target_to);
@@ -0,0 +1,8 @@
+-- Create sector lookup, the upstream API does not provide sectors
+CREATE TABLE IF NOT EXISTS ticker_sectors (
+    ticker VARCHAR(10) PRIMARY KEY,
+    sector VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticker_sectors_sector ON ticker_sectors(sector);
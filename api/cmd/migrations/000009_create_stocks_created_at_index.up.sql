@@ -0,0 +1,2 @@
+-- Index backing the live feed, which follows stocks in ingestion order
+CREATE INDEX IF NOT EXISTS idx_stocks_created_at ON stocks(created_at, id);
@@ -0,0 +1,2 @@
+-- Index backing the live feed, which follows stocks in the order they change
+CREATE INDEX IF NOT EXISTS idx_stocks_updated_at ON stocks(updated_at, id);
@@ -0,0 +1,12 @@
+-- Create daily recommendation snapshots, one per day and strategy, holding
+-- the ranked entries as JSON
+CREATE TABLE IF NOT EXISTS recommendation_snapshots (
+    snapshot_date DATE NOT NULL,
+    strategy VARCHAR(50) NOT NULL,
+    scoring_version VARCHAR(20) NOT NULL,
+    entries JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (snapshot_date, strategy)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_snapshots_strategy_date ON recommendation_snapshots(strategy, snapshot_date DESC);
@@ -0,0 +1,19 @@
+-- Create rating mappings added at runtime, merged over the built-in rating ladder
+CREATE TABLE IF NOT EXISTS rating_mappings (
+    rating VARCHAR(50) PRIMARY KEY,
+    level VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create the record of rating strings missing from the ladder, refreshed after
+-- every sync
+CREATE TABLE IF NOT EXISTS unmapped_ratings (
+    rating VARCHAR(50) PRIMARY KEY,
+    occurrences INT NOT NULL,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_unmapped_ratings_occurrences ON unmapped_ratings(occurrences DESC);
@@ -0,0 +1,2 @@
+-- The live feed follows stocks by updated_at, see 000010
+DROP INDEX IF EXISTS stocks@idx_stocks_created_at;
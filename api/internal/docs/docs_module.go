@@ -0,0 +1,34 @@
+package docs
+
+import (
+	"stonks-api/internal/docs/handlers"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+type Module struct {
+	DocsHandler *handlers.DocsHandler
+	Spec        *openapi3.T
+}
+
+func NewModule() (*Module, error) {
+	spec, err := LoadSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	docsHandler, err := handlers.NewDocsHandler(spec, SpecYAML())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Module{
+		DocsHandler: docsHandler,
+		Spec:        spec,
+	}, nil
+}
+
+func (m *Module) RegisterRoutes(e *echo.Group) {
+	m.DocsHandler.RegisterRoutes(e)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+// swaggerUIVersion pins the Swagger UI assets loaded by the docs page
+const swaggerUIVersion = "5.17.14"
+
+// docsPage renders Swagger UI against the served OpenAPI document
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Stonks API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+type DocsHandler struct {
+	specJSON []byte
+	specYAML []byte
+}
+
+func NewDocsHandler(spec *openapi3.T, specYAML []byte) (*DocsHandler, error) {
+	specJSON, err := spec.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocsHandler{
+		specJSON: specJSON,
+		specYAML: specYAML,
+	}, nil
+}
+
+// GetDocs serves the interactive API documentation
+func (h *DocsHandler) GetDocs(c echo.Context) error {
+	specURL := strings.TrimSuffix(c.Request().URL.Path, "/") + "/openapi.json"
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	return docsPage.Execute(c.Response(), map[string]string{
+		"Version": swaggerUIVersion,
+		"SpecURL": specURL,
+	})
+}
+
+// GetSpecJSON serves the OpenAPI document as JSON
+func (h *DocsHandler) GetSpecJSON(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, h.specJSON)
+}
+
+// GetSpecYAML serves the OpenAPI document as YAML
+func (h *DocsHandler) GetSpecYAML(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/yaml", h.specYAML)
+}
+
+// RegisterRoutes registers the documentation routes, which need no API key
+func (h *DocsHandler) RegisterRoutes(e *echo.Group) {
+	e.GET("", h.GetDocs)
+	e.GET("/openapi.json", h.GetSpecJSON)
+	e.GET("/openapi.yaml", h.GetSpecYAML)
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"stonks-api/internal/docs"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newTestServer(t *testing.T) *echo.Echo {
+	module, err := docs.NewModule()
+	if err != nil {
+		t.Fatalf("Expected docs module to load, but got %v", err)
+	}
+
+	e := echo.New()
+	module.RegisterRoutes(e.Group("/docs"))
+	return e
+}
+
+func TestDocs(t *testing.T) {
+	// The UI loads the JSON document served next to it
+	t.Run("docs page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newTestServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+		}
+
+		if !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMETextHTML) {
+			t.Errorf("Expected an HTML page but got %s", rec.Header().Get(echo.HeaderContentType))
+		}
+
+		if !strings.Contains(rec.Body.String(), `"/docs/openapi.json"`) {
+			t.Errorf("Expected the page to load /docs/openapi.json but got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("json document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newTestServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+		}
+
+		var spec struct {
+			OpenAPI string                 `json:"openapi"`
+			Paths   map[string]interface{} `json:"paths"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+			t.Fatalf("Expected a JSON document, but got %v", err)
+		}
+
+		if spec.OpenAPI != "3.0.3" {
+			t.Errorf("Expected OpenAPI 3.0.3 but got %s", spec.OpenAPI)
+		}
+
+		if _, ok := spec.Paths["/stocks"]; !ok {
+			t.Errorf("Expected /stocks to be documented")
+		}
+	})
+
+	t.Run("yaml document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newTestServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.yaml", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+		}
+
+		if !strings.HasPrefix(rec.Body.String(), "openapi: 3.0.3") {
+			t.Errorf("Expected the YAML document but got %.40s", rec.Body.String())
+		}
+	})
+}
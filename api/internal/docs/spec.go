@@ -0,0 +1,34 @@
+package docs
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// specYAML is the OpenAPI document describing the REST API. It is kept in
+// sync with the routes registered by the handlers, which the tests enforce.
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+// SpecYAML returns the OpenAPI document as written
+func SpecYAML() []byte {
+	return specYAML
+}
+
+// LoadSpec parses and validates the OpenAPI document
+func LoadSpec() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse OpenAPI spec: %v", err)
+	}
+
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,126 @@
+package docs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/docs"
+	graphqlHandlers "stonks-api/internal/graphql/handlers"
+	"stonks-api/internal/graphql/schema"
+	recommendationHandlers "stonks-api/internal/recommendations/handlers"
+	recommendationMocks "stonks-api/internal/recommendations/mocks"
+	"stonks-api/internal/stocks/handlers"
+	"stonks-api/internal/stocks/mocks"
+	"stonks-api/internal/stocks/services"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const basePath = "/api/v1/stonks-api"
+
+// newTestServer registers every module's routes like the application does
+func newTestServer(t *testing.T, repo *mocks.MockRepository) *echo.Echo {
+	spec, err := docs.LoadSpec()
+	if err != nil {
+		t.Fatalf("Expected spec to load, but got %v", err)
+	}
+
+	stockService := services.NewStockService(repo)
+	recommendationService := &recommendationMocks.MockRecommendationService{}
+	s, err := schema.NewSchema(stockService, recommendationService)
+	if err != nil {
+		t.Fatalf("Expected schema to build, but got %v", err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+	api := e.Group(basePath)
+	api.Use(middleware.RequestValidation(spec, basePath))
+	handlers.NewStockHandler(stockService).RegisterRoutes(api)
+	recommendationHandlers.NewRecommendationHandler(recommendationService).RegisterRoutes(api)
+	graphqlHandlers.NewGraphQLHandler(s, schema.DefaultLimits).RegisterRoutes(api)
+	api.POST("/stocks/live/token", middleware.NewStreamTokens("key", middleware.DefaultStreamTokenTTL).Handler)
+
+	admin := e.Group(basePath + "/admin")
+	admin.Use(middleware.RequestValidation(spec, basePath))
+	handlers.NewStockHandler(stockService).RegisterAdminRoutes(admin)
+	recommendationHandlers.NewRecommendationHandler(recommendationService).RegisterAdminRoutes(admin)
+
+	return e
+}
+
+func TestSpecMatchesRoutes(t *testing.T) {
+	spec, err := docs.LoadSpec()
+	if err != nil {
+		t.Fatalf("Expected spec to load, but got %v", err)
+	}
+
+	pathParam := regexp.MustCompile(`\{([^}/]+)\}`)
+	var documented []string
+	for path, pathItem := range spec.Paths {
+		for method := range pathItem.Operations() {
+			documented = append(documented, method+" "+basePath+pathParam.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	var registered []string
+	for _, route := range newTestServer(t, &mocks.MockRepository{}).Routes() {
+		if route.Method != echo.RouteNotFound && strings.HasPrefix(route.Path, basePath) && !strings.HasSuffix(route.Path, "*") {
+			registered = append(registered, route.Method+" "+route.Path)
+		}
+	}
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+
+	if strings.Join(documented, "\n") != strings.Join(registered, "\n") {
+		t.Errorf("Expected the spec to document the registered routes\n%s\nbut got\n%s",
+			strings.Join(registered, "\n"), strings.Join(documented, "\n"))
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	requests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		error  string
+	}{
+		{"valid page", http.MethodGet, "/stocks?page=2&page_size=10", "", http.StatusOK, ""},
+		{"page not a number", http.MethodGet, "/stocks?page=abc", "", http.StatusBadRequest, "Invalid page parameter"},
+		{"page size too big", http.MethodGet, "/stocks?page_size=1000", "", http.StatusBadRequest, "Invalid page_size parameter"},
+		{"unknown count mode", http.MethodGet, "/stocks?count=maybe", "", http.StatusBadRequest, "Invalid count parameter"},
+		{"brokerage calls page", http.MethodGet, "/brokerages/Example%20Brokerage/calls?page=-1", "", http.StatusBadRequest, "Invalid page parameter"},
+		{"missing search query", http.MethodGet, "/stocks/search", "", http.StatusBadRequest, "Invalid q parameter"},
+		{"lookup events out of range", http.MethodPost, "/stocks/lookup", `{"tickers": ["AAPL"], "events": 99}`, http.StatusBadRequest, "Invalid request body"},
+		{"lookup without tickers", http.MethodPost, "/stocks/lookup", `{"tickers": []}`, http.StatusBadRequest, "Invalid request body"},
+	}
+
+	for _, tc := range requests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestServer(t, &mocks.MockRepository{})
+
+			req := httptest.NewRequest(tc.method, basePath+tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("Expected status code %d but got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+
+			if tc.error != "" && !strings.Contains(rec.Body.String(), tc.error) {
+				t.Errorf("Expected error containing %q but got %s", tc.error, rec.Body.String())
+			}
+		})
+	}
+}
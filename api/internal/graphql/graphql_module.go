@@ -0,0 +1,28 @@
+package graphql
+
+import (
+	"stonks-api/internal/graphql/handlers"
+	"stonks-api/internal/graphql/schema"
+	recommendationServices "stonks-api/internal/recommendations/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Module struct {
+	GraphQLHandler *handlers.GraphQLHandler
+}
+
+func NewModule(stockService schema.StockService, recommendationService recommendationServices.RecommendationServiceInterface) (*Module, error) {
+	s, err := schema.NewSchema(stockService, recommendationService)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Module{
+		GraphQLHandler: handlers.NewGraphQLHandler(s, schema.DefaultLimits),
+	}, nil
+}
+
+func (m *Module) RegisterRoutes(e *echo.Group) {
+	m.GraphQLHandler.RegisterRoutes(e)
+}
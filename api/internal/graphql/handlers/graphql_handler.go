@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/graphql/schema"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/labstack/echo/v4"
+)
+
+// graphQLRequest is the body of a GraphQL POST request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+type GraphQLHandler struct {
+	schema graphql.Schema
+	limits schema.Limits
+}
+
+func NewGraphQLHandler(s graphql.Schema, limits schema.Limits) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: s,
+		limits: limits,
+	}
+}
+
+// Execute handles GraphQL queries sent as a JSON body (POST) or as query
+// parameters (GET)
+func (h *GraphQLHandler) Execute(c echo.Context) error {
+	var req graphQLRequest
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+		if variables := c.QueryParam("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return c.JSON(http.StatusBadRequest, errorResult("Invalid variables parameter: "+err.Error()))
+			}
+		}
+	} else if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResult("Invalid request body: "+err.Error()))
+	}
+
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, errorResult("A query is required"))
+	}
+
+	if err := h.limits.Check(req.Query, req.Variables, req.OperationName); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResult(err.Error()))
+	}
+
+	// Queries may select summaries and recommendations over windows ending now
+	middleware.NowRelative(c)
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        c.Request().Context(),
+	})
+
+	// Queries that never executed are client errors, resolver errors are
+	// reported next to the partial data
+	if result.Data == nil && result.HasErrors() {
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// errorResult wraps a message in the GraphQL response format
+func errorResult(message string) *graphql.Result {
+	return &graphql.Result{
+		Errors: []gqlerrors.FormattedError{{Message: message}},
+	}
+}
+
+func (h *GraphQLHandler) RegisterRoutes(e *echo.Group) {
+	e.GET("/graphql", h.Execute)
+	e.POST("/graphql", h.Execute)
+}
@@ -0,0 +1,248 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stonks-api/internal/graphql/handlers"
+	"stonks-api/internal/graphql/schema"
+	recommendationMocks "stonks-api/internal/recommendations/mocks"
+	recommendationServices "stonks-api/internal/recommendations/services"
+	"stonks-api/internal/stocks/mocks"
+	"stonks-api/internal/stocks/models"
+	"stonks-api/internal/stocks/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newTestHandler(t *testing.T, repo *mocks.MockRepository, recommendations *recommendationMocks.MockRecommendationService) *handlers.GraphQLHandler {
+	s, err := schema.NewSchema(services.NewStockService(repo), recommendations)
+	if err != nil {
+		t.Fatalf("Expected schema to build, but got %v", err)
+	}
+	return handlers.NewGraphQLHandler(s, schema.DefaultLimits)
+}
+
+func postQuery(t *testing.T, h *handlers.GraphQLHandler, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/stonks-api/graphql", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.Execute(c); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	return rec
+}
+
+func TestExecute(t *testing.T) {
+	// Stock filters and pagination are passed to the service
+	t.Run("stocks with filters", func(t *testing.T) {
+		var got models.PaginationParams
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				got = params
+				return models.PaginatedStocks{
+					Stocks:     []models.Stock{{ID: "1", Ticker: "AAPL", Company: "Apple Inc.", RatingTo: "Strong-Buy", Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+					TotalCount: 1,
+					Page:       1,
+					PageSize:   5,
+					TotalPages: 1,
+				}, nil
+			},
+		}
+
+		body := `{"query": "query($size: Int) { stocks(page_size: $size, ticker: [\"aapl\"], rating_category: POSITIVE, rating: [STRONG_BUY], sort: TARGET_TO, desc: true, tz: \"America/New_York\") { total_count stocks { ticker time rating } } }", "variables": {"size": 5}}`
+		rec := postQuery(t, newTestHandler(t, repo, &recommendationMocks.MockRecommendationService{}), body)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if got.PageSize != 5 || len(got.Filter.Tickers) != 1 || got.Filter.Tickers[0] != "AAPL" {
+			t.Errorf("Expected page size 5 for AAPL but got %+v", got)
+		}
+
+		if got.Filter.RatingCategory != models.RatingCategoryPositive || got.Filter.SortField != models.SortByTargetTo || !got.Filter.SortDesc {
+			t.Errorf("Expected positive category sorted by target_to desc but got %+v", got.Filter)
+		}
+
+		if len(got.Filter.Ratings) != 1 || got.Filter.Ratings[0] != models.RatingStrongBuy {
+			t.Errorf("Expected the strong buy rating filter but got %v", got.Filter.Ratings)
+		}
+
+		if !strings.Contains(rec.Body.String(), `"rating":"STRONG_BUY"`) {
+			t.Errorf("Expected the normalized rating but got %s", rec.Body.String())
+		}
+
+		if !strings.Contains(rec.Body.String(), `"time":"2024-12-31T19:00:00-05:00"`) {
+			t.Errorf("Expected time in the requested zone but got %s", rec.Body.String())
+		}
+	})
+
+	// Ticker history and recommendations in one round trip
+	t.Run("ticker and recommendations", func(t *testing.T) {
+		repo := &mocks.MockRepository{
+			GetStocksByTickerFn: func(ticker string) ([]models.Stock, error) {
+				return []models.Stock{{ID: "1", Ticker: ticker}, {ID: "2", Ticker: ticker}}, nil
+			},
+		}
+		recommendations := &recommendationMocks.MockRecommendationService{
+			GetRecommendationsFn: func(options recommendationServices.RecommendationOptions) (recommendationServices.Recommendations, error) {
+				return recommendationServices.Recommendations{Recommendations: []recommendationServices.StockRecommendation{
+					{Stock: models.Stock{ID: "3", Ticker: "MSFT"}, Score: 4.5, Reason: "Upgraded", Factors: []recommendationServices.ScoreFactor{
+						{ID: "action_upgraded", Description: "Upgraded", Contribution: 4.5, Inputs: map[string]interface{}{"action": "upgraded by"}},
+					}},
+				}}, nil
+			},
+		}
+
+		body := `{"query": "{ ticker(symbol: \"msft\") { ticker history(limit: 1) { id } } recommendations { score stock { ticker } factors { id contribution inputs { name value } } } }"}`
+		rec := postQuery(t, newTestHandler(t, repo, recommendations), body)
+
+		var result struct {
+			Data struct {
+				Ticker struct {
+					Ticker  string `json:"ticker"`
+					History []struct {
+						ID string `json:"id"`
+					} `json:"history"`
+				} `json:"ticker"`
+				Recommendations []struct {
+					Score   float64 `json:"score"`
+					Factors []struct {
+						ID     string `json:"id"`
+						Inputs []struct {
+							Name  string `json:"name"`
+							Value string `json:"value"`
+						} `json:"inputs"`
+					} `json:"factors"`
+				} `json:"recommendations"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Expected JSON response, but got %v", err)
+		}
+
+		if result.Data.Ticker.Ticker != "MSFT" || len(result.Data.Ticker.History) != 1 {
+			t.Errorf("Expected one MSFT event but got %s", rec.Body.String())
+		}
+
+		if len(result.Data.Recommendations) != 1 || result.Data.Recommendations[0].Score != 4.5 {
+			t.Fatalf("Expected one recommendation but got %s", rec.Body.String())
+		}
+
+		factors := result.Data.Recommendations[0].Factors
+		if len(factors) != 1 || factors[0].ID != "action_upgraded" || len(factors[0].Inputs) != 1 || factors[0].Inputs[0].Value != "upgraded by" {
+			t.Errorf("Expected the upgrade factor with its action but got %s", rec.Body.String())
+		}
+	})
+
+	// The negative direction ranks the tickers to avoid
+	t.Run("tickers to avoid", func(t *testing.T) {
+		var got recommendationServices.RecommendationOptions
+		recommendations := &recommendationMocks.MockRecommendationService{
+			GetRecommendationsFn: func(options recommendationServices.RecommendationOptions) (recommendationServices.Recommendations, error) {
+				got = options
+				return recommendationServices.Recommendations{}, nil
+			},
+		}
+
+		body := `{"query": "{ recommendations(direction: NEGATIVE, max_score: -2) { score } }"}`
+		rec := postQuery(t, newTestHandler(t, &mocks.MockRepository{}, recommendations), body)
+
+		if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "errors") {
+			t.Fatalf("Expected no errors but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if got.Direction != recommendationServices.DirectionNegative || got.MaxScore == nil || *got.MaxScore != -2 {
+			t.Errorf("Expected the negative direction with max score -2 but got %+v", got)
+		}
+	})
+
+	// Queries over the limits are rejected before execution
+	t.Run("limits", func(t *testing.T) {
+		called := false
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				called = true
+				return models.PaginatedStocks{}, nil
+			},
+		}
+		h := newTestHandler(t, repo, &recommendationMocks.MockRecommendationService{})
+
+		tooDeep := `{"query": "{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } } }"}`
+		rec := postQuery(t, h, tooDeep)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "depth") {
+			t.Errorf("Expected depth error but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		tooComplex := `{"query": "{ a: stocks(page_size: 100) { stocks { id ticker company brokerage action rating_from rating_to target_from target_to time } } b: stocks(page: 2, page_size: 100) { stocks { id ticker company brokerage action rating_from rating_to target_from target_to time } } recommendations(limit: 200) { stock { id ticker company } } }"}`
+		rec = postQuery(t, h, tooComplex)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "complexity") {
+			t.Errorf("Expected complexity error but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		// Each fragment spreads the next one twice, doubling the cost at every level
+		var fragments strings.Builder
+		for i := 0; i < 40; i++ {
+			fmt.Fprintf(&fragments, " fragment F%d on Stock { ...F%d ...F%d }", i, i+1, i+1)
+		}
+		fragments.WriteString(" fragment F40 on Stock { ticker }")
+		exponential := `{"query": "{ stocks(page_size: 1) { stocks { ...F0 } } }` + fragments.String() + `"}`
+		start := time.Now()
+		rec = postQuery(t, h, exponential)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "complexity") {
+			t.Errorf("Expected complexity error but got %d: %s", rec.Code, rec.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the fragments to be costed once but took %v", elapsed)
+		}
+
+		tooManySpreads := `{"query": "{ stocks { stocks { ` + strings.Repeat("...F ", 101) + `} } } fragment F on Stock { ticker }"}`
+		rec = postQuery(t, h, tooManySpreads)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "fragment spreads") {
+			t.Errorf("Expected fragment spreads error but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if called {
+			t.Errorf("Expected rejected queries not to reach the service")
+		}
+	})
+
+	// History is bounded like its cost estimate
+	t.Run("history limit", func(t *testing.T) {
+		events := make([]models.Stock, 150)
+		repo := &mocks.MockRepository{
+			GetStocksByTickerFn: func(ticker string) ([]models.Stock, error) {
+				return events, nil
+			},
+		}
+		h := newTestHandler(t, repo, &recommendationMocks.MockRecommendationService{})
+
+		rec := postQuery(t, h, `{"query": "{ ticker(symbol: \"AAPL\") { history { id } } }"}`)
+		if count := strings.Count(rec.Body.String(), `"id"`); count != 100 {
+			t.Errorf("Expected the default of 100 events but got %d", count)
+		}
+
+		rec = postQuery(t, h, `{"query": "{ ticker(symbol: \"AAPL\") { history(limit: 1000) { id } } }"}`)
+		if !strings.Contains(rec.Body.String(), "limit must be between 1 and 500") {
+			t.Errorf("Expected limit error but got %s", rec.Body.String())
+		}
+	})
+
+	// Invalid arguments
+	t.Run("invalid page size", func(t *testing.T) {
+		rec := postQuery(t, newTestHandler(t, &mocks.MockRepository{}, &recommendationMocks.MockRecommendationService{}),
+			`{"query": "{ stocks(page_size: 1000) { total_count } }"}`)
+
+		if !strings.Contains(rec.Body.String(), "page_size must be between 1 and 100") {
+			t.Errorf("Expected page size error but got %s", rec.Body.String())
+		}
+	})
+}
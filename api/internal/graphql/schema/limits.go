@@ -0,0 +1,222 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Limits bounds the cost of a query before it is executed. MaxFragmentSpreads
+// caps the fragment spreads written in the whole document.
+type Limits struct {
+	MaxDepth           int
+	MaxComplexity      int
+	MaxFragmentSpreads int
+}
+
+// DefaultLimits allows every query the frontend makes with room to spare. The
+// depth leaves room for the standard introspection query used by tooling.
+var DefaultLimits = Limits{MaxDepth: 12, MaxComplexity: 2500, MaxFragmentSpreads: 100}
+
+// maxCost bounds the depth and complexity computed for a query, so fragments
+// multiplying each other cannot overflow into an accepted value
+const maxCost = math.MaxInt32
+
+// listSizeArgs names the argument bounding each list field, keyed by parent
+// and field name (root fields have no parent), and the size assumed when it
+// is omitted
+var listSizeArgs = map[string]struct {
+	arg         string
+	defaultSize int
+}{
+	".stocks":          {"page_size", 20},
+	"ticker.history":   {"limit", defaultHistoryLimit},
+	".brokerages":      {"limit", 20},
+	".recommendations": {"limit", 20},
+}
+
+// Check parses the query and rejects it when its depth or complexity exceeds
+// the limits. Every field costs 1, and the selections under a list field are
+// multiplied by its page size or limit.
+func (l Limits) Check(query string, variables map[string]interface{}, operationName string) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return err
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operations []*ast.OperationDefinition
+	spreads := 0
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+			spreads += countSpreads(d.SelectionSet)
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operations = append(operations, d)
+			}
+			spreads += countSpreads(d.SelectionSet)
+		}
+	}
+	if l.MaxFragmentSpreads > 0 && spreads > l.MaxFragmentSpreads {
+		return fmt.Errorf("query has %d fragment spreads, more than the limit of %d", spreads, l.MaxFragmentSpreads)
+	}
+
+	c := costCounter{
+		fragments: fragments,
+		variables: variables,
+		expanding: map[string]bool{},
+		costs:     map[string]cost{},
+	}
+	for _, operation := range operations {
+		depth, complexity := c.selectionSet(operation.SelectionSet, "")
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+// countSpreads returns the number of fragment spreads written in the selections
+func countSpreads(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	count := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			count += countSpreads(s.SelectionSet)
+		case *ast.InlineFragment:
+			count += countSpreads(s.SelectionSet)
+		case *ast.FragmentSpread:
+			count++
+		}
+	}
+	return count
+}
+
+// cost is the depth and complexity of a fragment under a parent field
+type cost struct {
+	depth, complexity int
+}
+
+type costCounter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// expanding holds the fragments being expanded, so cycles cannot recurse forever
+	expanding map[string]bool
+	// costs holds the fragments already expanded, keyed by parent and name, so
+	// each is walked once however many times it is spread
+	costs map[string]cost
+}
+
+// selectionSet returns the depth and complexity of the selections
+func (c costCounter) selectionSet(set *ast.SelectionSet, parent string) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	maxDepth, total := 0, 0
+	for _, selection := range set.Selections {
+		var depth, complexity int
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			childDepth, childComplexity := c.selectionSet(s.SelectionSet, s.Name.Value)
+			depth = min(childDepth+1, maxCost)
+			complexity = saturatingAdd(1, saturatingMul(c.listSize(parent, s), childComplexity))
+		case *ast.InlineFragment:
+			depth, complexity = c.selectionSet(s.SelectionSet, parent)
+		case *ast.FragmentSpread:
+			depth, complexity = c.fragmentSpread(s.Name.Value, parent)
+		}
+
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		total = saturatingAdd(total, complexity)
+	}
+
+	return maxDepth, total
+}
+
+// fragmentSpread returns the depth and complexity of a fragment spread under
+// parent, walking the fragment the first time only
+func (c costCounter) fragmentSpread(name, parent string) (int, int) {
+	key := parent + "." + name
+	if known, ok := c.costs[key]; ok {
+		return known.depth, known.complexity
+	}
+
+	fragment, ok := c.fragments[name]
+	if !ok || c.expanding[name] {
+		return 0, 0
+	}
+
+	c.expanding[name] = true
+	depth, complexity := c.selectionSet(fragment.SelectionSet, parent)
+	delete(c.expanding, name)
+
+	c.costs[key] = cost{depth: depth, complexity: complexity}
+	return depth, complexity
+}
+
+// saturatingAdd adds costs, stopping at maxCost
+func saturatingAdd(a, b int) int {
+	if a > maxCost-b {
+		return maxCost
+	}
+	return a + b
+}
+
+// saturatingMul multiplies costs, stopping at maxCost
+func saturatingMul(a, b int) int {
+	if a != 0 && b > maxCost/a {
+		return maxCost
+	}
+	return a * b
+}
+
+// listSize returns the number of items a list field may return
+func (c costCounter) listSize(parent string, field *ast.Field) int {
+	size, ok := listSizeArgs[parent+"."+field.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != size.arg {
+			continue
+		}
+
+		switch v := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			switch n := c.variables[v.Name.Value].(type) {
+			case float64:
+				if n > 0 {
+					return int(n)
+				}
+			case int:
+				if n > 0 {
+					return n
+				}
+			}
+		}
+	}
+
+	return size.defaultSize
+}
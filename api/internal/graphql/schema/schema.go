@@ -0,0 +1,624 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	recommendationServices "stonks-api/internal/recommendations/services"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// maxPageSize caps the stocks page size, matching the REST listing
+const maxPageSize = 100
+
+// Default and maximum number of events returned by a ticker's history. The
+// default is the size the complexity check assumes when limit is omitted.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 500
+)
+
+// StockService is the part of the stock service exposed over GraphQL
+type StockService interface {
+	GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error)
+	GetStocksByTicker(ticker string) ([]models.Stock, error)
+	GetTickerSummary(ticker string, window time.Duration) (models.TickerSummary, error)
+	GetBrokerageLeaderboard(window time.Duration, sortField string, sortAsc bool, limit int) (models.BrokerageLeaderboard, error)
+}
+
+// tickerSource is the value resolved for the ticker query, its fields load lazily
+type tickerSource struct {
+	ticker string
+	loc    *time.Location
+}
+
+// distributionEntry is one rating category count of a summary
+type distributionEntry struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// NewSchema builds the GraphQL schema over the stock and recommendation
+// services. Output fields use the same snake_case names as the REST API.
+func NewSchema(stockService StockService, recommendationService recommendationServices.RecommendationServiceInterface) (graphql.Schema, error) {
+	ratingValues := graphql.EnumValueConfigMap{}
+	for _, level := range models.RatingLevels {
+		ratingValues[strings.ToUpper(level.String())] = &graphql.EnumValueConfig{Value: level}
+	}
+	ratingEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "Rating",
+		Description: "Level of a rating on the ladder from strong sell to strong buy",
+		Values:      ratingValues,
+	})
+
+	stockType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Stock",
+		Description: "A rating event published by a brokerage",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"ticker":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"company":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"brokerage":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"action":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rating_from": &graphql.Field{Type: graphql.String},
+			"rating_to":   &graphql.Field{Type: graphql.String},
+			"rating": &graphql.Field{
+				Type:        ratingEnum,
+				Description: "Normalized new rating, null when unmapped",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if level := p.Source.(models.Stock).NormalizedRating(); level != models.RatingUnknown {
+						return level, nil
+					}
+					return nil, nil
+				},
+			},
+			"target_from": &graphql.Field{Type: graphql.Float},
+			"target_to":   &graphql.Field{Type: graphql.Float},
+			"time":        &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	stockPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StockPage",
+		Fields: graphql.Fields{
+			"stocks":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockType)))},
+			"total_count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"page_size":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"page":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"total_pages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"next_cursor": &graphql.Field{Type: graphql.String},
+			"prev_cursor": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	brokerageRatingType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BrokerageRating",
+		Fields: graphql.Fields{
+			"brokerage":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"action":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rating_from":     &graphql.Field{Type: graphql.String},
+			"rating_to":       &graphql.Field{Type: graphql.String},
+			"rating_category": &graphql.Field{Type: graphql.String},
+			"rating": &graphql.Field{
+				Type: ratingEnum,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if level := p.Source.(models.BrokerageRating).Rating; level != models.RatingUnknown {
+						return level, nil
+					}
+					return nil, nil
+				},
+			},
+			"target_from": &graphql.Field{Type: graphql.Float},
+			"target_to":   &graphql.Field{Type: graphql.Float},
+			"time":        &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	targetStatsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TargetStats",
+		Fields: graphql.Fields{
+			"mean":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"median": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"high":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"low":    &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"count":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	distributionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RatingDistribution",
+		Fields: graphql.Fields{
+			"category": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"count":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	summaryType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TickerSummary",
+		Description: "The analyst consensus on a ticker over a time window",
+		Fields: graphql.Fields{
+			"ticker":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"company":          &graphql.Field{Type: graphql.String},
+			"window_start":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"window_end":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"latest_ratings":   &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(brokerageRatingType))},
+			"consensus_rating": &graphql.Field{Type: graphql.String},
+			"consensus_score":  &graphql.Field{Type: graphql.Float},
+			"rating_distribution": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(distributionType)),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					summary := p.Source.(models.TickerSummary)
+					entries := []distributionEntry{}
+					for _, category := range []string{models.RatingCategoryPositive, models.RatingCategoryNeutral, models.RatingCategoryNegative} {
+						if count, ok := summary.RatingDistribution[category]; ok {
+							entries = append(entries, distributionEntry{Category: category, Count: count})
+						}
+					}
+					return entries, nil
+				},
+			},
+			"target":              &graphql.Field{Type: targetStatsType},
+			"covering_brokerages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"upgrades":            &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"downgrades":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"total_events":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	tickerType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Ticker",
+		Description: "A ticker with its rating history and consensus",
+		Fields: graphql.Fields{
+			"ticker": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(tickerSource).ticker, nil
+				},
+			},
+			"history": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockType))),
+				Description: "Rating events, newest first",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultHistoryLimit},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit := p.Args["limit"].(int)
+					if limit < 1 || limit > maxHistoryLimit {
+						return nil, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+					}
+
+					source := p.Source.(tickerSource)
+					stocks, err := stockService.GetStocksByTicker(source.ticker)
+					if err != nil {
+						return nil, err
+					}
+					if limit < len(stocks) {
+						stocks = stocks[:limit]
+					}
+					return inLocation(stocks, source.loc), nil
+				},
+			},
+			"summary": &graphql.Field{
+				Type: summaryType,
+				Args: graphql.FieldConfigArgument{
+					"window": &graphql.ArgumentConfig{Type: graphql.String, Description: "Lookback such as 30d, 12w, 6m or 1y"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					source := p.Source.(tickerSource)
+					window, err := models.ParseWindow(stringArg(p, "window"))
+					if err != nil {
+						return nil, err
+					}
+					summary, err := stockService.GetTickerSummary(source.ticker, window)
+					if err != nil {
+						return nil, err
+					}
+					if summary.TotalEvents == 0 {
+						return nil, nil
+					}
+					return summary.InLocation(source.loc), nil
+				},
+			},
+		},
+	})
+
+	brokerageStatsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BrokerageStats",
+		Fields: graphql.Fields{
+			"brokerage":               &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"calls":                   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"upgrades":                &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"downgrades":              &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"upgrade_downgrade_ratio": &graphql.Field{Type: graphql.Float},
+			"avg_target_change":       &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"tickers_covered":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"last_activity":           &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	factorInputType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ScoreFactorInput",
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	factorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ScoreFactor",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"contribution": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"inputs": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(factorInputType))),
+				Description: "Values the factor was computed from, sorted by name",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return factorInputs(p.Source.(recommendationServices.ScoreFactor)), nil
+				},
+			},
+		},
+	})
+
+	recommendationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Recommendation",
+		Fields: graphql.Fields{
+			"stock":   &graphql.Field{Type: graphql.NewNonNull(stockType)},
+			"score":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"reason":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"factors": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(factorType)))},
+		},
+	})
+
+	ratingCategoryEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "RatingCategory",
+		Values: graphql.EnumValueConfigMap{
+			"POSITIVE": &graphql.EnumValueConfig{Value: models.RatingCategoryPositive},
+			"NEUTRAL":  &graphql.EnumValueConfig{Value: models.RatingCategoryNeutral},
+			"NEGATIVE": &graphql.EnumValueConfig{Value: models.RatingCategoryNegative},
+		},
+	})
+
+	directionEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "Direction",
+		Description: "End of the scores ranked, the tickers to buy or to avoid",
+		Values: graphql.EnumValueConfigMap{
+			"POSITIVE": &graphql.EnumValueConfig{Value: recommendationServices.DirectionPositive},
+			"NEGATIVE": &graphql.EnumValueConfig{Value: recommendationServices.DirectionNegative},
+		},
+	})
+
+	stockSortValues := graphql.EnumValueConfigMap{}
+	for _, field := range models.StockSortFields {
+		stockSortValues[strings.ToUpper(field)] = &graphql.EnumValueConfig{Value: field}
+	}
+	stockSortEnum := graphql.NewEnum(graphql.EnumConfig{Name: "StockSort", Values: stockSortValues})
+
+	brokerageSortValues := graphql.EnumValueConfigMap{}
+	for _, field := range models.BrokerageSortFields {
+		brokerageSortValues[strings.ToUpper(field)] = &graphql.EnumValueConfig{Value: field}
+	}
+	brokerageSortEnum := graphql.NewEnum(graphql.EnumConfig{Name: "BrokerageSort", Values: brokerageSortValues})
+
+	tzArg := &graphql.ArgumentConfig{Type: graphql.String, Description: "IANA time zone used to render timestamps"}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"stocks": &graphql.Field{
+				Type:        graphql.NewNonNull(stockPageType),
+				Description: "Filtered, paginated rating events",
+				Args: graphql.FieldConfigArgument{
+					"page":              &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"page_size":         &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+					"cursor":            &graphql.ArgumentConfig{Type: graphql.String},
+					"ticker":            &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"brokerage":         &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"action":            &graphql.ArgumentConfig{Type: graphql.String},
+					"rating_from":       &graphql.ArgumentConfig{Type: graphql.String},
+					"rating_to":         &graphql.ArgumentConfig{Type: graphql.String},
+					"rating_category":   &graphql.ArgumentConfig{Type: ratingCategoryEnum},
+					"rating":            &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(ratingEnum))},
+					"sector":            &graphql.ArgumentConfig{Type: graphql.String},
+					"from":              &graphql.ArgumentConfig{Type: graphql.DateTime},
+					"to":                &graphql.ArgumentConfig{Type: graphql.DateTime},
+					"target_min":        &graphql.ArgumentConfig{Type: graphql.Float},
+					"target_max":        &graphql.ArgumentConfig{Type: graphql.Float},
+					"target_change_min": &graphql.ArgumentConfig{Type: graphql.Float},
+					"target_change_max": &graphql.ArgumentConfig{Type: graphql.Float},
+					"sort":              &graphql.ArgumentConfig{Type: stockSortEnum},
+					"desc":              &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"tz":                tzArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					loc, err := models.LoadLocation(stringArg(p, "tz"))
+					if err != nil {
+						return nil, fmt.Errorf("invalid tz %q", stringArg(p, "tz"))
+					}
+
+					params, err := paginationParams(p)
+					if err != nil {
+						return nil, err
+					}
+
+					result, err := stockService.GetAllStocks(params)
+					if err != nil {
+						return nil, err
+					}
+					result.Stocks = inLocation(result.Stocks, loc)
+					return result, nil
+				},
+			},
+			"ticker": &graphql.Field{
+				Type:        tickerType,
+				Description: "History and consensus of one ticker",
+				Args: graphql.FieldConfigArgument{
+					"symbol": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"tz":     tzArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					loc, err := models.LoadLocation(stringArg(p, "tz"))
+					if err != nil {
+						return nil, fmt.Errorf("invalid tz %q", stringArg(p, "tz"))
+					}
+					return tickerSource{ticker: strings.ToUpper(strings.TrimSpace(stringArg(p, "symbol"))), loc: loc}, nil
+				},
+			},
+			"brokerages": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(brokerageStatsType))),
+				Description: "Brokerage leaderboard over a time window",
+				Args: graphql.FieldConfigArgument{
+					"window": &graphql.ArgumentConfig{Type: graphql.String},
+					"sort":   &graphql.ArgumentConfig{Type: brokerageSortEnum, DefaultValue: models.BrokerageSortByCalls},
+					"asc":    &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+					"tz":     tzArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					loc, err := models.LoadLocation(stringArg(p, "tz"))
+					if err != nil {
+						return nil, fmt.Errorf("invalid tz %q", stringArg(p, "tz"))
+					}
+
+					window, err := models.ParseWindow(stringArg(p, "window"))
+					if err != nil {
+						return nil, err
+					}
+
+					limit := p.Args["limit"].(int)
+					if limit < 1 || limit > maxPageSize {
+						return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+					}
+
+					leaderboard, err := stockService.GetBrokerageLeaderboard(window, p.Args["sort"].(string), p.Args["asc"].(bool), limit)
+					if err != nil {
+						return nil, err
+					}
+					for i := range leaderboard.Brokerages {
+						leaderboard.Brokerages[i].LastActivity = leaderboard.Brokerages[i].LastActivity.In(loc)
+					}
+					return leaderboard.Brokerages, nil
+				},
+			},
+			"recommendations": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(recommendationType))),
+				Description: "Top scored stocks from the latest rating events, or the lowest scored ones with direction NEGATIVE",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: recommendationServices.DefaultRecommendationLimit},
+					"page":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"strategy": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Name of the recommendation strategy, the default one when omitted",
+					},
+					"lookback":        &graphql.ArgumentConfig{Type: graphql.String, Description: "Ignore events older than this window, such as 30d"},
+					"lookback_events": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Number of most recent events ranked"},
+					"direction": &graphql.ArgumentConfig{
+						Type:        directionEnum,
+						Description: "NEGATIVE ranks the tickers to avoid, lowest score first",
+					},
+					"min_score":      &graphql.ArgumentConfig{Type: graphql.Float},
+					"max_score":      &graphql.ArgumentConfig{Type: graphql.Float},
+					"ticker":         &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"exclude_ticker": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"brokerage":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"sector":         &graphql.ArgumentConfig{Type: graphql.String},
+					"tz":             tzArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					loc, err := models.LoadLocation(stringArg(p, "tz"))
+					if err != nil {
+						return nil, fmt.Errorf("invalid tz %q", stringArg(p, "tz"))
+					}
+
+					options, err := recommendationOptions(p)
+					if err != nil {
+						return nil, err
+					}
+
+					result, err := recommendationService.GetRecommendations(options)
+					if err != nil {
+						return nil, err
+					}
+					recommendations := result.Recommendations
+					for i := range recommendations {
+						recommendations[i].Stock = recommendations[i].Stock.InLocation(loc)
+					}
+					return recommendations, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// paginationParams builds the stock listing parameters from the stocks arguments
+func paginationParams(p graphql.ResolveParams) (models.PaginationParams, error) {
+	params := models.PaginationParams{
+		Page:     p.Args["page"].(int),
+		PageSize: p.Args["page_size"].(int),
+		Filter: models.StockFilter{
+			Tickers:         stringListArg(p, "ticker"),
+			Brokerages:      stringListArg(p, "brokerage"),
+			Action:          stringArg(p, "action"),
+			RatingFrom:      stringArg(p, "rating_from"),
+			RatingTo:        stringArg(p, "rating_to"),
+			RatingCategory:  stringArg(p, "rating_category"),
+			Ratings:         ratingListArg(p, "rating"),
+			Sector:          stringArg(p, "sector"),
+			TargetMin:       floatArg(p, "target_min"),
+			TargetMax:       floatArg(p, "target_max"),
+			TargetChangeMin: floatArg(p, "target_change_min"),
+			TargetChangeMax: floatArg(p, "target_change_max"),
+			SortField:       stringArg(p, "sort"),
+		},
+	}
+
+	if params.Page < 1 {
+		return params, errors.New("page must be at least 1")
+	}
+	if params.PageSize < 1 || params.PageSize > maxPageSize {
+		return params, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+	}
+
+	for i, ticker := range params.Filter.Tickers {
+		params.Filter.Tickers[i] = strings.ToUpper(ticker)
+	}
+
+	if desc, ok := p.Args["desc"].(bool); ok {
+		params.Filter.SortDesc = desc
+	}
+
+	if from, ok := p.Args["from"].(time.Time); ok {
+		params.Filter.From = &from
+	}
+	if to, ok := p.Args["to"].(time.Time); ok {
+		params.Filter.To = &to
+	}
+
+	if cursor := stringArg(p, "cursor"); cursor != "" {
+		if params.Filter.SortField != "" {
+			return params, errors.New("cursor pagination only supports the default time order")
+		}
+
+		decoded, err := models.DecodeCursor(cursor)
+		if err != nil {
+			return params, errors.New("invalid cursor")
+		}
+		params.Cursor = decoded
+	}
+
+	return params, nil
+}
+
+// inLocation converts the stock timestamps to loc
+func inLocation(stocks []models.Stock, loc *time.Location) []models.Stock {
+	for i := range stocks {
+		stocks[i] = stocks[i].InLocation(loc)
+	}
+	return stocks
+}
+
+// recommendationOptions builds the recommendation options from the
+// recommendations arguments
+func recommendationOptions(p graphql.ResolveParams) (recommendationServices.RecommendationOptions, error) {
+	options := recommendationServices.RecommendationOptions{
+		Strategy:       stringArg(p, "strategy"),
+		Limit:          p.Args["limit"].(int),
+		Page:           p.Args["page"].(int),
+		MinScore:       floatArg(p, "min_score"),
+		MaxScore:       floatArg(p, "max_score"),
+		Tickers:        stringListArg(p, "ticker"),
+		ExcludeTickers: stringListArg(p, "exclude_ticker"),
+		Brokerages:     stringListArg(p, "brokerage"),
+		Sector:         stringArg(p, "sector"),
+	}
+	if direction, ok := p.Args["direction"].(recommendationServices.Direction); ok {
+		options.Direction = direction
+	}
+
+	for i, ticker := range options.Tickers {
+		options.Tickers[i] = strings.ToUpper(ticker)
+	}
+	for i, ticker := range options.ExcludeTickers {
+		options.ExcludeTickers[i] = strings.ToUpper(ticker)
+	}
+
+	if options.Limit < 1 || options.Limit > recommendationServices.MaxRecommendationLimit {
+		return options, fmt.Errorf("limit must be between 1 and %d", recommendationServices.MaxRecommendationLimit)
+	}
+	if options.Page < 1 {
+		return options, fmt.Errorf("page must be at least 1")
+	}
+
+	if lookback := stringArg(p, "lookback"); lookback != "" {
+		window, err := models.ParseWindow(lookback)
+		if err != nil {
+			return options, err
+		}
+		options.Lookback = window
+	}
+
+	if events, ok := p.Args["lookback_events"].(int); ok {
+		if events < 1 || events > recommendationServices.MaxLookbackEvents {
+			return options, fmt.Errorf("lookback_events must be between 1 and %d", recommendationServices.MaxLookbackEvents)
+		}
+		options.LookbackEvents = events
+	}
+
+	return options, nil
+}
+
+// factorInput is a named input value of a score factor
+type factorInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// factorInputs lists the inputs of a factor sorted by name
+func factorInputs(factor recommendationServices.ScoreFactor) []factorInput {
+	inputs := make([]factorInput, 0, len(factor.Inputs))
+	for name, value := range factor.Inputs {
+		inputs = append(inputs, factorInput{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+	return inputs
+}
+
+func stringArg(p graphql.ResolveParams, name string) string {
+	value, _ := p.Args[name].(string)
+	return strings.TrimSpace(value)
+}
+
+func stringListArg(p graphql.ResolveParams, name string) []string {
+	values, _ := p.Args[name].([]interface{})
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok && strings.TrimSpace(s) != "" {
+			list = append(list, strings.TrimSpace(s))
+		}
+	}
+	return list
+}
+
+func ratingListArg(p graphql.ResolveParams, name string) []models.RatingLevel {
+	values, _ := p.Args[name].([]interface{})
+	var list []models.RatingLevel
+	for _, value := range values {
+		if level, ok := value.(models.RatingLevel); ok {
+			list = append(list, level)
+		}
+	}
+	return list
+}
+
+func floatArg(p graphql.ResolveParams, name string) *float64 {
+	if value, ok := p.Args[name].(float64); ok {
+		return &value
+	}
+	return nil
+}
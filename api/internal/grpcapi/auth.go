@@ -0,0 +1,50 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the metadata entry carrying the API key, the gRPC
+// counterpart of the X-API-Key header
+const apiKeyMetadata = "x-api-key"
+
+// UnaryAPIKeyAuth rejects unary calls without a valid API key in the metadata
+func UnaryAPIKeyAuth(expectedAPIKey string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkAPIKey(ctx, expectedAPIKey); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAPIKeyAuth rejects streaming calls without a valid API key in the metadata
+func StreamAPIKeyAuth(expectedAPIKey string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkAPIKey(stream.Context(), expectedAPIKey); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func checkAPIKey(ctx context.Context, expectedAPIKey string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(apiKeyMetadata)
+
+	if len(values) == 0 || values[0] == "" {
+		return status.Error(codes.Unauthenticated, "API key is required")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(values[0]), []byte(expectedAPIKey)) != 1 {
+		return status.Error(codes.Unauthenticated, "Invalid API key")
+	}
+
+	return nil
+}
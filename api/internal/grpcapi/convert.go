@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"fmt"
+	"stonks-api/internal/grpcapi/stonkspb"
+	"stonks-api/internal/stocks/models"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProtoStock converts a stock to its protobuf message
+func toProtoStock(stock models.Stock) *stonkspb.Stock {
+	return &stonkspb.Stock{
+		Id:         stock.ID,
+		Ticker:     stock.Ticker,
+		Company:    stock.Company,
+		Brokerage:  stock.Brokerage,
+		Action:     stock.Action,
+		RatingFrom: stock.RatingFrom,
+		RatingTo:   stock.RatingTo,
+		TargetFrom: stock.TargetFrom,
+		TargetTo:   stock.TargetTo,
+		Time:       timestamppb.New(stock.Time),
+	}
+}
+
+func toProtoStocks(stocks []models.Stock) []*stonkspb.Stock {
+	messages := make([]*stonkspb.Stock, len(stocks))
+	for i, stock := range stocks {
+		messages[i] = toProtoStock(stock)
+	}
+	return messages
+}
+
+func toProtoPaginatedStocks(result models.PaginatedStocks) *stonkspb.PaginatedStocks {
+	return &stonkspb.PaginatedStocks{
+		Stocks:     toProtoStocks(result.Stocks),
+		TotalCount: result.TotalCount,
+		PageSize:   int32(result.PageSize),
+		Page:       int32(result.Page),
+		TotalPages: int32(result.TotalPages),
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+	}
+}
+
+// toStockFilter converts and validates a protobuf filter, nil means no filter
+func toStockFilter(message *stonkspb.StockFilter) (models.StockFilter, error) {
+	if message == nil {
+		return models.StockFilter{}, nil
+	}
+
+	filter := models.StockFilter{
+		Brokerages: message.GetBrokerages(),
+		Action:     strings.TrimSpace(message.GetAction()),
+		RatingFrom: strings.TrimSpace(message.GetRatingFrom()),
+		RatingTo:   strings.TrimSpace(message.GetRatingTo()),
+		Sector:     strings.TrimSpace(message.GetSector()),
+	}
+
+	for _, ticker := range message.GetTickers() {
+		filter.Tickers = append(filter.Tickers, strings.ToUpper(strings.TrimSpace(ticker)))
+	}
+
+	switch strings.ToLower(message.GetRatingCategory()) {
+	case "":
+	case "positive":
+		filter.RatingCategory = models.RatingCategoryPositive
+	case "neutral":
+		filter.RatingCategory = models.RatingCategoryNeutral
+	case "negative":
+		filter.RatingCategory = models.RatingCategoryNegative
+	default:
+		return filter, fmt.Errorf("invalid rating_category %q, expected positive, neutral or negative", message.GetRatingCategory())
+	}
+
+	if message.GetFrom() != nil {
+		from := message.GetFrom().AsTime()
+		filter.From = &from
+	}
+	if message.GetTo() != nil {
+		to := message.GetTo().AsTime()
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// isStockSortField reports whether field is a whitelisted sort field
+func isStockSortField(field string) bool {
+	for _, f := range models.StockSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
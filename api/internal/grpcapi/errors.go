@@ -0,0 +1,35 @@
+package grpcapi
+
+import (
+	"fmt"
+	"stonks-api/cmd/apierrors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcCodes maps the domain error codes to gRPC status codes
+var grpcCodes = map[apierrors.Code]codes.Code{
+	apierrors.CodeInvalidInput:        codes.InvalidArgument,
+	apierrors.CodeUnauthorized:        codes.Unauthenticated,
+	apierrors.CodeNotFound:            codes.NotFound,
+	apierrors.CodeConflict:            codes.Aborted,
+	apierrors.CodeUpstreamUnavailable: codes.Unavailable,
+	apierrors.CodeInternal:            codes.Internal,
+}
+
+// toStatus converts a service error to a gRPC status. Like the HTTP error
+// handler, the cause of unexpected errors is logged instead of returned.
+func toStatus(err error, message string) error {
+	apiErr := apierrors.FromError(apierrors.Wrap(err, message))
+	if apiErr.Err != nil {
+		fmt.Printf("gRPC error: %v\n", apiErr)
+	}
+
+	code, ok := grpcCodes[apiErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	return status.Error(code, apiErr.Message)
+}
@@ -0,0 +1,190 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"stonks-api/internal/grpcapi/stonkspb"
+	recommendationServices "stonks-api/internal/recommendations/services"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPageSize caps the page size of ListStocks, matching the REST listing
+const maxPageSize = 100
+
+// StockService is the part of the stock service exposed over gRPC
+type StockService interface {
+	GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error)
+	GetStocksByTicker(ticker string) ([]models.Stock, error)
+	WatchStocks(ctx context.Context, after models.Cursor, filter models.StockFilter, fn func(stock models.Stock, position models.Cursor) error) error
+	SyncStocks() (int, error)
+}
+
+// Server implements the StonksService gRPC service
+type Server struct {
+	stonkspb.UnimplementedStonksServiceServer
+
+	stockService          StockService
+	recommendationService recommendationServices.RecommendationServiceInterface
+}
+
+func NewServer(stockService StockService, recommendationService recommendationServices.RecommendationServiceInterface) *Server {
+	return &Server{
+		stockService:          stockService,
+		recommendationService: recommendationService,
+	}
+}
+
+// NewGRPCServer creates a gRPC server serving the StonksService behind API key auth
+func NewGRPCServer(server *Server, apiKey string) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryAPIKeyAuth(apiKey)),
+		grpc.StreamInterceptor(StreamAPIKeyAuth(apiKey)),
+	)
+	stonkspb.RegisterStonksServiceServer(s, server)
+	return s
+}
+
+// ListStocks returns a filtered page of rating events
+func (s *Server) ListStocks(ctx context.Context, req *stonkspb.ListStocksRequest) (*stonkspb.PaginatedStocks, error) {
+	params := models.PaginationParams{
+		Page:       int(req.GetPage()),
+		PageSize:   int(req.GetPageSize()),
+		ExactCount: req.GetExactCount(),
+	}
+
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize == 0 {
+		params.PageSize = 20
+	}
+	if params.PageSize < 1 || params.PageSize > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+
+	filter, err := toStockFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	params.Filter = filter
+
+	if sort := strings.ToLower(req.GetSort()); sort != "" {
+		if !isStockSortField(sort) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid sort %q", req.GetSort())
+		}
+		params.Filter.SortField = sort
+		params.Filter.SortDesc = req.GetDesc()
+	}
+
+	if cursor := req.GetCursor(); cursor != "" {
+		if params.Filter.SortField != "" {
+			return nil, status.Error(codes.InvalidArgument, "cursor pagination only supports the default time order")
+		}
+
+		params.Cursor, err = models.DecodeCursor(cursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+	}
+
+	result, err := s.stockService.GetAllStocks(params)
+	if err != nil {
+		return nil, toStatus(err, "failed to retrieve stocks")
+	}
+
+	return toProtoPaginatedStocks(result), nil
+}
+
+// GetStocksByTicker returns every rating event of a ticker, newest first
+func (s *Server) GetStocksByTicker(ctx context.Context, req *stonkspb.GetStocksByTickerRequest) (*stonkspb.GetStocksByTickerResponse, error) {
+	ticker := strings.ToUpper(strings.TrimSpace(req.GetTicker()))
+	if ticker == "" {
+		return nil, status.Error(codes.InvalidArgument, "ticker is required")
+	}
+
+	stocks, err := s.stockService.GetStocksByTicker(ticker)
+	if err != nil {
+		return nil, toStatus(err, "failed to retrieve stock")
+	}
+
+	if len(stocks) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no stock found with ticker: %s", ticker)
+	}
+
+	return &stonkspb.GetStocksByTickerResponse{Stocks: toProtoStocks(stocks)}, nil
+}
+
+// GetRecommendations returns the top scored stocks of the default strategy
+func (s *Server) GetRecommendations(ctx context.Context, req *stonkspb.GetRecommendationsRequest) (*stonkspb.GetRecommendationsResponse, error) {
+	result, err := s.recommendationService.GetRecommendations(recommendationServices.RecommendationOptions{Strategy: recommendationServices.DefaultStrategy})
+	if err != nil {
+		return nil, toStatus(err, "failed to get recommendations")
+	}
+	recommendations := result.Recommendations
+
+	response := &stonkspb.GetRecommendationsResponse{
+		Recommendations: make([]*stonkspb.StockRecommendation, len(recommendations)),
+	}
+	for i, recommendation := range recommendations {
+		response.Recommendations[i] = &stonkspb.StockRecommendation{
+			Stock:  toProtoStock(recommendation.Stock),
+			Score:  recommendation.Score,
+			Reason: recommendation.Reason,
+		}
+	}
+
+	return response, nil
+}
+
+// StreamStocks sends rating events as they are saved or changed until the client
+// cancels. Each event carries a resume token for reconnecting without gaps.
+func (s *Server) StreamStocks(req *stonkspb.StreamStocksRequest, stream grpc.ServerStreamingServer[stonkspb.StockEvent]) error {
+	filter, err := toStockFilter(req.GetFilter())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	after := models.Cursor{Time: time.Now().UTC()}
+	if token := req.GetResumeToken(); token != "" {
+		cursor, err := models.DecodeCursor(token)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid resume_token")
+		}
+		after = *cursor
+	}
+
+	err = s.stockService.WatchStocks(stream.Context(), after, filter, func(stock models.Stock, position models.Cursor) error {
+		return stream.Send(&stonkspb.StockEvent{
+			Stock:       toProtoStock(stock),
+			ResumeToken: position.Encode(),
+		})
+	})
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return toStatus(err, "failed to stream stocks")
+	}
+
+	return nil
+}
+
+// SyncStocks fetches the latest events from the external API
+func (s *Server) SyncStocks(ctx context.Context, req *stonkspb.SyncStocksRequest) (*stonkspb.SyncStocksResponse, error) {
+	count, err := s.stockService.SyncStocks()
+	if err != nil {
+		return nil, toStatus(err, "failed to sync stocks")
+	}
+
+	return &stonkspb.SyncStocksResponse{Saved: int32(count)}, nil
+}
@@ -0,0 +1,172 @@
+package grpcapi_test
+
+import (
+	"context"
+	"net"
+	"stonks-api/internal/grpcapi"
+	"stonks-api/internal/grpcapi/stonkspb"
+	recommendationMocks "stonks-api/internal/recommendations/mocks"
+	"stonks-api/internal/stocks/mocks"
+	"stonks-api/internal/stocks/models"
+	"stonks-api/internal/stocks/services"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves the repository over an in-memory connection
+func newTestClient(t *testing.T, repo *mocks.MockRepository) stonkspb.StonksServiceClient {
+	stockService := services.NewStockService(repo)
+	stockService.SetFeedPollInterval(10 * time.Millisecond)
+
+	server := grpcapi.NewGRPCServer(
+		grpcapi.NewServer(stockService, &recommendationMocks.MockRecommendationService{}),
+		"test-key",
+	)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return stonkspb.NewStonksServiceClient(conn)
+}
+
+func withAPIKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	client := newTestClient(t, &mocks.MockRepository{})
+
+	// Missing and invalid keys are rejected
+	for _, key := range []string{"", "wrong-key"} {
+		ctx := context.Background()
+		if key != "" {
+			ctx = withAPIKey(ctx, key)
+		}
+
+		_, err := client.GetRecommendations(ctx, &stonkspb.GetRecommendationsRequest{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated for key %q but got %v", key, err)
+		}
+	}
+}
+
+func TestListStocks(t *testing.T) {
+	// Filters and pagination are passed to the service
+	t.Run("valid request", func(t *testing.T) {
+		var got models.PaginationParams
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				got = params
+				return models.PaginatedStocks{
+					Stocks:     []models.Stock{{ID: "1", Ticker: "AAPL", Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+					TotalCount: 1,
+					Page:       1,
+					PageSize:   5,
+					TotalPages: 1,
+				}, nil
+			},
+		}
+
+		response, err := newTestClient(t, repo).ListStocks(withAPIKey(context.Background(), "test-key"), &stonkspb.ListStocksRequest{
+			PageSize: 5,
+			Filter:   &stonkspb.StockFilter{Tickers: []string{"aapl"}, RatingCategory: "positive"},
+			Sort:     "target_to",
+			Desc:     true,
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got.PageSize != 5 || got.Filter.Tickers[0] != "AAPL" || got.Filter.RatingCategory != models.RatingCategoryPositive || !got.Filter.SortDesc {
+			t.Errorf("Expected filtered, sorted params but got %+v", got)
+		}
+
+		if len(response.Stocks) != 1 || !response.Stocks[0].Time.AsTime().Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected one stock with its time but got %v", response.Stocks)
+		}
+	})
+
+	// Invalid sort field
+	t.Run("invalid sort", func(t *testing.T) {
+		_, err := newTestClient(t, &mocks.MockRepository{}).ListStocks(withAPIKey(context.Background(), "test-key"), &stonkspb.ListStocksRequest{Sort: "name"})
+
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument but got %v", err)
+		}
+	})
+}
+
+func TestGetStocksByTicker(t *testing.T) {
+	// Unknown tickers are NotFound
+	_, err := newTestClient(t, &mocks.MockRepository{}).GetStocksByTicker(withAPIKey(context.Background(), "test-key"), &stonkspb.GetStocksByTickerRequest{Ticker: "zzzz"})
+
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound but got %v", err)
+	}
+}
+
+func TestStreamStocks(t *testing.T) {
+	// Events are streamed with resume tokens, resuming from the given token
+	updated := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	var positions []models.Cursor
+	repo := &mocks.MockRepository{
+		GetStocksChangedAfterFn: func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			positions = append(positions, after)
+			if after.ID == "1" {
+				return []models.Stock{{ID: "2", Ticker: "MSFT", UpdatedAt: updated}}, nil
+			}
+			return []models.Stock{}, nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(withAPIKey(context.Background(), "test-key"), 5*time.Second)
+	defer cancel()
+
+	resume := models.Cursor{Time: updated.Add(-time.Hour), ID: "1"}.Encode()
+	stream, err := newTestClient(t, repo).StreamStocks(ctx, &stonkspb.StreamStocksRequest{ResumeToken: resume})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Expected an event, but got %v", err)
+	}
+
+	if event.Stock.GetTicker() != "MSFT" {
+		t.Errorf("Expected MSFT event but got %v", event.Stock)
+	}
+
+	next, err := models.DecodeCursor(event.GetResumeToken())
+	if err != nil || next.ID != "2" || !next.Time.Equal(updated) {
+		t.Errorf("Expected resume token after stock 2 but got %+v (%v)", next, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if positions[0].ID != "1" {
+		t.Errorf("Expected the stream to resume after stock 1 but got %+v", positions[0])
+	}
+}
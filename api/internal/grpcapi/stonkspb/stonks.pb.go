@@ -0,0 +1,1014 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: stonks/v1/stonks.proto
+
+package stonkspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Stock is a rating event published by a brokerage
+type Stock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ticker        string                 `protobuf:"bytes,2,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Company       string                 `protobuf:"bytes,3,opt,name=company,proto3" json:"company,omitempty"`
+	Brokerage     string                 `protobuf:"bytes,4,opt,name=brokerage,proto3" json:"brokerage,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
+	RatingFrom    string                 `protobuf:"bytes,6,opt,name=rating_from,json=ratingFrom,proto3" json:"rating_from,omitempty"`
+	RatingTo      string                 `protobuf:"bytes,7,opt,name=rating_to,json=ratingTo,proto3" json:"rating_to,omitempty"`
+	TargetFrom    float64                `protobuf:"fixed64,8,opt,name=target_from,json=targetFrom,proto3" json:"target_from,omitempty"`
+	TargetTo      float64                `protobuf:"fixed64,9,opt,name=target_to,json=targetTo,proto3" json:"target_to,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stock) Reset() {
+	*x = Stock{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stock) ProtoMessage() {}
+
+func (x *Stock) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stock.ProtoReflect.Descriptor instead.
+func (*Stock) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Stock) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Stock) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *Stock) GetCompany() string {
+	if x != nil {
+		return x.Company
+	}
+	return ""
+}
+
+func (x *Stock) GetBrokerage() string {
+	if x != nil {
+		return x.Brokerage
+	}
+	return ""
+}
+
+func (x *Stock) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Stock) GetRatingFrom() string {
+	if x != nil {
+		return x.RatingFrom
+	}
+	return ""
+}
+
+func (x *Stock) GetRatingTo() string {
+	if x != nil {
+		return x.RatingTo
+	}
+	return ""
+}
+
+func (x *Stock) GetTargetFrom() float64 {
+	if x != nil {
+		return x.TargetFrom
+	}
+	return 0
+}
+
+func (x *Stock) GetTargetTo() float64 {
+	if x != nil {
+		return x.TargetTo
+	}
+	return 0
+}
+
+func (x *Stock) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type PaginatedStocks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stocks        []*Stock               `protobuf:"bytes,1,rep,name=stocks,proto3" json:"stocks,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Page          int32                  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,5,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,6,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	PrevCursor    string                 `protobuf:"bytes,7,opt,name=prev_cursor,json=prevCursor,proto3" json:"prev_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaginatedStocks) Reset() {
+	*x = PaginatedStocks{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaginatedStocks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaginatedStocks) ProtoMessage() {}
+
+func (x *PaginatedStocks) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaginatedStocks.ProtoReflect.Descriptor instead.
+func (*PaginatedStocks) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PaginatedStocks) GetStocks() []*Stock {
+	if x != nil {
+		return x.Stocks
+	}
+	return nil
+}
+
+func (x *PaginatedStocks) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *PaginatedStocks) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *PaginatedStocks) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *PaginatedStocks) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *PaginatedStocks) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *PaginatedStocks) GetPrevCursor() string {
+	if x != nil {
+		return x.PrevCursor
+	}
+	return ""
+}
+
+type StockRecommendation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stock         *Stock                 `protobuf:"bytes,1,opt,name=stock,proto3" json:"stock,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockRecommendation) Reset() {
+	*x = StockRecommendation{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockRecommendation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockRecommendation) ProtoMessage() {}
+
+func (x *StockRecommendation) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockRecommendation.ProtoReflect.Descriptor instead.
+func (*StockRecommendation) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StockRecommendation) GetStock() *Stock {
+	if x != nil {
+		return x.Stock
+	}
+	return nil
+}
+
+func (x *StockRecommendation) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *StockRecommendation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// StockFilter narrows listings and streams, empty fields are ignored
+type StockFilter struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Tickers    []string               `protobuf:"bytes,1,rep,name=tickers,proto3" json:"tickers,omitempty"`
+	Brokerages []string               `protobuf:"bytes,2,rep,name=brokerages,proto3" json:"brokerages,omitempty"`
+	Action     string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	RatingFrom string                 `protobuf:"bytes,4,opt,name=rating_from,json=ratingFrom,proto3" json:"rating_from,omitempty"`
+	RatingTo   string                 `protobuf:"bytes,5,opt,name=rating_to,json=ratingTo,proto3" json:"rating_to,omitempty"`
+	// One of positive, neutral or negative
+	RatingCategory string                 `protobuf:"bytes,6,opt,name=rating_category,json=ratingCategory,proto3" json:"rating_category,omitempty"`
+	Sector         string                 `protobuf:"bytes,7,opt,name=sector,proto3" json:"sector,omitempty"`
+	From           *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=from,proto3" json:"from,omitempty"`
+	To             *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StockFilter) Reset() {
+	*x = StockFilter{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockFilter) ProtoMessage() {}
+
+func (x *StockFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockFilter.ProtoReflect.Descriptor instead.
+func (*StockFilter) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StockFilter) GetTickers() []string {
+	if x != nil {
+		return x.Tickers
+	}
+	return nil
+}
+
+func (x *StockFilter) GetBrokerages() []string {
+	if x != nil {
+		return x.Brokerages
+	}
+	return nil
+}
+
+func (x *StockFilter) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *StockFilter) GetRatingFrom() string {
+	if x != nil {
+		return x.RatingFrom
+	}
+	return ""
+}
+
+func (x *StockFilter) GetRatingTo() string {
+	if x != nil {
+		return x.RatingTo
+	}
+	return ""
+}
+
+func (x *StockFilter) GetRatingCategory() string {
+	if x != nil {
+		return x.RatingCategory
+	}
+	return ""
+}
+
+func (x *StockFilter) GetSector() string {
+	if x != nil {
+		return x.Sector
+	}
+	return ""
+}
+
+func (x *StockFilter) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *StockFilter) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type ListStocksRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Page     int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Opaque cursor from a previous page, replaces page
+	Cursor string       `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Filter *StockFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	// One of time, ticker, company, brokerage, target_to or target_change
+	Sort          string `protobuf:"bytes,5,opt,name=sort,proto3" json:"sort,omitempty"`
+	Desc          bool   `protobuf:"varint,6,opt,name=desc,proto3" json:"desc,omitempty"`
+	ExactCount    bool   `protobuf:"varint,7,opt,name=exact_count,json=exactCount,proto3" json:"exact_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStocksRequest) Reset() {
+	*x = ListStocksRequest{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStocksRequest) ProtoMessage() {}
+
+func (x *ListStocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStocksRequest.ProtoReflect.Descriptor instead.
+func (*ListStocksRequest) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListStocksRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListStocksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListStocksRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListStocksRequest) GetFilter() *StockFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListStocksRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListStocksRequest) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+func (x *ListStocksRequest) GetExactCount() bool {
+	if x != nil {
+		return x.ExactCount
+	}
+	return false
+}
+
+type GetStocksByTickerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStocksByTickerRequest) Reset() {
+	*x = GetStocksByTickerRequest{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStocksByTickerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStocksByTickerRequest) ProtoMessage() {}
+
+func (x *GetStocksByTickerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStocksByTickerRequest.ProtoReflect.Descriptor instead.
+func (*GetStocksByTickerRequest) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStocksByTickerRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+type GetStocksByTickerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stocks        []*Stock               `protobuf:"bytes,1,rep,name=stocks,proto3" json:"stocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStocksByTickerResponse) Reset() {
+	*x = GetStocksByTickerResponse{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStocksByTickerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStocksByTickerResponse) ProtoMessage() {}
+
+func (x *GetStocksByTickerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStocksByTickerResponse.ProtoReflect.Descriptor instead.
+func (*GetStocksByTickerResponse) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetStocksByTickerResponse) GetStocks() []*Stock {
+	if x != nil {
+		return x.Stocks
+	}
+	return nil
+}
+
+type GetRecommendationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRecommendationsRequest) Reset() {
+	*x = GetRecommendationsRequest{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecommendationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecommendationsRequest) ProtoMessage() {}
+
+func (x *GetRecommendationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecommendationsRequest.ProtoReflect.Descriptor instead.
+func (*GetRecommendationsRequest) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{7}
+}
+
+type GetRecommendationsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Recommendations []*StockRecommendation `protobuf:"bytes,1,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetRecommendationsResponse) Reset() {
+	*x = GetRecommendationsResponse{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecommendationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecommendationsResponse) ProtoMessage() {}
+
+func (x *GetRecommendationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecommendationsResponse.ProtoReflect.Descriptor instead.
+func (*GetRecommendationsResponse) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetRecommendationsResponse) GetRecommendations() []*StockRecommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+type StreamStocksRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *StockFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Resume token of the last event received, the stream starts with the
+	// events saved or changed after it. Without a token only new events are sent.
+	ResumeToken   string `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamStocksRequest) Reset() {
+	*x = StreamStocksRequest{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamStocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStocksRequest) ProtoMessage() {}
+
+func (x *StreamStocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStocksRequest.ProtoReflect.Descriptor instead.
+func (*StreamStocksRequest) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamStocksRequest) GetFilter() *StockFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *StreamStocksRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type StockEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stock         *Stock                 `protobuf:"bytes,1,opt,name=stock,proto3" json:"stock,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockEvent) Reset() {
+	*x = StockEvent{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockEvent) ProtoMessage() {}
+
+func (x *StockEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockEvent.ProtoReflect.Descriptor instead.
+func (*StockEvent) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StockEvent) GetStock() *Stock {
+	if x != nil {
+		return x.Stock
+	}
+	return nil
+}
+
+func (x *StockEvent) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type SyncStocksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncStocksRequest) Reset() {
+	*x = SyncStocksRequest{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncStocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncStocksRequest) ProtoMessage() {}
+
+func (x *SyncStocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncStocksRequest.ProtoReflect.Descriptor instead.
+func (*SyncStocksRequest) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{11}
+}
+
+type SyncStocksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Saved         int32                  `protobuf:"varint,1,opt,name=saved,proto3" json:"saved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncStocksResponse) Reset() {
+	*x = SyncStocksResponse{}
+	mi := &file_stonks_v1_stonks_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncStocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncStocksResponse) ProtoMessage() {}
+
+func (x *SyncStocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stonks_v1_stonks_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncStocksResponse.ProtoReflect.Descriptor instead.
+func (*SyncStocksResponse) Descriptor() ([]byte, []int) {
+	return file_stonks_v1_stonks_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SyncStocksResponse) GetSaved() int32 {
+	if x != nil {
+		return x.Saved
+	}
+	return 0
+}
+
+var File_stonks_v1_stonks_proto protoreflect.FileDescriptor
+
+const file_stonks_v1_stonks_proto_rawDesc = "" +
+	"\n" +
+	"\x16stonks/v1/stonks.proto\x12\tstonks.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xab\x02\n" +
+	"\x05Stock\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06ticker\x18\x02 \x01(\tR\x06ticker\x12\x18\n" +
+	"\acompany\x18\x03 \x01(\tR\acompany\x12\x1c\n" +
+	"\tbrokerage\x18\x04 \x01(\tR\tbrokerage\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\x12\x1f\n" +
+	"\vrating_from\x18\x06 \x01(\tR\n" +
+	"ratingFrom\x12\x1b\n" +
+	"\trating_to\x18\a \x01(\tR\bratingTo\x12\x1f\n" +
+	"\vtarget_from\x18\b \x01(\x01R\n" +
+	"targetFrom\x12\x1b\n" +
+	"\ttarget_to\x18\t \x01(\x01R\btargetTo\x12.\n" +
+	"\x04time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"\xf0\x01\n" +
+	"\x0fPaginatedStocks\x12(\n" +
+	"\x06stocks\x18\x01 \x03(\v2\x10.stonks.v1.StockR\x06stocks\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x03R\n" +
+	"totalCount\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\x12\x1f\n" +
+	"\vtotal_pages\x18\x05 \x01(\x05R\n" +
+	"totalPages\x12\x1f\n" +
+	"\vnext_cursor\x18\x06 \x01(\tR\n" +
+	"nextCursor\x12\x1f\n" +
+	"\vprev_cursor\x18\a \x01(\tR\n" +
+	"prevCursor\"k\n" +
+	"\x13StockRecommendation\x12&\n" +
+	"\x05stock\x18\x01 \x01(\v2\x10.stonks.v1.StockR\x05stock\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xba\x02\n" +
+	"\vStockFilter\x12\x18\n" +
+	"\atickers\x18\x01 \x03(\tR\atickers\x12\x1e\n" +
+	"\n" +
+	"brokerages\x18\x02 \x03(\tR\n" +
+	"brokerages\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x1f\n" +
+	"\vrating_from\x18\x04 \x01(\tR\n" +
+	"ratingFrom\x12\x1b\n" +
+	"\trating_to\x18\x05 \x01(\tR\bratingTo\x12'\n" +
+	"\x0frating_category\x18\x06 \x01(\tR\x0eratingCategory\x12\x16\n" +
+	"\x06sector\x18\a \x01(\tR\x06sector\x12.\n" +
+	"\x04from\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"\xd5\x01\n" +
+	"\x11ListStocksRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\x12.\n" +
+	"\x06filter\x18\x04 \x01(\v2\x16.stonks.v1.StockFilterR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x05 \x01(\tR\x04sort\x12\x12\n" +
+	"\x04desc\x18\x06 \x01(\bR\x04desc\x12\x1f\n" +
+	"\vexact_count\x18\a \x01(\bR\n" +
+	"exactCount\"2\n" +
+	"\x18GetStocksByTickerRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\"E\n" +
+	"\x19GetStocksByTickerResponse\x12(\n" +
+	"\x06stocks\x18\x01 \x03(\v2\x10.stonks.v1.StockR\x06stocks\"\x1b\n" +
+	"\x19GetRecommendationsRequest\"f\n" +
+	"\x1aGetRecommendationsResponse\x12H\n" +
+	"\x0frecommendations\x18\x01 \x03(\v2\x1e.stonks.v1.StockRecommendationR\x0frecommendations\"h\n" +
+	"\x13StreamStocksRequest\x12.\n" +
+	"\x06filter\x18\x01 \x01(\v2\x16.stonks.v1.StockFilterR\x06filter\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\"W\n" +
+	"\n" +
+	"StockEvent\x12&\n" +
+	"\x05stock\x18\x01 \x01(\v2\x10.stonks.v1.StockR\x05stock\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\"\x13\n" +
+	"\x11SyncStocksRequest\"*\n" +
+	"\x12SyncStocksResponse\x12\x14\n" +
+	"\x05saved\x18\x01 \x01(\x05R\x05saved2\xae\x03\n" +
+	"\rStonksService\x12F\n" +
+	"\n" +
+	"ListStocks\x12\x1c.stonks.v1.ListStocksRequest\x1a\x1a.stonks.v1.PaginatedStocks\x12^\n" +
+	"\x11GetStocksByTicker\x12#.stonks.v1.GetStocksByTickerRequest\x1a$.stonks.v1.GetStocksByTickerResponse\x12a\n" +
+	"\x12GetRecommendations\x12$.stonks.v1.GetRecommendationsRequest\x1a%.stonks.v1.GetRecommendationsResponse\x12G\n" +
+	"\fStreamStocks\x12\x1e.stonks.v1.StreamStocksRequest\x1a\x15.stonks.v1.StockEvent0\x01\x12I\n" +
+	"\n" +
+	"SyncStocks\x12\x1c.stonks.v1.SyncStocksRequest\x1a\x1d.stonks.v1.SyncStocksResponseB/Z-stonks-api/internal/grpcapi/stonkspb;stonkspbb\x06proto3"
+
+var (
+	file_stonks_v1_stonks_proto_rawDescOnce sync.Once
+	file_stonks_v1_stonks_proto_rawDescData []byte
+)
+
+func file_stonks_v1_stonks_proto_rawDescGZIP() []byte {
+	file_stonks_v1_stonks_proto_rawDescOnce.Do(func() {
+		file_stonks_v1_stonks_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stonks_v1_stonks_proto_rawDesc), len(file_stonks_v1_stonks_proto_rawDesc)))
+	})
+	return file_stonks_v1_stonks_proto_rawDescData
+}
+
+var file_stonks_v1_stonks_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_stonks_v1_stonks_proto_goTypes = []any{
+	(*Stock)(nil),                      // 0: stonks.v1.Stock
+	(*PaginatedStocks)(nil),            // 1: stonks.v1.PaginatedStocks
+	(*StockRecommendation)(nil),        // 2: stonks.v1.StockRecommendation
+	(*StockFilter)(nil),                // 3: stonks.v1.StockFilter
+	(*ListStocksRequest)(nil),          // 4: stonks.v1.ListStocksRequest
+	(*GetStocksByTickerRequest)(nil),   // 5: stonks.v1.GetStocksByTickerRequest
+	(*GetStocksByTickerResponse)(nil),  // 6: stonks.v1.GetStocksByTickerResponse
+	(*GetRecommendationsRequest)(nil),  // 7: stonks.v1.GetRecommendationsRequest
+	(*GetRecommendationsResponse)(nil), // 8: stonks.v1.GetRecommendationsResponse
+	(*StreamStocksRequest)(nil),        // 9: stonks.v1.StreamStocksRequest
+	(*StockEvent)(nil),                 // 10: stonks.v1.StockEvent
+	(*SyncStocksRequest)(nil),          // 11: stonks.v1.SyncStocksRequest
+	(*SyncStocksResponse)(nil),         // 12: stonks.v1.SyncStocksResponse
+	(*timestamppb.Timestamp)(nil),      // 13: google.protobuf.Timestamp
+}
+var file_stonks_v1_stonks_proto_depIdxs = []int32{
+	13, // 0: stonks.v1.Stock.time:type_name -> google.protobuf.Timestamp
+	0,  // 1: stonks.v1.PaginatedStocks.stocks:type_name -> stonks.v1.Stock
+	0,  // 2: stonks.v1.StockRecommendation.stock:type_name -> stonks.v1.Stock
+	13, // 3: stonks.v1.StockFilter.from:type_name -> google.protobuf.Timestamp
+	13, // 4: stonks.v1.StockFilter.to:type_name -> google.protobuf.Timestamp
+	3,  // 5: stonks.v1.ListStocksRequest.filter:type_name -> stonks.v1.StockFilter
+	0,  // 6: stonks.v1.GetStocksByTickerResponse.stocks:type_name -> stonks.v1.Stock
+	2,  // 7: stonks.v1.GetRecommendationsResponse.recommendations:type_name -> stonks.v1.StockRecommendation
+	3,  // 8: stonks.v1.StreamStocksRequest.filter:type_name -> stonks.v1.StockFilter
+	0,  // 9: stonks.v1.StockEvent.stock:type_name -> stonks.v1.Stock
+	4,  // 10: stonks.v1.StonksService.ListStocks:input_type -> stonks.v1.ListStocksRequest
+	5,  // 11: stonks.v1.StonksService.GetStocksByTicker:input_type -> stonks.v1.GetStocksByTickerRequest
+	7,  // 12: stonks.v1.StonksService.GetRecommendations:input_type -> stonks.v1.GetRecommendationsRequest
+	9,  // 13: stonks.v1.StonksService.StreamStocks:input_type -> stonks.v1.StreamStocksRequest
+	11, // 14: stonks.v1.StonksService.SyncStocks:input_type -> stonks.v1.SyncStocksRequest
+	1,  // 15: stonks.v1.StonksService.ListStocks:output_type -> stonks.v1.PaginatedStocks
+	6,  // 16: stonks.v1.StonksService.GetStocksByTicker:output_type -> stonks.v1.GetStocksByTickerResponse
+	8,  // 17: stonks.v1.StonksService.GetRecommendations:output_type -> stonks.v1.GetRecommendationsResponse
+	10, // 18: stonks.v1.StonksService.StreamStocks:output_type -> stonks.v1.StockEvent
+	12, // 19: stonks.v1.StonksService.SyncStocks:output_type -> stonks.v1.SyncStocksResponse
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_stonks_v1_stonks_proto_init() }
+func file_stonks_v1_stonks_proto_init() {
+	if File_stonks_v1_stonks_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stonks_v1_stonks_proto_rawDesc), len(file_stonks_v1_stonks_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stonks_v1_stonks_proto_goTypes,
+		DependencyIndexes: file_stonks_v1_stonks_proto_depIdxs,
+		MessageInfos:      file_stonks_v1_stonks_proto_msgTypes,
+	}.Build()
+	File_stonks_v1_stonks_proto = out.File
+	file_stonks_v1_stonks_proto_goTypes = nil
+	file_stonks_v1_stonks_proto_depIdxs = nil
+}
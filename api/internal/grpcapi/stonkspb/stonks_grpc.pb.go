@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: stonks/v1/stonks.proto
+
+package stonkspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StonksService_ListStocks_FullMethodName         = "/stonks.v1.StonksService/ListStocks"
+	StonksService_GetStocksByTicker_FullMethodName  = "/stonks.v1.StonksService/GetStocksByTicker"
+	StonksService_GetRecommendations_FullMethodName = "/stonks.v1.StonksService/GetRecommendations"
+	StonksService_StreamStocks_FullMethodName       = "/stonks.v1.StonksService/StreamStocks"
+	StonksService_SyncStocks_FullMethodName         = "/stonks.v1.StonksService/SyncStocks"
+)
+
+// StonksServiceClient is the client API for StonksService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// StonksService exposes rating data to internal services. Every call must
+// carry the API key in the x-api-key metadata entry.
+type StonksServiceClient interface {
+	// ListStocks returns a filtered page of rating events
+	ListStocks(ctx context.Context, in *ListStocksRequest, opts ...grpc.CallOption) (*PaginatedStocks, error)
+	// GetStocksByTicker returns every rating event of a ticker, newest first
+	GetStocksByTicker(ctx context.Context, in *GetStocksByTickerRequest, opts ...grpc.CallOption) (*GetStocksByTickerResponse, error)
+	// GetRecommendations returns the top scored stocks
+	GetRecommendations(ctx context.Context, in *GetRecommendationsRequest, opts ...grpc.CallOption) (*GetRecommendationsResponse, error)
+	// StreamStocks sends rating events as they are saved or changed
+	StreamStocks(ctx context.Context, in *StreamStocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StockEvent], error)
+	// SyncStocks fetches the latest events from the external API
+	SyncStocks(ctx context.Context, in *SyncStocksRequest, opts ...grpc.CallOption) (*SyncStocksResponse, error)
+}
+
+type stonksServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStonksServiceClient(cc grpc.ClientConnInterface) StonksServiceClient {
+	return &stonksServiceClient{cc}
+}
+
+func (c *stonksServiceClient) ListStocks(ctx context.Context, in *ListStocksRequest, opts ...grpc.CallOption) (*PaginatedStocks, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaginatedStocks)
+	err := c.cc.Invoke(ctx, StonksService_ListStocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stonksServiceClient) GetStocksByTicker(ctx context.Context, in *GetStocksByTickerRequest, opts ...grpc.CallOption) (*GetStocksByTickerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStocksByTickerResponse)
+	err := c.cc.Invoke(ctx, StonksService_GetStocksByTicker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stonksServiceClient) GetRecommendations(ctx context.Context, in *GetRecommendationsRequest, opts ...grpc.CallOption) (*GetRecommendationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRecommendationsResponse)
+	err := c.cc.Invoke(ctx, StonksService_GetRecommendations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stonksServiceClient) StreamStocks(ctx context.Context, in *StreamStocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StockEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StonksService_ServiceDesc.Streams[0], StonksService_StreamStocks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamStocksRequest, StockEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StonksService_StreamStocksClient = grpc.ServerStreamingClient[StockEvent]
+
+func (c *stonksServiceClient) SyncStocks(ctx context.Context, in *SyncStocksRequest, opts ...grpc.CallOption) (*SyncStocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncStocksResponse)
+	err := c.cc.Invoke(ctx, StonksService_SyncStocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StonksServiceServer is the server API for StonksService service.
+// All implementations must embed UnimplementedStonksServiceServer
+// for forward compatibility.
+//
+// StonksService exposes rating data to internal services. Every call must
+// carry the API key in the x-api-key metadata entry.
+type StonksServiceServer interface {
+	// ListStocks returns a filtered page of rating events
+	ListStocks(context.Context, *ListStocksRequest) (*PaginatedStocks, error)
+	// GetStocksByTicker returns every rating event of a ticker, newest first
+	GetStocksByTicker(context.Context, *GetStocksByTickerRequest) (*GetStocksByTickerResponse, error)
+	// GetRecommendations returns the top scored stocks
+	GetRecommendations(context.Context, *GetRecommendationsRequest) (*GetRecommendationsResponse, error)
+	// StreamStocks sends rating events as they are saved or changed
+	StreamStocks(*StreamStocksRequest, grpc.ServerStreamingServer[StockEvent]) error
+	// SyncStocks fetches the latest events from the external API
+	SyncStocks(context.Context, *SyncStocksRequest) (*SyncStocksResponse, error)
+	mustEmbedUnimplementedStonksServiceServer()
+}
+
+// UnimplementedStonksServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStonksServiceServer struct{}
+
+func (UnimplementedStonksServiceServer) ListStocks(context.Context, *ListStocksRequest) (*PaginatedStocks, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStocks not implemented")
+}
+func (UnimplementedStonksServiceServer) GetStocksByTicker(context.Context, *GetStocksByTickerRequest) (*GetStocksByTickerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStocksByTicker not implemented")
+}
+func (UnimplementedStonksServiceServer) GetRecommendations(context.Context, *GetRecommendationsRequest) (*GetRecommendationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecommendations not implemented")
+}
+func (UnimplementedStonksServiceServer) StreamStocks(*StreamStocksRequest, grpc.ServerStreamingServer[StockEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamStocks not implemented")
+}
+func (UnimplementedStonksServiceServer) SyncStocks(context.Context, *SyncStocksRequest) (*SyncStocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncStocks not implemented")
+}
+func (UnimplementedStonksServiceServer) mustEmbedUnimplementedStonksServiceServer() {}
+func (UnimplementedStonksServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeStonksServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StonksServiceServer will
+// result in compilation errors.
+type UnsafeStonksServiceServer interface {
+	mustEmbedUnimplementedStonksServiceServer()
+}
+
+func RegisterStonksServiceServer(s grpc.ServiceRegistrar, srv StonksServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStonksServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StonksService_ServiceDesc, srv)
+}
+
+func _StonksService_ListStocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StonksServiceServer).ListStocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StonksService_ListStocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StonksServiceServer).ListStocks(ctx, req.(*ListStocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StonksService_GetStocksByTicker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStocksByTickerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StonksServiceServer).GetStocksByTicker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StonksService_GetStocksByTicker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StonksServiceServer).GetStocksByTicker(ctx, req.(*GetStocksByTickerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StonksService_GetRecommendations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecommendationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StonksServiceServer).GetRecommendations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StonksService_GetRecommendations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StonksServiceServer).GetRecommendations(ctx, req.(*GetRecommendationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StonksService_StreamStocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStocksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StonksServiceServer).StreamStocks(m, &grpc.GenericServerStream[StreamStocksRequest, StockEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StonksService_StreamStocksServer = grpc.ServerStreamingServer[StockEvent]
+
+func _StonksService_SyncStocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncStocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StonksServiceServer).SyncStocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StonksService_SyncStocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StonksServiceServer).SyncStocks(ctx, req.(*SyncStocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StonksService_ServiceDesc is the grpc.ServiceDesc for StonksService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StonksService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stonks.v1.StonksService",
+	HandlerType: (*StonksServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListStocks",
+			Handler:    _StonksService_ListStocks_Handler,
+		},
+		{
+			MethodName: "GetStocksByTicker",
+			Handler:    _StonksService_GetStocksByTicker_Handler,
+		},
+		{
+			MethodName: "GetRecommendations",
+			Handler:    _StonksService_GetRecommendations_Handler,
+		},
+		{
+			MethodName: "SyncStocks",
+			Handler:    _StonksService_SyncStocks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamStocks",
+			Handler:       _StonksService_StreamStocks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "stonks/v1/stonks.proto",
+}
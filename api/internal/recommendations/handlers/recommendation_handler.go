@@ -1,8 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
 	"stonks-api/internal/recommendations/services"
+	"stonks-api/internal/stocks/models"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -18,22 +27,190 @@ func NewRecommendationHandler(recommendationService services.RecommendationServi
 }
 
 func (h *RecommendationHandler) GetRecommendations(c echo.Context) error {
-	recommendations, err := h.recommendationService.GetRecommendations()
+	options, err := parseRecommendationOptions(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get recommendations: " + err.Error(),
-		})
+		return err
 	}
 
-	if len(recommendations) == 0 {
-		return c.JSON(http.StatusOK, map[string]string{
-			"message": "No recommendations available at this time",
-		})
+	return h.recommend(c, options)
+}
+
+// GetAvoidRecommendations handles the API endpoint ranking the tickers to
+// avoid, the recommendations in the negative direction
+func (h *RecommendationHandler) GetAvoidRecommendations(c echo.Context) error {
+	options, err := parseRecommendationOptions(c)
+	if err != nil {
+		return err
+	}
+	if c.QueryParam("direction") != "" && options.Direction != services.DirectionNegative {
+		return apierrors.InvalidParameter("direction", "Tickers to avoid are always ranked in the negative direction")
+	}
+	options.Direction = services.DirectionNegative
+
+	return h.recommend(c, options)
+}
+
+// recommend responds with the recommendations for the options
+func (h *RecommendationHandler) recommend(c echo.Context, options services.RecommendationOptions) error {
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	// Recency weighting and lookback windows are relative to now
+	middleware.NowRelative(c)
+	result, err := h.recommendationService.GetRecommendations(options)
+	if err != nil {
+		return h.strategyError(err, options.Strategy, "Failed to get recommendations")
+	}
+
+	// Clients always get an array, empty when nothing qualifies
+	if result.Recommendations == nil {
+		result.Recommendations = []services.StockRecommendation{}
+	}
+
+	for i := range result.Recommendations {
+		result.Recommendations[i].Stock = result.Recommendations[i].Stock.InLocation(loc)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// strategyError reports unknown strategies, or strategies that cannot rank
+// the tickers to avoid, along with the names to choose from and wraps any
+// other error with message
+func (h *RecommendationHandler) strategyError(err error, strategy, message string) error {
+	switch {
+	case errors.Is(err, services.ErrUnknownStrategy):
+		var names []string
+		for _, info := range h.recommendationService.Strategies() {
+			names = append(names, info.Name)
+		}
+		return apierrors.InvalidParameter("strategy", "Unknown strategy: "+strategy).
+			WithDetail("strategies", names)
+	case errors.Is(err, services.ErrUnsupportedDirection):
+		var names []string
+		for _, info := range h.recommendationService.Strategies() {
+			if slices.Contains(info.Directions, services.DirectionNegative) {
+				names = append(names, info.Name)
+			}
+		}
+		return apierrors.InvalidParameter("direction", "Strategy "+strategy+" cannot rank the tickers to avoid").
+			WithDetail("strategies", names)
+	default:
+		return apierrors.Wrap(err, message)
+	}
+}
+
+// parseRecommendationOptions reads the recommendation options from the query
+// string, returning an API error naming the first invalid parameter
+func parseRecommendationOptions(c echo.Context) (services.RecommendationOptions, error) {
+	options := services.RecommendationOptions{
+		Strategy:       c.QueryParam("strategy"),
+		Tickers:        parseList(c, "ticker"),
+		ExcludeTickers: parseList(c, "exclude_ticker"),
+		Brokerages:     parseList(c, "brokerage"),
+		Sector:         strings.TrimSpace(c.QueryParam("sector")),
 	}
 
-	return c.JSON(http.StatusOK, recommendations)
+	direction, err := services.ParseDirection(strings.TrimSpace(c.QueryParam("direction")))
+	if err != nil {
+		return options, apierrors.InvalidParameter("direction", "Invalid direction parameter: "+err.Error())
+	}
+	options.Direction = direction
+
+	for i, ticker := range options.Tickers {
+		options.Tickers[i] = strings.ToUpper(ticker)
+	}
+	for i, ticker := range options.ExcludeTickers {
+		options.ExcludeTickers[i] = strings.ToUpper(ticker)
+	}
+
+	intParams := []struct {
+		name     string
+		dest     *int
+		def, max int
+	}{
+		{"limit", &options.Limit, services.DefaultRecommendationLimit, services.MaxRecommendationLimit},
+		{"page", &options.Page, 1, math.MaxInt32},
+		{"lookback_events", &options.LookbackEvents, 0, services.MaxLookbackEvents},
+	}
+	for _, param := range intParams {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			*param.dest = param.def
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > param.max {
+			return options, apierrors.InvalidParameter(param.name,
+				fmt.Sprintf("Invalid %s parameter: %q is not an integer between 1 and %d", param.name, value, param.max))
+		}
+		*param.dest = n
+	}
+
+	if value := c.QueryParam("lookback"); value != "" {
+		lookback, err := models.ParseWindow(value)
+		if err != nil {
+			return options, apierrors.InvalidParameter("lookback", "Invalid lookback parameter: "+err.Error())
+		}
+		options.Lookback = lookback
+	}
+
+	scoreParams := []struct {
+		name string
+		dest **float64
+	}{
+		{"min_score", &options.MinScore},
+		{"max_score", &options.MaxScore},
+	}
+	for _, param := range scoreParams {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			continue
+		}
+
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return options, apierrors.InvalidParameter(param.name, fmt.Sprintf("Invalid %s parameter: %q is not a number", param.name, value))
+		}
+		*param.dest = &score
+	}
+
+	return options, nil
+}
+
+// parseList collects a repeatable, comma separated query parameter
+func parseList(c echo.Context, name string) []string {
+	var values []string
+	for _, raw := range c.QueryParams()[name] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// GetStrategies handles the API endpoint listing the recommendation strategies
+func (h *RecommendationHandler) GetStrategies(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.recommendationService.Strategies())
 }
 
 func (h *RecommendationHandler) RegisterRoutes(e *echo.Group) {
 	e.GET("/recommendations", h.GetRecommendations)
+	e.GET("/recommendations/avoid", h.GetAvoidRecommendations)
+	e.GET("/recommendations/strategies", h.GetStrategies)
+	e.GET("/recommendations/snapshots", h.ListSnapshots)
+	e.GET("/recommendations/snapshots/diff", h.DiffSnapshots)
+	e.GET("/recommendations/snapshots/:date", h.GetSnapshot)
+}
+
+func (h *RecommendationHandler) RegisterAdminRoutes(e *echo.Group) {
+	e.POST("/recommendations/snapshots", h.TakeSnapshot)
+	e.GET("/scoring", h.GetScoringConfig)
+	e.PUT("/scoring", h.SetScoringConfig)
+	e.POST("/scoring/reload", h.ReloadScoringConfig)
 }
@@ -3,13 +3,18 @@ package handlers_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"stonks-api/cmd/apierrors"
 	"stonks-api/internal/recommendations/handlers"
 	"stonks-api/internal/recommendations/mocks"
+	recommendationModels "stonks-api/internal/recommendations/models"
 	"stonks-api/internal/recommendations/services"
 	"stonks-api/internal/stocks/models"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -43,8 +48,8 @@ func TestGetRecommendations(t *testing.T) {
 		}
 
 		mockService := &mocks.MockRecommendationService{
-			GetRecommendationsFn: func() ([]services.StockRecommendation, error) {
-				return recommendations, nil
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{Scoring: services.DefaultScoringConfig(), Recommendations: recommendations}, nil
 			},
 		}
 
@@ -62,19 +67,24 @@ func TestGetRecommendations(t *testing.T) {
 			t.Errorf("Expected status code %d but got %d", http.StatusOK, rec.Code)
 		}
 
-		var response []services.StockRecommendation
+		var response services.Recommendations
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		if err != nil {
 			t.Errorf("Error unmarshaling response: %v", err)
 		}
 
-		if len(response) != len(recommendations) {
-			t.Errorf("Expected %d recommendations but got %d", len(recommendations), len(response))
+		if len(response.Recommendations) != len(recommendations) {
+			t.Fatalf("Expected %d recommendations but got %d", len(recommendations), len(response.Recommendations))
 		}
 
-		if response[0].Stock.Ticker != "AAPL" || response[1].Stock.Ticker != "MSFT" {
+		if response.Recommendations[0].Stock.Ticker != "AAPL" || response.Recommendations[1].Stock.Ticker != "MSFT" {
 			t.Errorf("Response did not match expected recommendations")
 		}
+
+		// The scoring used is echoed so results can be reproduced
+		if response.Scoring != services.DefaultScoringConfig() {
+			t.Errorf("Expected the default scoring config but got %+v", response.Scoring)
+		}
 	})
 
 	// No recommendations available
@@ -86,8 +96,8 @@ func TestGetRecommendations(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockService := &mocks.MockRecommendationService{
-			GetRecommendationsFn: func() ([]services.StockRecommendation, error) {
-				return []services.StockRecommendation{}, nil
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{}, nil
 			},
 		}
 
@@ -105,15 +115,8 @@ func TestGetRecommendations(t *testing.T) {
 			t.Errorf("Expected status code %d but got %d", http.StatusOK, rec.Code)
 		}
 
-		var response map[string]string
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		if err != nil {
-			t.Errorf("Error unmarshaling response: %v", err)
-		}
-
-		message, exists := response["message"]
-		if !exists || message != "No recommendations available at this time" {
-			t.Errorf("Expected message about no recommendations but got: %v", response)
+		if !strings.Contains(rec.Body.String(), `"recommendations":[]`) {
+			t.Errorf("Expected an empty array but got: %s", rec.Body.String())
 		}
 	})
 
@@ -126,8 +129,8 @@ func TestGetRecommendations(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		mockService := &mocks.MockRecommendationService{
-			GetRecommendationsFn: func() ([]services.StockRecommendation, error) {
-				return nil, errors.New("service error")
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{}, errors.New("service error")
 			},
 		}
 
@@ -135,25 +138,554 @@ func TestGetRecommendations(t *testing.T) {
 
 		// Act
 		err := h.GetRecommendations(c)
-
-		// Assert
-		if err != nil {
-			t.Errorf("Expected no error, but got %v", err)
+		if err == nil {
+			t.Fatalf("Expected an error, but got nil")
 		}
+		apierrors.HTTPErrorHandler(err, c)
 
+		// Assert
 		if rec.Code != http.StatusInternalServerError {
 			t.Errorf("Expected status code %d but got %d", http.StatusInternalServerError, rec.Code)
 		}
 
-		var response map[string]string
+		var response apierrors.Envelope
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		if err != nil {
 			t.Errorf("Error unmarshaling response: %v", err)
 		}
 
-		errMsg, exists := response["error"]
-		if !exists || errMsg != "Failed to get recommendations: service error" {
-			t.Errorf("Expected error message but got: %v", response)
+		if response.Error.Code != apierrors.CodeInternal || response.Error.Message != "Failed to get recommendations" {
+			t.Errorf("Expected internal error envelope but got: %v", response)
+		}
+
+		if strings.Contains(rec.Body.String(), "service error") {
+			t.Errorf("Expected the cause to stay out of the response but got: %s", rec.Body.String())
+		}
+	})
+}
+
+func TestGetRecommendationsTimeZone(t *testing.T) {
+	// Invalid time zone
+	t.Run("invalid tz", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?tz=Mars/Olympus", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := handlers.NewRecommendationHandler(&mocks.MockRecommendationService{})
+
+		err := h.GetRecommendations(c)
+		if err == nil {
+			t.Fatalf("Expected an error, but got nil")
+		}
+		apierrors.HTTPErrorHandler(err, c)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	// Timestamps rendered in the requested zone
+	t.Run("valid tz", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?tz=Asia/Tokyo", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		eventTime := time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC)
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{Recommendations: []services.StockRecommendation{
+					{Stock: models.Stock{Ticker: "AAPL", Time: eventTime}, Score: 3},
+				}}, nil
+			},
+		}
+
+		h := handlers.NewRecommendationHandler(mockService)
+
+		if err := h.GetRecommendations(c); err != nil {
+			t.Errorf("Expected no error, but got %v", err)
+		}
+
+		if !strings.Contains(rec.Body.String(), "2025-01-16T05:00:00+09:00") {
+			t.Errorf("Expected time in Asia/Tokyo but got: %s", rec.Body.String())
+		}
+	})
+}
+
+func TestGetRecommendationsOptions(t *testing.T) {
+	// Query parameters are passed to the service as options
+	t.Run("parsed options", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?limit=10&page=2&lookback=30d"+
+			"&lookback_events=500&min_score=2.5&ticker=aapl,msft&exclude_ticker=tsla&brokerage=The+Goldman+Sachs+Group&sector=Technology", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got services.RecommendationOptions
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				got = options
+				return services.Recommendations{TotalCount: 12, PageSize: 10, Page: 2, TotalPages: 2}, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetRecommendations(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got.Limit != 10 || got.Page != 2 || got.LookbackEvents != 500 || got.Lookback != 30*24*time.Hour {
+			t.Errorf("Expected limit 10, page 2, 500 events over 30 days but got %+v", got)
+		}
+		if got.MinScore == nil || *got.MinScore != 2.5 {
+			t.Errorf("Expected min score 2.5 but got %v", got.MinScore)
+		}
+		if strings.Join(got.Tickers, ",") != "AAPL,MSFT" || strings.Join(got.ExcludeTickers, ",") != "TSLA" {
+			t.Errorf("Expected upper cased tickers but got %v and %v", got.Tickers, got.ExcludeTickers)
+		}
+		if len(got.Brokerages) != 1 || got.Brokerages[0] != "The Goldman Sachs Group" || got.Sector != "Technology" {
+			t.Errorf("Expected the brokerage and sector filters but got %v and %q", got.Brokerages, got.Sector)
+		}
+		if !strings.Contains(rec.Body.String(), `"total_pages":2`) {
+			t.Errorf("Expected the pagination in the response but got: %s", rec.Body.String())
+		}
+	})
+
+	// Defaults apply when no parameter is given
+	t.Run("defaults", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got services.RecommendationOptions
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				got = options
+				return services.Recommendations{}, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetRecommendations(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got.Limit != services.DefaultRecommendationLimit || got.Page != 1 || got.LookbackEvents != 0 || got.MinScore != nil ||
+			got.MaxScore != nil || got.Direction != services.DirectionPositive {
+			t.Errorf("Expected the default options but got %+v", got)
+		}
+	})
+
+	// Invalid parameters are rejected
+	for _, query := range []string{"limit=0", "limit=101", "page=0", "lookback=soon", "lookback_events=99999", "min_score=high", "max_score=low", "direction=sideways"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?"+query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			if err := handlers.NewRecommendationHandler(&mocks.MockRecommendationService{}).GetRecommendations(c); err != nil {
+				apierrors.HTTPErrorHandler(err, c)
+			}
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetAvoidRecommendations(t *testing.T) {
+	// The avoid endpoint ranks the negative direction with the same options
+	t.Run("negative direction", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations/avoid?strategy=heuristic&max_score=-2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got services.RecommendationOptions
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				got = options
+				return services.Recommendations{
+					Strategy:  options.Strategy,
+					Direction: options.Direction,
+					Recommendations: []services.StockRecommendation{{
+						Stock:  models.Stock{Ticker: "AAPL"},
+						Score:  -4,
+						Reason: "Stock was recently downgraded, Target price decreased significantly",
+					}},
+				}, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetAvoidRecommendations(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got.Direction != services.DirectionNegative || got.Strategy != "heuristic" || got.MaxScore == nil || *got.MaxScore != -2 {
+			t.Errorf("Expected the negative direction with the heuristic strategy and max score -2 but got %+v", got)
+		}
+		if !strings.Contains(rec.Body.String(), `"direction":"negative"`) || !strings.Contains(rec.Body.String(), `"score":-4`) {
+			t.Errorf("Expected the tickers to avoid in the response but got: %s", rec.Body.String())
+		}
+	})
+
+	// The direction parameter selects the same ranking on the recommendations endpoint
+	t.Run("direction parameter", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?direction=negative", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got services.Direction
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				got = options.Direction
+				return services.Recommendations{}, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetRecommendations(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got != services.DirectionNegative {
+			t.Errorf("Expected the negative direction but got %q", got)
+		}
+	})
+
+	// The avoid endpoint does not rank the tickers to buy
+	t.Run("positive direction", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations/avoid?direction=positive", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := handlers.NewRecommendationHandler(&mocks.MockRecommendationService{}).GetAvoidRecommendations(c); err != nil {
+			apierrors.HTTPErrorHandler(err, c)
+		}
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	// Strategies without a negative ranking are rejected with the ones to use
+	t.Run("unsupported strategy", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations/avoid?strategy=upgrades_only", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{}, fmt.Errorf("%w: %s", services.ErrUnsupportedDirection, options.Strategy)
+			},
+			StrategiesFn: func() []services.StrategyInfo {
+				return []services.StrategyInfo{
+					{Name: "consensus", Directions: []services.Direction{services.DirectionPositive, services.DirectionNegative}},
+					{Name: "upgrades_only", Directions: []services.Direction{services.DirectionPositive}},
+				}
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetAvoidRecommendations(c); err != nil {
+			apierrors.HTTPErrorHandler(err, c)
+		}
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+
+		body := rec.Body.String()
+		if !strings.Contains(body, `"strategies":["consensus"]`) || !strings.Contains(body, `"direction"`) {
+			t.Errorf("Expected the strategies ranking the tickers to avoid in the details but got: %s", body)
+		}
+	})
+}
+
+func TestRecommendationStrategies(t *testing.T) {
+	// The strategy parameter selects the strategy
+	t.Run("selected strategy", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?strategy=momentum", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got string
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				got = options.Strategy
+				return services.Recommendations{Strategy: options.Strategy}, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetRecommendations(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got != "momentum" || !strings.Contains(rec.Body.String(), `"strategy":"momentum"`) {
+			t.Errorf("Expected the momentum strategy but got %q: %s", got, rec.Body.String())
+		}
+	})
+
+	// Unknown strategies are rejected with the available names
+	t.Run("unknown strategy", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations?strategy=astrology", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockService := &mocks.MockRecommendationService{
+			GetRecommendationsFn: func(options services.RecommendationOptions) (services.Recommendations, error) {
+				return services.Recommendations{}, fmt.Errorf("%w: %s", services.ErrUnknownStrategy, options.Strategy)
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).GetRecommendations(c); err != nil {
+			apierrors.HTTPErrorHandler(err, c)
+		}
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+
+		if !strings.Contains(rec.Body.String(), "target_upside") {
+			t.Errorf("Expected the available strategies in the details but got: %s", rec.Body.String())
+		}
+	})
+
+	// The strategies endpoint lists the registered strategies
+	t.Run("list strategies", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/recommendations/strategies", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := handlers.NewRecommendationHandler(&mocks.MockRecommendationService{}).GetStrategies(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		var strategies []services.StrategyInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &strategies); err != nil {
+			t.Fatalf("Error unmarshaling response: %v", err)
+		}
+
+		if len(strategies) != 5 || strategies[0].Name != services.DefaultStrategy {
+			t.Errorf("Expected the built-in strategies but got %+v", strategies)
+		}
+	})
+}
+
+func TestScoringConfigEndpoints(t *testing.T) {
+	// A valid body is applied, omitted fields take their default
+	t.Run("set config", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/stonks-api/admin/scoring",
+			strings.NewReader(`{"actions": {"upgraded": 3}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var applied services.ScoringConfig
+		mockService := &mocks.MockRecommendationService{
+			SetScoringConfigFn: func(config services.ScoringConfig) (services.ScoringConfig, error) {
+				applied = config
+				return config, nil
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).SetScoringConfig(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+		}
+
+		if applied.Actions.Upgraded != 3 || applied.Actions.Downgraded != -2 {
+			t.Errorf("Expected upgraded 3 and the default downgraded but got %+v", applied.Actions)
+		}
+	})
+
+	// Invalid configurations are rejected with the validation message
+	invalid := map[string]string{
+		"unknown field":      `{"bonus": 1}`,
+		"malformed":          `{"actions":`,
+		"inconsistent score": `{"rating_scores": {"negative": 9}}`,
+	}
+
+	for name, body := range invalid {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/stonks-api/admin/scoring", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := handlers.NewRecommendationHandler(&mocks.MockRecommendationService{})
+			if err := h.SetScoringConfig(c); err != nil {
+				apierrors.HTTPErrorHandler(err, c)
+			}
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+
+	// Reloading without a configured file is a conflict
+	t.Run("reload without file", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/stonks-api/admin/scoring/reload", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockService := &mocks.MockRecommendationService{
+			ReloadScoringConfigFn: func() (services.ScoringConfig, error) {
+				return services.ScoringConfig{}, services.ErrNoScoringFile
+			},
+		}
+
+		if err := handlers.NewRecommendationHandler(mockService).ReloadScoringConfig(c); err != nil {
+			apierrors.HTTPErrorHandler(err, c)
+		}
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d but got %d", http.StatusConflict, rec.Code)
+		}
+	})
+}
+
+func TestSnapshotEndpoints(t *testing.T) {
+	serve := func(service *mocks.MockRecommendationService, method, target string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+		handler := handlers.NewRecommendationHandler(service)
+		handler.RegisterRoutes(e.Group("/api/v1/stonks-api"))
+		handler.RegisterAdminRoutes(e.Group("/api/v1/stonks-api/admin"))
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	// A snapshot is fetched by date and strategy
+	t.Run("get snapshot", func(t *testing.T) {
+		var gotDate time.Time
+		var gotStrategy string
+		mockService := &mocks.MockRecommendationService{
+			GetSnapshotFn: func(date time.Time, strategy string) (recommendationModels.Snapshot, error) {
+				gotDate, gotStrategy = date, strategy
+				return recommendationModels.Snapshot{Date: "2025-03-01", Strategy: strategy}, nil
+			},
+		}
+
+		rec := serve(mockService, http.MethodGet, "/api/v1/stonks-api/recommendations/snapshots/2025-03-01?strategy=momentum")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if !gotDate.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || gotStrategy != "momentum" {
+			t.Errorf("Expected the momentum snapshot of 2025-03-01 but got %v %q", gotDate, gotStrategy)
+		}
+	})
+
+	// Missing snapshots are not found
+	t.Run("missing snapshot", func(t *testing.T) {
+		mockService := &mocks.MockRecommendationService{
+			GetSnapshotFn: func(date time.Time, strategy string) (recommendationModels.Snapshot, error) {
+				return recommendationModels.Snapshot{}, apierrors.NotFound("No snapshot")
+			},
+		}
+
+		rec := serve(mockService, http.MethodGet, "/api/v1/stonks-api/recommendations/snapshots/2025-03-01")
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	// The diff takes both dates
+	t.Run("diff", func(t *testing.T) {
+		var gotFrom, gotTo time.Time
+		mockService := &mocks.MockRecommendationService{
+			DiffSnapshotsFn: func(from, to time.Time, strategy string) (recommendationModels.SnapshotDiff, error) {
+				gotFrom, gotTo = from, to
+				return recommendationModels.SnapshotDiff{From: "2025-03-01", To: "2025-03-08"}, nil
+			},
+		}
+
+		rec := serve(mockService, http.MethodGet, "/api/v1/stonks-api/recommendations/snapshots/diff?from=2025-03-01&to=2025-03-08")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if gotFrom.Day() != 1 || gotTo.Day() != 8 {
+			t.Errorf("Expected the two dates but got %v and %v", gotFrom, gotTo)
+		}
+	})
+
+	// Invalid dates are rejected
+	for name, target := range map[string]string{
+		"invalid date":  "/api/v1/stonks-api/recommendations/snapshots/yesterday",
+		"missing from":  "/api/v1/stonks-api/recommendations/snapshots/diff?to=2025-03-08",
+		"invalid to":    "/api/v1/stonks-api/recommendations/snapshots/diff?from=2025-03-01&to=2025-13-01",
+		"invalid limit": "/api/v1/stonks-api/recommendations/snapshots?limit=0",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := serve(&mocks.MockRecommendationService{}, http.MethodGet, target)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+
+	// Snapshots are listed, empty when none is stored
+	t.Run("list snapshots", func(t *testing.T) {
+		mockService := &mocks.MockRecommendationService{
+			ListSnapshotsFn: func(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error) {
+				return nil, nil
+			},
+		}
+
+		rec := serve(mockService, http.MethodGet, "/api/v1/stonks-api/recommendations/snapshots")
+
+		if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+			t.Errorf("Expected an empty list but got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	// Taking a snapshot returns it as created
+	t.Run("take snapshot", func(t *testing.T) {
+		mockService := &mocks.MockRecommendationService{
+			TakeSnapshotFn: func(strategy string) (recommendationModels.Snapshot, error) {
+				return recommendationModels.Snapshot{Date: "2025-03-01", Strategy: services.DefaultStrategy}, nil
+			},
+		}
+
+		rec := serve(mockService, http.MethodPost, "/api/v1/stonks-api/admin/recommendations/snapshots")
+
+		if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"date":"2025-03-01"`) {
+			t.Errorf("Expected the created snapshot but got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	// Unknown strategies list the available ones
+	t.Run("unknown strategy", func(t *testing.T) {
+		mockService := &mocks.MockRecommendationService{
+			TakeSnapshotFn: func(strategy string) (recommendationModels.Snapshot, error) {
+				return recommendationModels.Snapshot{}, fmt.Errorf("%w: %s", services.ErrUnknownStrategy, strategy)
+			},
+		}
+
+		rec := serve(mockService, http.MethodPost, "/api/v1/stonks-api/admin/recommendations/snapshots?strategy=astrology")
+
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "momentum") {
+			t.Errorf("Expected the available strategies but got %d: %s", rec.Code, rec.Body.String())
 		}
 	})
 }
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/recommendations/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetScoringConfig handles the API endpoint returning the scoring configuration in use
+func (h *RecommendationHandler) GetScoringConfig(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.recommendationService.ScoringConfig())
+}
+
+// SetScoringConfig handles the API endpoint replacing the scoring configuration.
+// Omitted fields take their default value.
+func (h *RecommendationHandler) SetScoringConfig(c echo.Context) error {
+	config, err := services.ParseScoringConfig(c.Request().Body)
+	if err != nil {
+		return apierrors.InvalidInput(err.Error())
+	}
+
+	config, err = h.recommendationService.SetScoringConfig(config)
+	if err != nil {
+		return scoringError(err, "Failed to set scoring config")
+	}
+
+	return c.JSON(http.StatusOK, config)
+}
+
+// ReloadScoringConfig handles the API endpoint reading the scoring
+// configuration file again
+func (h *RecommendationHandler) ReloadScoringConfig(c echo.Context) error {
+	config, err := h.recommendationService.ReloadScoringConfig()
+	if err != nil {
+		return scoringError(err, "Failed to reload scoring config")
+	}
+
+	return c.JSON(http.StatusOK, config)
+}
+
+// scoringError maps scoring configuration failures to API errors
+func scoringError(err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidScoringConfig):
+		return apierrors.InvalidInput(err.Error())
+	case errors.Is(err, services.ErrNoScoringFile):
+		return apierrors.Conflict("No scoring config file is configured")
+	default:
+		return apierrors.Wrap(err, message)
+	}
+}
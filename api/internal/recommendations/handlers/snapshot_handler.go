@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	recommendationModels "stonks-api/internal/recommendations/models"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxSnapshotListLimit caps the number of snapshots listed at once
+const maxSnapshotListLimit = 365
+
+// TakeSnapshot handles the API endpoint storing today's recommendation snapshot
+func (h *RecommendationHandler) TakeSnapshot(c echo.Context) error {
+	strategy := c.QueryParam("strategy")
+	snapshot, err := h.recommendationService.TakeSnapshot(strategy)
+	if err != nil {
+		return h.strategyError(err, strategy, "Failed to take snapshot")
+	}
+
+	return c.JSON(http.StatusCreated, snapshot)
+}
+
+// ListSnapshots handles the API endpoint listing the stored snapshots, newest first
+func (h *RecommendationHandler) ListSnapshots(c echo.Context) error {
+	limit := 30
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSnapshotListLimit {
+			return apierrors.InvalidParameter("limit",
+				fmt.Sprintf("Invalid limit parameter: %q is not an integer between 1 and %d", value, maxSnapshotListLimit))
+		}
+		limit = n
+	}
+
+	strategy := c.QueryParam("strategy")
+	snapshots, err := h.recommendationService.ListSnapshots(strategy, limit)
+	if err != nil {
+		return h.strategyError(err, strategy, "Failed to list snapshots")
+	}
+
+	if snapshots == nil {
+		snapshots = []recommendationModels.SnapshotSummary{}
+	}
+
+	return c.JSON(http.StatusOK, snapshots)
+}
+
+// GetSnapshot handles the API endpoint returning the snapshot of a date
+func (h *RecommendationHandler) GetSnapshot(c echo.Context) error {
+	date, err := parseSnapshotDate(c.Param("date"), "date")
+	if err != nil {
+		return err
+	}
+
+	strategy := c.QueryParam("strategy")
+	snapshot, err := h.recommendationService.GetSnapshot(date, strategy)
+	if err != nil {
+		return h.strategyError(err, strategy, "Failed to get snapshot")
+	}
+
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// DiffSnapshots handles the API endpoint comparing the snapshots of two dates
+func (h *RecommendationHandler) DiffSnapshots(c echo.Context) error {
+	from, err := parseSnapshotDate(c.QueryParam("from"), "from")
+	if err != nil {
+		return err
+	}
+
+	to, err := parseSnapshotDate(c.QueryParam("to"), "to")
+	if err != nil {
+		return err
+	}
+
+	strategy := c.QueryParam("strategy")
+	diff, err := h.recommendationService.DiffSnapshots(from, to, strategy)
+	if err != nil {
+		return h.strategyError(err, strategy, "Failed to diff snapshots")
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+// parseSnapshotDate reads a required YYYY-MM-DD date parameter
+func parseSnapshotDate(value, name string) (time.Time, error) {
+	date, err := time.Parse(recommendationModels.SnapshotDateLayout, value)
+	if err != nil {
+		return time.Time{}, apierrors.InvalidParameter(name,
+			fmt.Sprintf("Invalid %s parameter: %q is not a date (YYYY-MM-DD)", name, value))
+	}
+
+	return date, nil
+}
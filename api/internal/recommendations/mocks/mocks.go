@@ -2,59 +2,146 @@
 package mocks
 
 import (
+	recommendationModels "stonks-api/internal/recommendations/models"
 	"stonks-api/internal/recommendations/services"
 	"stonks-api/internal/stocks/models"
+	"time"
 )
 
 // MockStockRepository implements the interfaces.StockRepository interface for testing
 type MockStockRepository struct {
-	GetRecentStocksFn   func(limit int) ([]models.Stock, error)
-	SaveStocksFn        func(stocks []models.Stock) error
-	GetAllStocksFn      func(params models.PaginationParams) (models.PaginatedStocks, error)
-	GetStocksByTickerFn func(ticker string) ([]models.Stock, error)
+	GetRecentStocksFn     func(filter models.StockFilter, limit int) ([]models.Stock, error)
+	GetLatestCallsSinceFn func(since time.Time, filter models.StockFilter, limit int) ([]models.Stock, error)
 }
 
 // GetRecentStocks implements the required method
-func (m *MockStockRepository) GetRecentStocks(limit int) ([]models.Stock, error) {
+func (m *MockStockRepository) GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error) {
 	if m.GetRecentStocksFn != nil {
-		return m.GetRecentStocksFn(limit)
+		return m.GetRecentStocksFn(filter, limit)
 	}
 	return []models.Stock{}, nil
 }
 
-// SaveStocks implements the required method
-func (m *MockStockRepository) SaveStocks(stocks []models.Stock) error {
-	if m.SaveStocksFn != nil {
-		return m.SaveStocksFn(stocks)
+// GetLatestCallsSince implements the required method
+func (m *MockStockRepository) GetLatestCallsSince(since time.Time, filter models.StockFilter, limit int) ([]models.Stock, error) {
+	if m.GetLatestCallsSinceFn != nil {
+		return m.GetLatestCallsSinceFn(since, filter, limit)
 	}
-	return nil
+	return []models.Stock{}, nil
+}
+
+// MockRecommendationService implements the RecommendationServiceInterface for testing
+type MockRecommendationService struct {
+	GetRecommendationsFn  func(options services.RecommendationOptions) (services.Recommendations, error)
+	StrategiesFn          func() []services.StrategyInfo
+	ScoringConfigFn       func() services.ScoringConfig
+	SetScoringConfigFn    func(config services.ScoringConfig) (services.ScoringConfig, error)
+	ReloadScoringConfigFn func() (services.ScoringConfig, error)
+	TakeSnapshotFn        func(strategy string) (recommendationModels.Snapshot, error)
+	GetSnapshotFn         func(date time.Time, strategy string) (recommendationModels.Snapshot, error)
+	ListSnapshotsFn       func(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error)
+	DiffSnapshotsFn       func(from, to time.Time, strategy string) (recommendationModels.SnapshotDiff, error)
 }
 
-// GetAllStocks implements the required method
-func (m *MockStockRepository) GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error) {
-	if m.GetAllStocksFn != nil {
-		return m.GetAllStocksFn(params)
+// GetRecommendations implements the required method
+func (m *MockRecommendationService) GetRecommendations(options services.RecommendationOptions) (services.Recommendations, error) {
+	if m.GetRecommendationsFn != nil {
+		return m.GetRecommendationsFn(options)
 	}
-	return models.PaginatedStocks{}, nil
+	return services.Recommendations{Recommendations: []services.StockRecommendation{}}, nil
 }
 
-// GetStocksByTicker implements the required method
-func (m *MockStockRepository) GetStocksByTicker(ticker string) ([]models.Stock, error) {
-	if m.GetStocksByTickerFn != nil {
-		return m.GetStocksByTickerFn(ticker)
+// Strategies implements the required method
+func (m *MockRecommendationService) Strategies() []services.StrategyInfo {
+	if m.StrategiesFn != nil {
+		return m.StrategiesFn()
 	}
-	return []models.Stock{}, nil
+	return services.DefaultStrategyRegistry().List()
 }
 
-// MockRecommendationService implements the RecommendationServiceInterface for testing
-type MockRecommendationService struct {
-	GetRecommendationsFn func() ([]services.StockRecommendation, error)
+// ScoringConfig implements the required method
+func (m *MockRecommendationService) ScoringConfig() services.ScoringConfig {
+	if m.ScoringConfigFn != nil {
+		return m.ScoringConfigFn()
+	}
+	return services.DefaultScoringConfig()
 }
 
-// GetRecommendations implements the required method
-func (m *MockRecommendationService) GetRecommendations() ([]services.StockRecommendation, error) {
-	if m.GetRecommendationsFn != nil {
-		return m.GetRecommendationsFn()
+// SetScoringConfig implements the required method
+func (m *MockRecommendationService) SetScoringConfig(config services.ScoringConfig) (services.ScoringConfig, error) {
+	if m.SetScoringConfigFn != nil {
+		return m.SetScoringConfigFn(config)
+	}
+	return config, nil
+}
+
+// ReloadScoringConfig implements the required method
+func (m *MockRecommendationService) ReloadScoringConfig() (services.ScoringConfig, error) {
+	if m.ReloadScoringConfigFn != nil {
+		return m.ReloadScoringConfigFn()
+	}
+	return services.DefaultScoringConfig(), nil
+}
+
+// TakeSnapshot implements the required method
+func (m *MockRecommendationService) TakeSnapshot(strategy string) (recommendationModels.Snapshot, error) {
+	if m.TakeSnapshotFn != nil {
+		return m.TakeSnapshotFn(strategy)
+	}
+	return recommendationModels.Snapshot{}, nil
+}
+
+// GetSnapshot implements the required method
+func (m *MockRecommendationService) GetSnapshot(date time.Time, strategy string) (recommendationModels.Snapshot, error) {
+	if m.GetSnapshotFn != nil {
+		return m.GetSnapshotFn(date, strategy)
+	}
+	return recommendationModels.Snapshot{}, nil
+}
+
+// ListSnapshots implements the required method
+func (m *MockRecommendationService) ListSnapshots(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error) {
+	if m.ListSnapshotsFn != nil {
+		return m.ListSnapshotsFn(strategy, limit)
+	}
+	return []recommendationModels.SnapshotSummary{}, nil
+}
+
+// DiffSnapshots implements the required method
+func (m *MockRecommendationService) DiffSnapshots(from, to time.Time, strategy string) (recommendationModels.SnapshotDiff, error) {
+	if m.DiffSnapshotsFn != nil {
+		return m.DiffSnapshotsFn(from, to, strategy)
+	}
+	return recommendationModels.SnapshotDiff{}, nil
+}
+
+// MockSnapshotRepository implements the services.SnapshotRepository interface for testing
+type MockSnapshotRepository struct {
+	SaveSnapshotFn  func(snapshot recommendationModels.Snapshot) error
+	GetSnapshotFn   func(date, strategy string) (*recommendationModels.Snapshot, error)
+	ListSnapshotsFn func(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error)
+}
+
+// SaveSnapshot implements the required method
+func (m *MockSnapshotRepository) SaveSnapshot(snapshot recommendationModels.Snapshot) error {
+	if m.SaveSnapshotFn != nil {
+		return m.SaveSnapshotFn(snapshot)
+	}
+	return nil
+}
+
+// GetSnapshot implements the required method
+func (m *MockSnapshotRepository) GetSnapshot(date, strategy string) (*recommendationModels.Snapshot, error) {
+	if m.GetSnapshotFn != nil {
+		return m.GetSnapshotFn(date, strategy)
+	}
+	return nil, nil
+}
+
+// ListSnapshots implements the required method
+func (m *MockSnapshotRepository) ListSnapshots(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error) {
+	if m.ListSnapshotsFn != nil {
+		return m.ListSnapshotsFn(strategy, limit)
 	}
-	return []services.StockRecommendation{}, nil
+	return []recommendationModels.SnapshotSummary{}, nil
 }
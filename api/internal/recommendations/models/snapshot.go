@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// SnapshotDateLayout is the layout of snapshot dates
+const SnapshotDateLayout = "2006-01-02"
+
+// SnapshotEntry is a ranked recommendation kept in a snapshot
+type SnapshotEntry struct {
+	Rank    int     `json:"rank"`
+	Ticker  string  `json:"ticker"`
+	Company string  `json:"company"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason"`
+}
+
+// Snapshot holds the top recommendations of a strategy on one UTC day. A
+// later snapshot on the same day replaces the earlier one.
+type Snapshot struct {
+	Date           string          `json:"date"`
+	Strategy       string          `json:"strategy"`
+	ScoringVersion string          `json:"scoring_version"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Entries        []SnapshotEntry `json:"entries"`
+}
+
+// SnapshotSummary describes a stored snapshot without its entries
+type SnapshotSummary struct {
+	Date           string    `json:"date"`
+	Strategy       string    `json:"strategy"`
+	ScoringVersion string    `json:"scoring_version"`
+	CreatedAt      time.Time `json:"created_at"`
+	Size           int       `json:"size"`
+}
+
+// RankChange follows a ticker present in both snapshots of a diff. Change is
+// positive when the ticker climbed.
+type RankChange struct {
+	Ticker      string  `json:"ticker"`
+	Company     string  `json:"company"`
+	FromRank    int     `json:"from_rank"`
+	ToRank      int     `json:"to_rank"`
+	Change      int     `json:"change"`
+	ScoreChange float64 `json:"score_change"`
+}
+
+// SnapshotDiff compares two snapshots of a strategy. Entered holds the
+// entries of the later snapshot missing from the earlier one, Exited the
+// reverse, and RankChanges the tickers in both, by their later rank.
+type SnapshotDiff struct {
+	Strategy    string          `json:"strategy"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Entered     []SnapshotEntry `json:"entered"`
+	Exited      []SnapshotEntry `json:"exited"`
+	RankChanges []RankChange    `json:"rank_changes"`
+}
@@ -1,8 +1,10 @@
 package recommendations
 
 import (
+	"stonks-api/cmd/cache"
 	"stonks-api/cmd/database"
 	"stonks-api/internal/recommendations/handlers"
+	repository "stonks-api/internal/recommendations/repositories"
 	"stonks-api/internal/recommendations/services"
 	stocksRepository "stonks-api/internal/stocks/repositories"
 
@@ -14,9 +16,12 @@ type Module struct {
 	RecommendationService *services.RecommendationService
 }
 
-func NewModule(db database.Database) *Module {
+func NewModule(db database.Database, readCache *cache.ReadCache) *Module {
 	stockRepo := stocksRepository.NewStockRepository(db)
+	stockRepo.SetReadCache(readCache)
 	recommendationService := services.NewRecommendationService(stockRepo)
+	recommendationService.SetReadCache(readCache)
+	recommendationService.SetSnapshotRepository(repository.NewSnapshotRepository(db))
 	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
 
 	return &Module{
@@ -28,3 +33,7 @@ func NewModule(db database.Database) *Module {
 func (m *Module) RegisterRoutes(e *echo.Group) {
 	m.RecommendationHandler.RegisterRoutes(e)
 }
+
+func (m *Module) RegisterAdminRoutes(e *echo.Group) {
+	m.RecommendationHandler.RegisterAdminRoutes(e)
+}
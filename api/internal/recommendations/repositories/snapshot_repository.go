@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"stonks-api/cmd/database"
+	"stonks-api/internal/recommendations/models"
+	"time"
+)
+
+type SnapshotRepository struct {
+	db database.Database
+}
+
+func NewSnapshotRepository(db database.Database) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// snapshotRow is a stored snapshot with its entries still encoded
+type snapshotRow struct {
+	Date           string
+	Strategy       string
+	ScoringVersion string
+	CreatedAt      time.Time
+	Entries        string
+	Size           int
+}
+
+// SaveSnapshot stores the snapshot, replacing the one of the same day and strategy
+func (r *SnapshotRepository) SaveSnapshot(snapshot models.Snapshot) error {
+	entries, err := json.Marshal(snapshot.Entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot entries: %w", err)
+	}
+
+	err = r.db.Exec(`
+		INSERT INTO recommendation_snapshots (snapshot_date, strategy, scoring_version, entries, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (snapshot_date, strategy) DO UPDATE SET
+			scoring_version = excluded.scoring_version,
+			entries = excluded.entries,
+			created_at = excluded.created_at`,
+		snapshot.Date, snapshot.Strategy, snapshot.ScoringVersion, string(entries), snapshot.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot of %s: %w", snapshot.Date, err)
+	}
+
+	return nil
+}
+
+// GetSnapshot retrieves the snapshot of a strategy on a date, nil when there is none
+func (r *SnapshotRepository) GetSnapshot(date, strategy string) (*models.Snapshot, error) {
+	var rows []snapshotRow
+	err := r.db.Raw(`
+		SELECT snapshot_date::STRING AS date, strategy, scoring_version, created_at, entries::STRING AS entries
+		FROM recommendation_snapshots
+		WHERE snapshot_date = ? AND strategy = ?`,
+		date, strategy,
+	).Scan(&rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve snapshot of %s: %w", date, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	snapshot := models.Snapshot{
+		Date:           rows[0].Date,
+		Strategy:       rows[0].Strategy,
+		ScoringVersion: rows[0].ScoringVersion,
+		CreatedAt:      rows[0].CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(rows[0].Entries), &snapshot.Entries); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot of %s: %w", date, err)
+	}
+
+	return &snapshot, nil
+}
+
+// ListSnapshots retrieves the latest snapshots of a strategy up to the limit,
+// newest first
+func (r *SnapshotRepository) ListSnapshots(strategy string, limit int) ([]models.SnapshotSummary, error) {
+	var rows []snapshotRow
+	err := r.db.Raw(`
+		SELECT snapshot_date::STRING AS date, strategy, scoring_version, created_at, jsonb_array_length(entries) AS size
+		FROM recommendation_snapshots
+		WHERE strategy = ?
+		ORDER BY snapshot_date DESC
+		LIMIT ?`,
+		strategy, limit,
+	).Scan(&rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	summaries := make([]models.SnapshotSummary, len(rows))
+	for i, row := range rows {
+		summaries[i] = models.SnapshotSummary{
+			Date:           row.Date,
+			Strategy:       row.Strategy,
+			ScoringVersion: row.ScoringVersion,
+			CreatedAt:      row.CreatedAt,
+			Size:           row.Size,
+		}
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,132 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"errors"
+	"stonks-api/cmd/database"
+	"stonks-api/internal/recommendations/models"
+	repository "stonks-api/internal/recommendations/repositories"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	// The entries are stored as JSON, replacing the snapshot of the same day
+	t.Run("successful save", func(t *testing.T) {
+		var gotSQL string
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			ExecFn: func(sql string, values ...interface{}) error {
+				gotSQL, gotArgs = sql, values
+				return nil
+			},
+		}
+
+		snapshot := models.Snapshot{
+			Date:           "2025-03-01",
+			Strategy:       "consensus",
+			ScoringVersion: "ee38ecec39db",
+			CreatedAt:      time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Entries:        []models.SnapshotEntry{{Rank: 1, Ticker: "AAPL", Score: 3.5}},
+		}
+		if err := repository.NewSnapshotRepository(mockDB).SaveSnapshot(snapshot); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if !strings.Contains(gotSQL, "ON CONFLICT (snapshot_date, strategy)") {
+			t.Errorf("Expected an upsert on the day and strategy but got: %s", gotSQL)
+		}
+
+		if len(gotArgs) != 5 || gotArgs[0] != "2025-03-01" || !strings.Contains(gotArgs[3].(string), `"ticker":"AAPL"`) {
+			t.Errorf("Expected the date and encoded entries as arguments but got %v", gotArgs)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		mockDB := database.NewMockDatabaseWithError(errors.New("database error"))
+
+		if err := repository.NewSnapshotRepository(mockDB).SaveSnapshot(models.Snapshot{}); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestGetSnapshot(t *testing.T) {
+	// The stored entries are decoded
+	t.Run("successful retrieval", func(t *testing.T) {
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotArgs = values
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						return json.Unmarshal([]byte(`[{"Date": "2025-03-01", "Strategy": "consensus",
+							"Entries": "[{\"rank\": 1, \"ticker\": \"AAPL\", \"score\": 3.5}]"}]`), dest)
+					},
+				}
+			},
+		}
+
+		snapshot, err := repository.NewSnapshotRepository(mockDB).GetSnapshot("2025-03-01", "consensus")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if snapshot == nil || len(snapshot.Entries) != 1 || snapshot.Entries[0].Ticker != "AAPL" {
+			t.Errorf("Expected the AAPL entry but got %+v", snapshot)
+		}
+
+		if len(gotArgs) != 2 || gotArgs[0] != "2025-03-01" || gotArgs[1] != "consensus" {
+			t.Errorf("Expected the date and strategy as arguments but got %v", gotArgs)
+		}
+	})
+
+	// No snapshot on that day
+	t.Run("not found", func(t *testing.T) {
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				return &database.MockQuery{ScanFn: func(dest interface{}) error { return nil }}
+			},
+		}
+
+		snapshot, err := repository.NewSnapshotRepository(mockDB).GetSnapshot("2025-03-01", "consensus")
+		if err != nil || snapshot != nil {
+			t.Errorf("Expected no snapshot and no error but got %+v, %v", snapshot, err)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		mockDB := database.NewMockDatabaseWithError(errors.New("database error"))
+
+		if _, err := repository.NewSnapshotRepository(mockDB).GetSnapshot("2025-03-01", "consensus"); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestListSnapshots(t *testing.T) {
+	// Summaries are listed in the order returned
+	t.Run("successful list", func(t *testing.T) {
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						return json.Unmarshal([]byte(`[{"Date": "2025-03-02", "Size": 20}, {"Date": "2025-03-01", "Size": 18}]`), dest)
+					},
+				}
+			},
+		}
+
+		summaries, err := repository.NewSnapshotRepository(mockDB).ListSnapshots("consensus", 30)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(summaries) != 2 || summaries[0].Date != "2025-03-02" || summaries[1].Size != 18 {
+			t.Errorf("Expected two summaries newest first but got %+v", summaries)
+		}
+	})
+}
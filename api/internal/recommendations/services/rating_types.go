@@ -1,68 +1,25 @@
 package services
 
+import "stonks-api/internal/stocks/models"
+
 // Rating categories for recommendation calculation
 const (
-	RatingCategoryPositive = "Positive"
-	RatingCategoryNeutral  = "Neutral"
-	RatingCategoryNegative = "Negative"
+	RatingCategoryPositive = models.RatingCategoryPositive
+	RatingCategoryNeutral  = models.RatingCategoryNeutral
+	RatingCategoryNegative = models.RatingCategoryNegative
 )
 
-// getRatingCategoryMap maps specific rating strings to their categories
-func getRatingCategoryMap() map[string]string {
-	return map[string]string{
-		// Positive ratings
-		"Buy":               RatingCategoryPositive,
-		"Strong-Buy":        RatingCategoryPositive,
-		"Outperform":        RatingCategoryPositive,
-		"Outperformer":      RatingCategoryPositive,
-		"Overweight":        RatingCategoryPositive,
-		"Positive":          RatingCategoryPositive,
-		"Market Outperform": RatingCategoryPositive,
-		"Sector Outperform": RatingCategoryPositive,
-
-		// Neutral ratings
-		"Hold":           RatingCategoryNeutral,
-		"Neutral":        RatingCategoryNeutral,
-		"Equal Weight":   RatingCategoryNeutral,
-		"Market Perform": RatingCategoryNeutral,
-		"Sector Perform": RatingCategoryNeutral,
-		"In-Line":        RatingCategoryNeutral,
-		"Inline":         RatingCategoryNeutral,
-		"Peer Perform":   RatingCategoryNeutral,
-		"Sector Weight":  RatingCategoryNeutral,
-
-		// Negative ratings
-		"Sell":                RatingCategoryNegative,
-		"Reduce":              RatingCategoryNegative,
-		"Underperform":        RatingCategoryNegative,
-		"Underweight":         RatingCategoryNegative,
-		"Negative":            RatingCategoryNegative,
-		"Sector Underperform": RatingCategoryNegative,
-	}
-}
-
-// ratingCategories contains the mapping from rating string to category
-var ratingCategories = getRatingCategoryMap()
-
-// GetRatingScore returns a numeric score for a rating
+// GetRatingScore returns the numeric score of a rating under the default scoring
 func GetRatingScore(rating string) int {
-	category := GetRatingCategory(rating)
+	return int(defaultRatingScores.Score(GetRatingLevel("", rating)))
+}
 
-	switch category {
-	case RatingCategoryPositive:
-		return 5
-	case RatingCategoryNegative:
-		return 1
-	default:
-		return 3
-	}
+// GetRatingLevel returns the level of a rating published by a brokerage
+func GetRatingLevel(brokerage, rating string) models.RatingLevel {
+	return models.GetRatingLevel(brokerage, rating)
 }
 
 // GetRatingCategory returns the category of a rating
 func GetRatingCategory(rating string) string {
-	category, exists := ratingCategories[rating]
-	if !exists {
-		return RatingCategoryNeutral
-	}
-	return category
+	return models.GetRatingCategory(rating)
 }
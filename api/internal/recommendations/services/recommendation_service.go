@@ -1,88 +1,321 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"sort"
+	"stonks-api/cmd/cache"
+	recommendationModels "stonks-api/internal/recommendations/models"
 	"stonks-api/internal/stocks/models"
+	"sync"
+	"time"
 )
 
 type StockRepository interface {
-	GetRecentStocks(limit int) ([]models.Stock, error)
+	GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error)
+	GetLatestCallsSince(since time.Time, filter models.StockFilter, limit int) ([]models.Stock, error)
 }
 
 type RecommendationServiceInterface interface {
-	GetRecommendations() ([]StockRecommendation, error)
+	GetRecommendations(options RecommendationOptions) (Recommendations, error)
+	Strategies() []StrategyInfo
+	ScoringConfig() ScoringConfig
+	SetScoringConfig(config ScoringConfig) (ScoringConfig, error)
+	ReloadScoringConfig() (ScoringConfig, error)
+	TakeSnapshot(strategy string) (recommendationModels.Snapshot, error)
+	GetSnapshot(date time.Time, strategy string) (recommendationModels.Snapshot, error)
+	ListSnapshots(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error)
+	DiffSnapshots(from, to time.Time, strategy string) (recommendationModels.SnapshotDiff, error)
 }
 
+// StockRecommendation is a scored stock. Factors break the score down, Reason
+// summarizes them in words.
 type StockRecommendation struct {
-	Stock  models.Stock `json:"stock"`
-	Score  float64      `json:"score"`
-	Reason string       `json:"reason"`
+	Stock   models.Stock  `json:"stock"`
+	Score   float64       `json:"score"`
+	Reason  string        `json:"reason"`
+	Factors []ScoreFactor `json:"factors"`
 }
 
+// Defaults and bounds of the recommendation options
+const (
+	DefaultRecommendationLimit = 5
+	DefaultLookbackEvents      = 200
+	DefaultWindowLookbackCalls = 1000
+	MaxRecommendationLimit     = 100
+	MaxLookbackEvents          = 5000
+)
+
+// RecommendationOptions select the strategy, the rating events it ranks and
+// the page of the ranked list to return. Zero values use the defaults.
+type RecommendationOptions struct {
+	Strategy string
+	// Direction ranks the tickers to buy, or to avoid with DirectionNegative
+	Direction Direction
+	// Limit is the page size, DefaultRecommendationLimit when zero
+	Limit int
+	// Page is the 1-based page of the ranked list
+	Page int
+	// Lookback ignores events older than the duration
+	Lookback time.Duration
+	// LookbackEvents caps the number of most recent events ranked, defaulting
+	// to DefaultLookbackEvents, or to DefaultWindowLookbackCalls latest calls
+	// for windowed strategies
+	LookbackEvents int
+	// MinScore drops recommendations scoring below it
+	MinScore *float64
+	// MaxScore drops recommendations scoring above it
+	MaxScore       *float64
+	Tickers        []string
+	ExcludeTickers []string
+	Brokerages     []string
+	Sector         string
+}
+
+// Recommendations are a page of the scored stocks along with the strategy,
+// direction and scoring configuration that produced them
+type Recommendations struct {
+	Strategy        string                `json:"strategy"`
+	Direction       Direction             `json:"direction"`
+	Scoring         ScoringConfig         `json:"scoring"`
+	Recommendations []StockRecommendation `json:"recommendations"`
+	TotalCount      int                   `json:"total_count"`
+	PageSize        int                   `json:"page_size"`
+	Page            int                   `json:"page"`
+	TotalPages      int                   `json:"total_pages"`
+}
+
+// ErrNoScoringFile is returned when reloading without a scoring config file
+var ErrNoScoringFile = errors.New("no scoring config file is configured")
+
 type RecommendationService struct {
 	stockRepository StockRepository
+	strategies      *StrategyRegistry
+
+	scoringMu   sync.RWMutex
+	scoring     ScoringConfig
+	scoringFile string
+
+	readCache *cache.ReadCache
+
+	snapshotRepository SnapshotRepository
+	snapshotSize       int
 }
 
 func NewRecommendationService(stockRepository StockRepository) *RecommendationService {
 	return &RecommendationService{
 		stockRepository: stockRepository,
+		strategies:      DefaultStrategyRegistry(),
+		scoring:         DefaultScoringConfig(),
+	}
+}
+
+// SetReadCache sets the read cache to invalidate whenever the scoring changes
+func (s *RecommendationService) SetReadCache(readCache *cache.ReadCache) {
+	s.readCache = readCache
+}
+
+// ScoringConfig returns the scoring configuration in use
+func (s *RecommendationService) ScoringConfig() ScoringConfig {
+	s.scoringMu.RLock()
+	defer s.scoringMu.RUnlock()
+
+	return s.scoring
+}
+
+// SetScoringConfig validates the configuration and uses it for the following
+// recommendations. It returns the configuration with its version.
+func (s *RecommendationService) SetScoringConfig(config ScoringConfig) (ScoringConfig, error) {
+	if err := config.Validate(); err != nil {
+		return ScoringConfig{}, fmt.Errorf("%w: %w", ErrInvalidScoringConfig, err)
 	}
+	config.Version = config.computeVersion()
+
+	s.scoringMu.Lock()
+	s.scoring = config
+	s.scoringMu.Unlock()
+
+	// Cached recommendations were scored with the previous weights
+	if s.readCache != nil {
+		s.readCache.Invalidate()
+	}
+
+	return config, nil
 }
 
-func (s *RecommendationService) GetRecommendations() ([]StockRecommendation, error) {
-	// Only fetch the 200 most recent stocks instead of all stocks
-	stocks, err := s.stockRepository.GetRecentStocks(200)
+// LoadScoringFile loads the scoring configuration from path and remembers the
+// path for ReloadScoringConfig
+func (s *RecommendationService) LoadScoringFile(path string) (ScoringConfig, error) {
+	s.scoringMu.Lock()
+	s.scoringFile = path
+	s.scoringMu.Unlock()
+
+	return s.ReloadScoringConfig()
+}
+
+// ReloadScoringConfig reads the scoring configuration file again. The current
+// configuration is kept when the file is invalid.
+func (s *RecommendationService) ReloadScoringConfig() (ScoringConfig, error) {
+	s.scoringMu.RLock()
+	path := s.scoringFile
+	s.scoringMu.RUnlock()
+
+	if path == "" {
+		return ScoringConfig{}, ErrNoScoringFile
+	}
+
+	config, err := LoadScoringConfig(path)
 	if err != nil {
-		return nil, err
+		return ScoringConfig{}, err
 	}
 
-	recommendations := make([]StockRecommendation, 0, len(stocks)/2)
-	// Map to ensure to only include one recommendation per ticker
-	tickerMap := make(map[string]bool)
+	return s.SetScoringConfig(config)
+}
 
-	for _, stock := range stocks {
-		if _, exists := tickerMap[stock.Ticker]; exists {
-			continue
-		}
+// GetRecommendations ranks the recent rating events matching the options with
+// the selected strategy, the default one when none is named, and returns the
+// requested page of the ranked list. The tickers to avoid are ranked lowest
+// score first.
+func (s *RecommendationService) GetRecommendations(options RecommendationOptions) (Recommendations, error) {
+	strategy, err := s.strategies.Get(options.Strategy)
+	if err != nil {
+		return Recommendations{}, err
+	}
+
+	direction := options.Direction
+	if direction == "" {
+		direction = DirectionPositive
+	}
+	avoider, canAvoid := strategy.(AvoidStrategy)
+	if direction == DirectionNegative && !canAvoid {
+		return Recommendations{}, fmt.Errorf("%w: %s cannot rank %s", ErrUnsupportedDirection, strategy.Name(), direction)
+	}
 
-		score, reason := s.calculateScore(stock)
+	pageSize := options.Limit
+	if pageSize <= 0 {
+		pageSize = DefaultRecommendationLimit
+	}
+	page := options.Page
+	if page < 1 {
+		page = 1
+	}
 
-		if score > 0 {
-			recommendations = append(recommendations, StockRecommendation{
-				Stock:  stock,
-				Score:  score,
-				Reason: reason,
-			})
+	// Score the whole batch with the same weights even if they are reloaded meanwhile
+	scoring := s.ScoringConfig()
+
+	filter := models.StockFilter{
+		Tickers:        options.Tickers,
+		ExcludeTickers: options.ExcludeTickers,
+		Brokerages:     options.Brokerages,
+		Sector:         options.Sector,
+	}
 
-			// Mark ticker as processed
-			tickerMap[stock.Ticker] = true
+	var stocks []models.Stock
+	if windowed, ok := strategy.(WindowedStrategy); ok {
+		window := windowed.Window(scoring)
+		if options.Lookback > 0 && options.Lookback < window {
+			window = options.Lookback
 		}
+		limit := options.LookbackEvents
+		if limit <= 0 {
+			limit = DefaultWindowLookbackCalls
+		}
+		stocks, err = s.stockRepository.GetLatestCallsSince(time.Now().Add(-window), filter, limit)
+	} else {
+		if options.Lookback > 0 {
+			from := time.Now().Add(-options.Lookback)
+			filter.From = &from
+		}
+		// Only fetch the most recent stocks instead of all stocks
+		limit := options.LookbackEvents
+		if limit <= 0 {
+			limit = DefaultLookbackEvents
+		}
+		stocks, err = s.stockRepository.GetRecentStocks(filter, limit)
+	}
+	if err != nil {
+		return Recommendations{}, err
+	}
+
+	var recommendations []StockRecommendation
+	if direction == DirectionNegative {
+		recommendations = avoider.Avoid(stocks, scoring)
+	} else {
+		recommendations = strategy.Recommend(stocks, scoring)
+	}
+
+	if options.MinScore != nil || options.MaxScore != nil {
+		kept := recommendations[:0]
+		for _, recommendation := range recommendations {
+			if options.MinScore != nil && recommendation.Score < *options.MinScore {
+				continue
+			}
+			if options.MaxScore != nil && recommendation.Score > *options.MaxScore {
+				continue
+			}
+			kept = append(kept, recommendation)
+		}
+		recommendations = kept
 	}
 
-	// Sort by score (highest first)
-	sort.Slice(recommendations, func(i, j int) bool {
+	// Sort by score (highest first, lowest first for the tickers to avoid),
+	// ties keep the strategy's order
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if direction == DirectionNegative {
+			return recommendations[i].Score < recommendations[j].Score
+		}
 		return recommendations[i].Score > recommendations[j].Score
 	})
 
-	// Return top 5 recommendations or all if less than 5
-	if len(recommendations) > 5 {
-		return recommendations[:5], nil
-	}
-	return recommendations, nil
+	total := len(recommendations)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+
+	return Recommendations{
+		Strategy:        strategy.Name(),
+		Direction:       direction,
+		Scoring:         scoring,
+		Recommendations: recommendations[start:end],
+		TotalCount:      total,
+		PageSize:        pageSize,
+		Page:            page,
+		TotalPages:      (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// Strategies describes the strategies selectable by name
+func (s *RecommendationService) Strategies() []StrategyInfo {
+	return s.strategies.List()
+}
+
+// RegisterStrategy makes a strategy selectable by its name
+func (s *RecommendationService) RegisterStrategy(strategy Strategy) error {
+	return s.strategies.Register(strategy)
 }
 
-// calculateScore assigns a score to a stock based on various factors
-func (s *RecommendationService) calculateScore(stock models.Stock) (float64, string) {
-	var score float64
-	var reason string
+// calculateScore breaks the score of a stock down into factors, weighted by
+// the scoring configuration. Negative ratings only count against a stock when
+// ranking the tickers to avoid, the positive ranking is left as it was.
+func calculateScore(stock models.Stock, scoring ScoringConfig, direction Direction) []ScoreFactor {
+	negative := direction == DirectionNegative
+
+	var factors []ScoreFactor
 
 	// 1: Upgrade vs downgrade
 	if stock.Action == "upgraded by" {
-		score += 2.0
-		reason = "Stock was recently upgraded"
+		factors = append(factors, ScoreFactor{
+			ID:           FactorActionUpgraded,
+			Description:  "Stock was recently upgraded",
+			Inputs:       map[string]interface{}{"action": stock.Action},
+			Contribution: scoring.Actions.Upgraded,
+		})
 	} else if stock.Action == "downgraded by" {
-		score -= 2.0
-		reason = "Stock was recently downgraded"
+		factors = append(factors, ScoreFactor{
+			ID:           FactorActionDowngraded,
+			Description:  "Stock was recently downgraded",
+			Inputs:       map[string]interface{}{"action": stock.Action},
+			Contribution: scoring.Actions.Downgraded,
+		})
 	}
 
 	// 2: Target price change
@@ -92,80 +325,106 @@ func (s *RecommendationService) calculateScore(stock models.Stock) (float64, str
 		targetPercentChange = (targetChange / stock.TargetFrom) * 100
 	}
 
-	if targetPercentChange > 10 {
-		score += 2.0
-		if reason == "" {
-			reason = "Target price increased significantly"
-		} else {
-			reason += ", Target price increased significantly"
-		}
+	target := scoring.Target
+	targetFactor := ScoreFactor{Inputs: map[string]interface{}{
+		"target_from":    stock.TargetFrom,
+		"target_to":      stock.TargetTo,
+		"change_percent": targetPercentChange,
+	}}
+	if targetPercentChange > target.SignificantChangePercent {
+		targetFactor.ID, targetFactor.Description = FactorTargetRaisedSignificantly, "Target price increased significantly"
+		targetFactor.Contribution = target.SignificantWeight
 	} else if targetPercentChange > 0 {
-		score += 1.0
-		if reason == "" {
-			reason = "Target price increased"
-		} else {
-			reason += ", Target price increased"
-		}
-	} else if targetPercentChange < -10 {
-		score -= 2.0
-		if reason == "" {
-			reason = "Target price decreased significantly"
-		} else {
-			reason += ", Target price decreased significantly"
-		}
+		targetFactor.ID, targetFactor.Description = FactorTargetRaised, "Target price increased"
+		targetFactor.Contribution = target.ChangeWeight
+	} else if targetPercentChange < -target.SignificantChangePercent {
+		targetFactor.ID, targetFactor.Description = FactorTargetCutSignificantly, "Target price decreased significantly"
+		targetFactor.Contribution = -target.SignificantWeight
 	} else if targetPercentChange < 0 {
-		score -= 1.0
-		if reason == "" {
-			reason = "Target price decreased"
-		} else {
-			reason += ", Target price decreased"
-		}
+		targetFactor.ID, targetFactor.Description = FactorTargetCut, "Target price decreased"
+		targetFactor.Contribution = -target.ChangeWeight
+	}
+	if targetFactor.ID != "" {
+		factors = append(factors, targetFactor)
 	}
 
 	// 3: Rating improvement
-	fromScore := GetRatingScore(stock.RatingFrom)
-	toScore := GetRatingScore(stock.RatingTo)
+	fromLevel := GetRatingLevel(stock.Brokerage, stock.RatingFrom)
+	toLevel := GetRatingLevel(stock.Brokerage, stock.RatingTo)
+	fromScore := scoring.RatingScores.Score(fromLevel)
+	toScore := scoring.RatingScores.Score(toLevel)
+	strength := scoring.RatingStrength
 
+	ratingInputs := map[string]interface{}{
+		"rating_from": stock.RatingFrom,
+		"rating_to":   stock.RatingTo,
+		"from_level":  fromLevel.String(),
+		"to_level":    toLevel.String(),
+		"from_score":  fromScore,
+		"to_score":    toScore,
+	}
 	ratingChange := toScore - fromScore
 	if ratingChange > 0 {
-		score += float64(ratingChange) * 0.5
-		if reason == "" {
-			reason = "Rating improved"
-		} else {
-			reason += ", Rating improved"
-		}
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingImproved,
+			Description:  "Rating improved",
+			Inputs:       ratingInputs,
+			Contribution: ratingChange * scoring.RatingChange.Multiplier,
+		})
 	} else if ratingChange < 0 {
-		score += float64(ratingChange) * 0.5
-		if reason == "" {
-			reason = "Rating downgraded"
-		} else {
-			reason += ", Rating downgraded"
-		}
-	} else if toScore >= 5 {
-		score += 0.5
-		if reason == "" {
-			reason = "Maintained positive rating"
-		} else {
-			reason += ", Maintained positive rating"
-		}
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingDowngraded,
+			Description:  "Rating downgraded",
+			Inputs:       ratingInputs,
+			Contribution: ratingChange * scoring.RatingChange.Multiplier,
+		})
+	} else if toScore >= strength.PositiveThreshold {
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingMaintainedPositive,
+			Description:  "Maintained positive rating",
+			Inputs:       ratingInputs,
+			Contribution: scoring.RatingChange.MaintainedPositive,
+		})
+	} else if negative && toScore <= strength.NegativeThreshold {
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingMaintainedNegative,
+			Description:  "Maintained negative rating",
+			Inputs:       ratingInputs,
+			Contribution: -scoring.RatingChange.MaintainedNegative,
+		})
 	}
 
 	// 4: Current rating strength
-	if toScore >= 7 { // Strong Buy or Buy
-		score += 2.0
-		if reason == "" {
-			reason = "Strong positive rating"
-		} else {
-			reason += ", Strong positive rating"
-		}
-	} else if toScore >= 5 { // Outperform, Overweight
-		score += 1.0
-		if reason == "" {
-			reason = "Positive rating"
-		} else {
-			reason += ", Positive rating"
-		}
+	strengthInputs := map[string]interface{}{"rating_to": stock.RatingTo, "rating_level": toLevel.String(), "rating_score": toScore}
+	if toScore >= strength.StrongThreshold { // Strong Buy
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingStrong,
+			Description:  "Strong positive rating",
+			Inputs:       strengthInputs,
+			Contribution: strength.StrongWeight,
+		})
+	} else if toScore >= strength.PositiveThreshold { // Buy, Outperform, Overweight
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingPositive,
+			Description:  "Positive rating",
+			Inputs:       strengthInputs,
+			Contribution: strength.PositiveWeight,
+		})
+	} else if negative && toScore <= strength.StrongNegativeThreshold { // Strong Sell
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingStrongNegative,
+			Description:  "Strong negative rating",
+			Inputs:       strengthInputs,
+			Contribution: -strength.StrongNegativeWeight,
+		})
+	} else if negative && toScore <= strength.NegativeThreshold { // Sell, Underperform, Underweight
+		factors = append(factors, ScoreFactor{
+			ID:           FactorRatingNegative,
+			Description:  "Negative rating",
+			Inputs:       strengthInputs,
+			Contribution: -strength.NegativeWeight,
+		})
 	}
 
-	return score, reason
+	return factors
 }
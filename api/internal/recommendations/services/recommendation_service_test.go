@@ -2,9 +2,15 @@ package services_test
 
 import (
 	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"stonks-api/internal/recommendations/mocks"
 	"stonks-api/internal/recommendations/services"
 	"stonks-api/internal/stocks/models"
+	"strings"
 	"testing"
 	"time"
 )
@@ -49,14 +55,15 @@ func TestGetRecommendations(t *testing.T) {
 		}
 
 		mockRepo := &mocks.MockStockRepository{
-			GetRecentStocksFn: func(limit int) ([]models.Stock, error) {
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
 				return stocks, nil
 			},
 		}
 
 		service := services.NewRecommendationService(mockRepo)
 
-		recommendations, err := service.GetRecommendations()
+		result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+		recommendations := result.Recommendations
 
 		if err != nil {
 			t.Errorf("Expected no error but got: %v", err)
@@ -124,14 +131,15 @@ func TestGetRecommendations(t *testing.T) {
 		}
 
 		mockRepo := &mocks.MockStockRepository{
-			GetRecentStocksFn: func(limit int) ([]models.Stock, error) {
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
 				return stocks, nil
 			},
 		}
 
 		service := services.NewRecommendationService(mockRepo)
 
-		recommendations, err := service.GetRecommendations()
+		result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+		recommendations := result.Recommendations
 
 		if err != nil {
 			t.Errorf("Expected no error but got: %v", err)
@@ -145,14 +153,14 @@ func TestGetRecommendations(t *testing.T) {
 	// Repository error
 	t.Run("repository error", func(t *testing.T) {
 		mockRepo := &mocks.MockStockRepository{
-			GetRecentStocksFn: func(limit int) ([]models.Stock, error) {
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
 				return nil, errors.New("repository error")
 			},
 		}
 
 		service := services.NewRecommendationService(mockRepo)
 
-		_, err := service.GetRecommendations()
+		_, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
 
 		if err == nil {
 			t.Errorf("Expected error but got nil")
@@ -231,14 +239,15 @@ func TestGetRecommendations(t *testing.T) {
 		}
 
 		mockRepo := &mocks.MockStockRepository{
-			GetRecentStocksFn: func(limit int) ([]models.Stock, error) {
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
 				return stocks, nil
 			},
 		}
 
 		service := services.NewRecommendationService(mockRepo)
 
-		recommendations, err := service.GetRecommendations()
+		result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+		recommendations := result.Recommendations
 
 		if err != nil {
 			t.Errorf("Expected no error but got: %v", err)
@@ -260,7 +269,7 @@ func TestGetRecommendations(t *testing.T) {
 
 func TestGetRatingScore(t *testing.T) {
 	t.Run("test rating score calculation", func(t *testing.T) {
-		positiveRatings := []string{"Buy", "Strong-Buy", "Outperform", "Overweight"}
+		positiveRatings := []string{"Buy", "Outperform", "Overweight"}
 		neutralRatings := []string{"Hold", "Neutral", "Equal Weight", "Market Perform"}
 		negativeRatings := []string{"Sell", "Reduce", "Underperform", "Underweight"}
 
@@ -285,6 +294,15 @@ func TestGetRatingScore(t *testing.T) {
 			}
 		}
 
+		// Strong ratings sit above and below the plain ones
+		if score := services.GetRatingScore("Strong-Buy"); score != 7 {
+			t.Errorf("Expected strong buy score of 7 but got %d", score)
+		}
+
+		if score := services.GetRatingScore("Strong Sell"); score != -1 {
+			t.Errorf("Expected strong sell score of -1 but got %d", score)
+		}
+
 		// Test unknown rating
 		score := services.GetRatingScore("Unknown Rating")
 		if score != 3 {
@@ -292,3 +310,707 @@ func TestGetRatingScore(t *testing.T) {
 		}
 	})
 }
+
+func TestScoringConfig(t *testing.T) {
+	upgrade := models.Stock{
+		Ticker:     "AAPL",
+		Action:     "upgraded by",
+		RatingFrom: "Hold",
+		RatingTo:   "Buy",
+		TargetFrom: 100.0,
+		TargetTo:   120.0,
+		Time:       time.Now(),
+	}
+	mockRepo := &mocks.MockStockRepository{
+		GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+			return []models.Stock{upgrade}, nil
+		},
+	}
+
+	// The default weights reproduce the original scoring
+	t.Run("default weights", func(t *testing.T) {
+		service := services.NewRecommendationService(mockRepo)
+
+		result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		// Upgrade 2, significant target raise 2, rating change (5-3)*0.5, positive rating 1
+		if len(result.Recommendations) != 1 || result.Recommendations[0].Score != 6 {
+			t.Errorf("Expected a score of 6 but got %+v", result.Recommendations)
+		}
+
+		if result.Scoring != services.DefaultScoringConfig() || result.Scoring.Version == "" {
+			t.Errorf("Expected the versioned default config but got %+v", result.Scoring)
+		}
+	})
+
+	// Custom weights change the score and the version
+	t.Run("custom weights", func(t *testing.T) {
+		service := services.NewRecommendationService(mockRepo)
+
+		config := services.DefaultScoringConfig()
+		config.Actions.Upgraded = 5
+		config.Target.SignificantChangePercent = 50
+
+		applied, err := service.SetScoringConfig(config)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if applied.Version == services.DefaultScoringConfig().Version {
+			t.Errorf("Expected a new version for custom weights")
+		}
+
+		result, _ := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+
+		// Upgrade 5, target raise below 50% 1, rating change 1, positive rating 1
+		if len(result.Recommendations) != 1 || result.Recommendations[0].Score != 8 {
+			t.Errorf("Expected a score of 8 but got %+v", result.Recommendations)
+		}
+
+		if result.Scoring != applied {
+			t.Errorf("Expected the applied config to be echoed but got %+v", result.Scoring)
+		}
+	})
+
+	// Inconsistent weights are rejected and the current ones kept
+	t.Run("invalid weights", func(t *testing.T) {
+		service := services.NewRecommendationService(mockRepo)
+
+		config := services.DefaultScoringConfig()
+		config.RatingScores.Negative = 10
+
+		_, err := service.SetScoringConfig(config)
+		if !errors.Is(err, services.ErrInvalidScoringConfig) {
+			t.Errorf("Expected an invalid config error but got %v", err)
+		}
+
+		if service.ScoringConfig() != services.DefaultScoringConfig() {
+			t.Errorf("Expected the default config to be kept")
+		}
+
+		// Negative thresholds must stay below the positive ones
+		config = services.DefaultScoringConfig()
+		config.RatingStrength.NegativeThreshold = config.RatingStrength.PositiveThreshold
+		if _, err := service.SetScoringConfig(config); !errors.Is(err, services.ErrInvalidScoringConfig) {
+			t.Errorf("Expected an invalid config error but got %v", err)
+		}
+	})
+
+	// The file is read again on reload, an invalid file keeps the current config
+	t.Run("reload file", func(t *testing.T) {
+		service := services.NewRecommendationService(mockRepo)
+		path := filepath.Join(t.TempDir(), "scoring.json")
+
+		if _, err := service.ReloadScoringConfig(); !errors.Is(err, services.ErrNoScoringFile) {
+			t.Errorf("Expected a missing file error but got %v", err)
+		}
+
+		os.WriteFile(path, []byte(`{"actions": {"upgraded": 3}}`), 0o600)
+		loaded, err := service.LoadScoringFile(path)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		// Omitted fields keep their default
+		if loaded.Actions.Upgraded != 3 || loaded.Actions.Downgraded != -2 {
+			t.Errorf("Expected upgraded 3 and the default downgraded but got %+v", loaded.Actions)
+		}
+
+		os.WriteFile(path, []byte(`{"actions": {"upgraded": 4}, "unknown": 1}`), 0o600)
+		if _, err := service.ReloadScoringConfig(); !errors.Is(err, services.ErrInvalidScoringConfig) {
+			t.Errorf("Expected an invalid config error but got %v", err)
+		}
+
+		if service.ScoringConfig() != loaded {
+			t.Errorf("Expected the loaded config to be kept but got %+v", service.ScoringConfig())
+		}
+
+		os.WriteFile(path, []byte(`{"actions": {"upgraded": 4}}`), 0o600)
+		reloaded, err := service.ReloadScoringConfig()
+		if err != nil || reloaded.Actions.Upgraded != 4 {
+			t.Errorf("Expected the reloaded weights but got %+v, %v", reloaded.Actions, err)
+		}
+	})
+}
+
+func TestRecommendationStrategies(t *testing.T) {
+	now := time.Now()
+	stocks := []models.Stock{
+		{Ticker: "AAPL", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 100, TargetTo: 110, Time: now},
+		{Ticker: "MSFT", Action: "target raised by", RatingFrom: "Buy", RatingTo: "Buy", TargetFrom: 100, TargetTo: 150, Time: now.Add(-time.Hour)},
+		{Ticker: "AAPL", Action: "upgraded by", RatingFrom: "Sell", RatingTo: "Hold", TargetFrom: 90, TargetTo: 100, Time: now.Add(-2 * time.Hour)},
+		{Ticker: "GOOG", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 200, TargetTo: 190, Time: now.Add(-3 * time.Hour)},
+		{Ticker: "MSFT", Action: "downgraded by", RatingFrom: "Buy", RatingTo: "Hold", TargetFrom: 160, TargetTo: 100, Time: now.Add(-4 * time.Hour)},
+	}
+	mockRepo := &mocks.MockStockRepository{
+		GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+			return stocks, nil
+		},
+	}
+	service := services.NewRecommendationService(mockRepo)
+
+	tests := []struct {
+		strategy string
+		tickers  []string
+		scores   []float64
+	}{
+		// Two upgrades for AAPL, one for GOOG
+		{"momentum", []string{"AAPL", "GOOG"}, []float64{2, 1}},
+		// Latest targets: MSFT +50%, AAPL +10%, GOOG was cut
+		{"target_upside", []string{"MSFT", "AAPL"}, []float64{50, 10}},
+		// AAPL moved Hold->Buy and Sell->Hold, GOOG Hold->Buy, MSFT lost a Buy
+		{"consensus_change", []string{"AAPL", "GOOG"}, []float64{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: tt.strategy})
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if result.Strategy != tt.strategy {
+				t.Errorf("Expected strategy %s to be echoed but got %s", tt.strategy, result.Strategy)
+			}
+
+			if len(result.Recommendations) != len(tt.tickers) {
+				t.Fatalf("Expected %d recommendations but got %+v", len(tt.tickers), result.Recommendations)
+			}
+
+			for i, recommendation := range result.Recommendations {
+				if recommendation.Stock.Ticker != tt.tickers[i] || recommendation.Score != tt.scores[i] {
+					t.Errorf("Expected %s with score %v at %d but got %s with %v",
+						tt.tickers[i], tt.scores[i], i, recommendation.Stock.Ticker, recommendation.Score)
+				}
+				if recommendation.Reason == "" {
+					t.Errorf("Expected a reason for %s", recommendation.Stock.Ticker)
+				}
+			}
+		})
+	}
+
+	avoidTests := []struct {
+		strategy string
+		tickers  []string
+		scores   []float64
+	}{
+		// One downgrade for MSFT
+		{"momentum", []string{"MSFT"}, []float64{-1}},
+		// GOOG's latest target was cut 5%, MSFT's latest was raised
+		{"target_upside", []string{"GOOG"}, []float64{-5}},
+		// MSFT's Buy became a Hold, its Buy was then maintained
+		{"consensus_change", []string{"MSFT"}, []float64{-1}},
+	}
+
+	for _, tt := range avoidTests {
+		t.Run(tt.strategy+" avoid", func(t *testing.T) {
+			result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: tt.strategy, Direction: services.DirectionNegative})
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if result.Direction != services.DirectionNegative {
+				t.Errorf("Expected the negative direction to be echoed but got %q", result.Direction)
+			}
+
+			if len(result.Recommendations) != len(tt.tickers) {
+				t.Fatalf("Expected %d tickers to avoid but got %+v", len(tt.tickers), result.Recommendations)
+			}
+
+			for i, recommendation := range result.Recommendations {
+				if recommendation.Stock.Ticker != tt.tickers[i] || recommendation.Score != tt.scores[i] {
+					t.Errorf("Expected %s with score %v at %d but got %s with %v",
+						tt.tickers[i], tt.scores[i], i, recommendation.Stock.Ticker, recommendation.Score)
+				}
+				var sum float64
+				for _, factor := range recommendation.Factors {
+					sum += factor.Contribution
+				}
+				if math.Abs(sum-recommendation.Score) > 1e-9 {
+					t.Errorf("Expected the contributions of %s to add up to %v but got %v", recommendation.Stock.Ticker, recommendation.Score, sum)
+				}
+			}
+		})
+	}
+
+	// The tickers to avoid are ranked lowest score first
+	t.Run("heuristic avoid", func(t *testing.T) {
+		result, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic", Direction: services.DirectionNegative})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		// MSFT's downgrade with a 37.5% target cut, GOOG has no negative event
+		if len(result.Recommendations) != 1 || result.Recommendations[0].Stock.Ticker != "MSFT" {
+			t.Fatalf("Expected MSFT to avoid but got %+v", result.Recommendations)
+		}
+		if !strings.Contains(result.Recommendations[0].Reason, "Stock was recently downgraded") {
+			t.Errorf("Expected the reason to explain the downgrade but got %q", result.Recommendations[0].Reason)
+		}
+	})
+
+	// Strategies that cannot rank the tickers to avoid are rejected
+	t.Run("unsupported direction", func(t *testing.T) {
+		registry := services.NewRecommendationService(mockRepo)
+		if err := registry.RegisterStrategy(upgradesOnlyStrategy{}); err != nil {
+			t.Fatalf("Expected no error registering a strategy but got: %v", err)
+		}
+
+		_, err := registry.GetRecommendations(services.RecommendationOptions{Strategy: "upgrades_only", Direction: services.DirectionNegative})
+		if !errors.Is(err, services.ErrUnsupportedDirection) {
+			t.Errorf("Expected an unsupported direction error but got %v", err)
+		}
+
+		for _, info := range registry.Strategies() {
+			negative := slices.Contains(info.Directions, services.DirectionNegative)
+			if negative == (info.Name == "upgrades_only") {
+				t.Errorf("Expected only the built-in strategies to rank the tickers to avoid but got %+v", info)
+			}
+		}
+	})
+
+	// No name selects the default strategy
+	t.Run("default strategy", func(t *testing.T) {
+		result, err := service.GetRecommendations(services.RecommendationOptions{})
+		if err != nil || result.Strategy != services.DefaultStrategy {
+			t.Errorf("Expected the default strategy but got %q, %v", result.Strategy, err)
+		}
+	})
+
+	// Unknown names are rejected
+	t.Run("unknown strategy", func(t *testing.T) {
+		_, err := service.GetRecommendations(services.RecommendationOptions{Strategy: "astrology"})
+		if !errors.Is(err, services.ErrUnknownStrategy) {
+			t.Errorf("Expected an unknown strategy error but got %v", err)
+		}
+	})
+
+	// Strategies are listed in registration order, names are unique
+	t.Run("registry", func(t *testing.T) {
+		infos := service.Strategies()
+		if len(infos) != 5 || infos[0].Name != services.DefaultStrategy || !infos[0].Default {
+			t.Errorf("Expected the built-in strategies with the default first but got %+v", infos)
+		}
+
+		if err := service.RegisterStrategy(services.MomentumStrategy{}); err == nil {
+			t.Errorf("Expected an error registering a duplicate strategy")
+		}
+	})
+}
+
+// upgradesOnlyStrategy is a strategy without a ranking of the tickers to avoid
+type upgradesOnlyStrategy struct{}
+
+func (upgradesOnlyStrategy) Name() string        { return "upgrades_only" }
+func (upgradesOnlyStrategy) Description() string { return "Upgrades only" }
+
+func (upgradesOnlyStrategy) Recommend(stocks []models.Stock, scoring services.ScoringConfig) []services.StockRecommendation {
+	return services.MomentumStrategy{}.Recommend(stocks, scoring)
+}
+
+func TestConsensusStrategy(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	call := func(ticker, brokerage string, positive bool, age time.Duration) models.Stock {
+		stock := models.Stock{Ticker: ticker, Brokerage: brokerage, Time: now.Add(-age)}
+		if positive {
+			stock.Action, stock.RatingFrom, stock.RatingTo, stock.TargetFrom, stock.TargetTo = "upgraded by", "Hold", "Buy", 100, 120
+		} else {
+			stock.Action, stock.RatingFrom, stock.RatingTo, stock.TargetFrom, stock.TargetTo = "downgraded by", "Buy", "Sell", 100, 80
+		}
+		return stock
+	}
+	day := 24 * time.Hour
+
+	stocks := []models.Stock{
+		// Recent positive calls outweigh an old negative one
+		call("TSLA", "A", true, 0),
+		call("TSLA", "B", true, day),
+		call("TSLA", "C", false, 20*day),
+		// The same calls with the negative one recent rank lower
+		call("NVDA", "A", false, 0),
+		call("NVDA", "B", true, day),
+		call("NVDA", "C", true, 20*day),
+		// Only the latest call of a brokerage counts, two brokerages are not enough
+		call("MSFT", "A", true, 0),
+		call("MSFT", "B", true, day),
+		call("MSFT", "A", true, 2*day),
+		// Calls outside the window are ignored
+		call("GOOG", "A", true, 0),
+		call("GOOG", "B", true, day),
+		call("GOOG", "C", true, 40*day),
+	}
+	sort.SliceStable(stocks, func(i, j int) bool { return stocks[i].Time.After(stocks[j].Time) })
+
+	strategy := services.ConsensusStrategy{Now: func() time.Time { return now }}
+	recommendations := strategy.Recommend(stocks, services.DefaultScoringConfig())
+
+	if len(recommendations) != 2 {
+		t.Fatalf("Expected TSLA and NVDA but got %+v", recommendations)
+	}
+
+	scores := map[string]float64{}
+	for _, recommendation := range recommendations {
+		scores[recommendation.Stock.Ticker] = recommendation.Score
+	}
+
+	if scores["TSLA"] <= scores["NVDA"] || scores["NVDA"] <= 0 {
+		t.Errorf("Expected TSLA to outrank NVDA thanks to time decay but got %v", scores)
+	}
+
+	// Counting the negative ratings against them, the recent negative call
+	// makes NVDA a ticker to avoid
+	avoid := strategy.Avoid(stocks, services.DefaultScoringConfig())
+	if len(avoid) != 1 || avoid[0].Stock.Ticker != "NVDA" || avoid[0].Score >= 0 {
+		t.Fatalf("Expected NVDA to avoid thanks to time decay but got %+v", avoid)
+	}
+	if !strings.Contains(avoid[0].Reason, "2 positive and 1 negative calls") {
+		t.Errorf("Expected the reason to count the calls but got %q", avoid[0].Reason)
+	}
+
+	// A lower analyst threshold admits MSFT, counting brokerage A once
+	scoring := services.DefaultScoringConfig()
+	scoring.Consensus.MinAnalysts = 2
+	for _, recommendation := range strategy.Recommend(stocks, scoring) {
+		if recommendation.Stock.Ticker == "MSFT" && !strings.Contains(recommendation.Reason, "2 brokerages") {
+			t.Errorf("Expected MSFT to count 2 brokerages but got %q", recommendation.Reason)
+		}
+	}
+
+	// The service reads the latest calls within the window for the default strategy
+	t.Run("service window", func(t *testing.T) {
+		var since time.Time
+		mockRepo := &mocks.MockStockRepository{
+			GetLatestCallsSinceFn: func(s time.Time, filter models.StockFilter, limit int) ([]models.Stock, error) {
+				since = s
+				return []models.Stock{}, nil
+			},
+		}
+
+		result, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{})
+		if err != nil || result.Strategy != "consensus" {
+			t.Fatalf("Expected the consensus strategy but got %q, %v", result.Strategy, err)
+		}
+
+		if age := time.Since(since); age < 30*day || age > 30*day+time.Minute {
+			t.Errorf("Expected a 30 day window but got %v", age)
+		}
+	})
+}
+
+func TestRecommendationOptions(t *testing.T) {
+	now := time.Now()
+	var stocks []models.Stock
+	for i, ticker := range []string{"AAPL", "MSFT", "GOOG", "AMZN", "NVDA", "TSLA", "META"} {
+		stocks = append(stocks, models.Stock{
+			Ticker: ticker, Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy",
+			TargetFrom: 100, TargetTo: 100 + float64(i+1), Time: now.Add(-time.Duration(i) * time.Hour),
+		})
+	}
+	// A single upgrade without a target or rating change scores lowest
+	stocks = append(stocks, models.Stock{Ticker: "IBM", Action: "upgraded by", Time: now.Add(-8 * time.Hour)})
+
+	// The ticker, brokerage, sector and lookback options reach the repository
+	t.Run("repository filter", func(t *testing.T) {
+		var gotFilter models.StockFilter
+		var gotLimit int
+		mockRepo := &mocks.MockStockRepository{
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+				gotFilter, gotLimit = filter, limit
+				return stocks, nil
+			},
+		}
+
+		_, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{
+			Strategy:       "heuristic",
+			Lookback:       7 * 24 * time.Hour,
+			LookbackEvents: 50,
+			Tickers:        []string{"AAPL"},
+			ExcludeTickers: []string{"TSLA"},
+			Brokerages:     []string{"Example Brokerage"},
+			Sector:         "Technology",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if gotLimit != 50 {
+			t.Errorf("Expected 50 events but got %d", gotLimit)
+		}
+		if gotFilter.Tickers[0] != "AAPL" || gotFilter.ExcludeTickers[0] != "TSLA" ||
+			gotFilter.Brokerages[0] != "Example Brokerage" || gotFilter.Sector != "Technology" {
+			t.Errorf("Expected the filters to be passed but got %+v", gotFilter)
+		}
+		if gotFilter.From == nil || time.Since(*gotFilter.From) < 7*24*time.Hour-time.Minute {
+			t.Errorf("Expected events from the last 7 days but got %v", gotFilter.From)
+		}
+	})
+
+	// Without options the 200 latest events are ranked
+	t.Run("default lookback", func(t *testing.T) {
+		var gotLimit int
+		mockRepo := &mocks.MockStockRepository{
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+				gotLimit = limit
+				return stocks, nil
+			},
+		}
+
+		result, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{Strategy: "heuristic"})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if gotLimit != services.DefaultLookbackEvents {
+			t.Errorf("Expected %d events but got %d", services.DefaultLookbackEvents, gotLimit)
+		}
+		if len(result.Recommendations) != 5 || result.TotalCount != 8 || result.TotalPages != 2 {
+			t.Errorf("Expected the first 5 of 8 recommendations but got %d of %d", len(result.Recommendations), result.TotalCount)
+		}
+	})
+
+	// Pages cover the whole ranked list
+	t.Run("pagination", func(t *testing.T) {
+		mockRepo := &mocks.MockStockRepository{
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+				return stocks, nil
+			},
+		}
+		service := services.NewRecommendationService(mockRepo)
+
+		first, _ := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic", Limit: 3})
+		second, _ := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic", Limit: 3, Page: 3})
+		beyond, _ := service.GetRecommendations(services.RecommendationOptions{Strategy: "heuristic", Limit: 3, Page: 4})
+
+		if len(first.Recommendations) != 3 || first.TotalPages != 3 || first.Page != 1 {
+			t.Errorf("Expected the first page of 3 but got %+v", first)
+		}
+		if len(second.Recommendations) != 2 || second.Recommendations[1].Stock.Ticker != "IBM" {
+			t.Errorf("Expected the last page to end with IBM but got %+v", second.Recommendations)
+		}
+		if len(beyond.Recommendations) != 0 || beyond.TotalCount != 8 {
+			t.Errorf("Expected no recommendations beyond the last page but got %+v", beyond.Recommendations)
+		}
+	})
+
+	// Recommendations below the minimum score are dropped
+	t.Run("min score", func(t *testing.T) {
+		mockRepo := &mocks.MockStockRepository{
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+				return stocks, nil
+			},
+		}
+
+		minScore := 3.0
+		result, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{
+			Strategy: "heuristic", Limit: 20, MinScore: &minScore,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if result.TotalCount != 7 {
+			t.Errorf("Expected IBM to be dropped but got %d recommendations", result.TotalCount)
+		}
+		for _, recommendation := range result.Recommendations {
+			if recommendation.Score < minScore {
+				t.Errorf("Expected scores of at least %v but got %v for %s", minScore, recommendation.Score, recommendation.Stock.Ticker)
+			}
+		}
+	})
+
+	// A shorter lookback narrows the consensus window
+	t.Run("windowed lookback", func(t *testing.T) {
+		var since time.Time
+		var gotLimit int
+		mockRepo := &mocks.MockStockRepository{
+			GetLatestCallsSinceFn: func(s time.Time, filter models.StockFilter, limit int) ([]models.Stock, error) {
+				since, gotLimit = s, limit
+				return []models.Stock{}, nil
+			},
+		}
+
+		_, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{
+			Lookback: 24 * time.Hour, LookbackEvents: 100,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if age := time.Since(since); age < 24*time.Hour || age > 24*time.Hour+time.Minute {
+			t.Errorf("Expected a 1 day window but got %v", age)
+		}
+		if gotLimit != 100 {
+			t.Errorf("Expected 100 calls but got %d", gotLimit)
+		}
+	})
+
+	// Windowed strategies fetch a bounded number of calls by default
+	t.Run("windowed default limit", func(t *testing.T) {
+		var gotLimit int
+		mockRepo := &mocks.MockStockRepository{
+			GetLatestCallsSinceFn: func(s time.Time, filter models.StockFilter, limit int) ([]models.Stock, error) {
+				gotLimit = limit
+				return []models.Stock{}, nil
+			},
+		}
+
+		if _, err := services.NewRecommendationService(mockRepo).GetRecommendations(services.RecommendationOptions{}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if gotLimit != services.DefaultWindowLookbackCalls {
+			t.Errorf("Expected %d calls but got %d", services.DefaultWindowLookbackCalls, gotLimit)
+		}
+	})
+}
+
+func TestScoreFactors(t *testing.T) {
+	// Every strategy breaks its scores down into factors adding up to the score
+	stocks := []models.Stock{
+		{Ticker: "AAPL", Brokerage: "A", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 150, TargetTo: 200, Time: time.Now()},
+		{Ticker: "AAPL", Brokerage: "B", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 150, TargetTo: 160, Time: time.Now().Add(-time.Hour)},
+		{Ticker: "AAPL", Brokerage: "C", Action: "target raised by", RatingFrom: "Buy", RatingTo: "Buy", TargetFrom: 150, TargetTo: 155, Time: time.Now().Add(-2 * time.Hour)},
+	}
+
+	for _, info := range services.DefaultStrategyRegistry().List() {
+		t.Run(info.Name, func(t *testing.T) {
+			strategy, _ := services.DefaultStrategyRegistry().Get(info.Name)
+			recommendations := strategy.Recommend(stocks, services.DefaultScoringConfig())
+			if len(recommendations) == 0 {
+				t.Fatalf("Expected a recommendation for AAPL")
+			}
+
+			for _, recommendation := range recommendations {
+				var sum float64
+				for _, factor := range recommendation.Factors {
+					if factor.ID == "" || factor.Description == "" {
+						t.Errorf("Expected factors with an ID and description but got %+v", factor)
+					}
+					sum += factor.Contribution
+				}
+				if len(recommendation.Factors) == 0 || math.Abs(sum-recommendation.Score) > 1e-9 {
+					t.Errorf("Expected the contributions to add up to %v but got %v", recommendation.Score, sum)
+				}
+			}
+		})
+	}
+
+	// The heuristic factors keep the reason and record their inputs
+	t.Run("heuristic breakdown", func(t *testing.T) {
+		recommendations := services.HeuristicStrategy{}.Recommend(stocks[:1], services.DefaultScoringConfig())
+		if len(recommendations) != 1 {
+			t.Fatalf("Expected 1 recommendation but got %d", len(recommendations))
+		}
+		recommendation := recommendations[0]
+
+		want := []struct {
+			id           string
+			contribution float64
+		}{
+			{services.FactorActionUpgraded, 2},
+			{services.FactorTargetRaisedSignificantly, 2},
+			{services.FactorRatingImproved, 1},
+			{services.FactorRatingPositive, 1},
+		}
+		if len(recommendation.Factors) != len(want) {
+			t.Fatalf("Expected %d factors but got %+v", len(want), recommendation.Factors)
+		}
+		for i, factor := range recommendation.Factors {
+			if factor.ID != want[i].id || factor.Contribution != want[i].contribution {
+				t.Errorf("Expected %s contributing %v but got %s contributing %v",
+					want[i].id, want[i].contribution, factor.ID, factor.Contribution)
+			}
+		}
+
+		if recommendation.Reason != "Stock was recently upgraded, Target price increased significantly, Rating improved, Positive rating" {
+			t.Errorf("Expected the reason to be kept but got %q", recommendation.Reason)
+		}
+
+		if inputs := recommendation.Factors[1].Inputs; inputs["target_from"] != 150.0 || inputs["target_to"] != 200.0 {
+			t.Errorf("Expected the targets as inputs but got %v", inputs)
+		}
+	})
+
+	// The consensus has one factor per brokerage call
+	t.Run("consensus breakdown", func(t *testing.T) {
+		recommendations := services.ConsensusStrategy{}.Recommend(stocks, services.DefaultScoringConfig())
+		if len(recommendations) != 1 || len(recommendations[0].Factors) != 3 {
+			t.Fatalf("Expected 1 recommendation with 3 calls but got %+v", recommendations)
+		}
+
+		for i, brokerage := range []string{"A", "B", "C"} {
+			factor := recommendations[0].Factors[i]
+			if factor.ID != services.FactorBrokerageCall || factor.Inputs["brokerage"] != brokerage {
+				t.Errorf("Expected the call by %s but got %+v", brokerage, factor)
+			}
+		}
+	})
+}
+
+func TestRatingLadderScoring(t *testing.T) {
+	// Strong buys score above plain buys
+	t.Run("strong rating", func(t *testing.T) {
+		stock := models.Stock{Ticker: "AAPL", Brokerage: "A", Action: "reiterated by", RatingFrom: "Strong-Buy", RatingTo: "Strong-Buy"}
+		recommendations := services.HeuristicStrategy{}.Recommend([]models.Stock{stock}, services.DefaultScoringConfig())
+		if len(recommendations) != 1 {
+			t.Fatalf("Expected 1 recommendation but got %d", len(recommendations))
+		}
+
+		factors := recommendations[0].Factors
+		if len(factors) != 2 || factors[1].ID != services.FactorRatingStrong || factors[1].Inputs["rating_level"] != "strong_buy" {
+			t.Errorf("Expected a strong rating factor but got %+v", factors)
+		}
+	})
+
+	// Negative ratings subtract from the score, strong sells the most
+	t.Run("negative rating", func(t *testing.T) {
+		stocks := []models.Stock{
+			{Ticker: "AAPL", Brokerage: "A", Action: "reiterated by", RatingFrom: "Strong Sell", RatingTo: "Strong Sell"},
+			{Ticker: "MSFT", Brokerage: "A", Action: "reiterated by", RatingFrom: "Underweight", RatingTo: "Underweight"},
+		}
+		recommendations := services.HeuristicStrategy{}.Avoid(stocks, services.DefaultScoringConfig())
+		if len(recommendations) != 2 {
+			t.Fatalf("Expected 2 tickers to avoid but got %d", len(recommendations))
+		}
+
+		want := []struct {
+			id    string
+			score float64
+		}{
+			{services.FactorRatingStrongNegative, -2.5},
+			{services.FactorRatingNegative, -1.5},
+		}
+		for i, recommendation := range recommendations {
+			factors := recommendation.Factors
+			if recommendation.Score != want[i].score || len(factors) != 2 ||
+				factors[0].ID != services.FactorRatingMaintainedNegative || factors[1].ID != want[i].id {
+				t.Errorf("Expected %s scoring %v but got %v with %+v", want[i].id, want[i].score, recommendation.Score, factors)
+			}
+		}
+	})
+
+	// Brokerage overrides move a house rating on the ladder
+	t.Run("brokerage override", func(t *testing.T) {
+		ladder := models.DefaultRatingLadder()
+		ladder.BrokerageOverrides["Oppenheimer"] = map[string]models.RatingLevel{"Outperform": models.RatingStrongBuy}
+		models.SetRatingLadder(ladder)
+		defer models.SetRatingLadder(models.DefaultRatingLadder())
+
+		stocks := []models.Stock{
+			{Ticker: "AAPL", Brokerage: "Oppenheimer", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Outperform"},
+			{Ticker: "MSFT", Brokerage: "Mizuho", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Outperform"},
+		}
+		recommendations := services.HeuristicStrategy{}.Recommend(stocks, services.DefaultScoringConfig())
+		if len(recommendations) != 2 {
+			t.Fatalf("Expected 2 recommendations but got %d", len(recommendations))
+		}
+
+		// action 2 + rating improved 2 + strong 2 against action 2 + improved 1 + positive 1
+		if recommendations[0].Score != 6 || recommendations[1].Score != 4 {
+			t.Errorf("Expected scores 6 and 4 but got %v and %v", recommendations[0].Score, recommendations[1].Score)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package services
+
+import "strings"
+
+// Identifiers of the factors making up a score. Clients key translations and
+// charts on them, so they must not change once published.
+const (
+	FactorActionUpgraded            = "action_upgraded"
+	FactorActionDowngraded          = "action_downgraded"
+	FactorTargetRaisedSignificantly = "target_raised_significantly"
+	FactorTargetRaised              = "target_raised"
+	FactorTargetCutSignificantly    = "target_cut_significantly"
+	FactorTargetCut                 = "target_cut"
+	FactorRatingImproved            = "rating_improved"
+	FactorRatingDowngraded          = "rating_downgraded"
+	FactorRatingMaintainedPositive  = "rating_maintained_positive"
+	FactorRatingMaintainedNegative  = "rating_maintained_negative"
+	FactorRatingStrong              = "rating_strong"
+	FactorRatingPositive            = "rating_positive"
+	FactorRatingNegative            = "rating_negative"
+	FactorRatingStrongNegative      = "rating_strong_negative"
+	FactorBrokerageCall             = "brokerage_call"
+	FactorUpgrades                  = "upgrades"
+	FactorDowngrades                = "downgrades"
+	FactorTargetUpside              = "target_upside"
+	FactorTargetDownside            = "target_downside"
+	FactorConsensusChange           = "consensus_change"
+)
+
+// ScoreFactor is one term of a recommendation's score. The contributions of a
+// recommendation's factors add up to its score.
+type ScoreFactor struct {
+	ID           string                 `json:"id"`
+	Description  string                 `json:"description"`
+	Inputs       map[string]interface{} `json:"inputs"`
+	Contribution float64                `json:"contribution"`
+}
+
+// scoreOf adds up the contributions of the factors
+func scoreOf(factors []ScoreFactor) float64 {
+	var score float64
+	for _, factor := range factors {
+		score += factor.Contribution
+	}
+	return score
+}
+
+// reasonOf joins the factor descriptions into the human readable reason
+func reasonOf(factors []ScoreFactor) string {
+	descriptions := make([]string, len(factors))
+	for i, factor := range factors {
+		descriptions[i] = factor.Description
+	}
+	return strings.Join(descriptions, ", ")
+}
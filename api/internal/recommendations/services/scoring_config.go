@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"stonks-api/internal/stocks/models"
+)
+
+// ErrInvalidScoringConfig is returned for scoring configurations that cannot
+// be parsed or fail validation
+var ErrInvalidScoringConfig = errors.New("invalid scoring config")
+
+// ScoringConfig holds the weights and thresholds used to score rating events.
+// Version identifies the values so results can be reproduced.
+type ScoringConfig struct {
+	Version        string                `json:"version"`
+	Actions        ActionWeights         `json:"actions"`
+	Target         TargetWeights         `json:"target"`
+	RatingChange   RatingChangeWeights   `json:"rating_change"`
+	RatingStrength RatingStrengthWeights `json:"rating_strength"`
+	RatingScores   RatingScores          `json:"rating_scores"`
+	Consensus      ConsensusWeights      `json:"consensus"`
+}
+
+// ActionWeights are added for the action of the event
+type ActionWeights struct {
+	Upgraded   float64 `json:"upgraded"`
+	Downgraded float64 `json:"downgraded"`
+}
+
+// TargetWeights score the price target change. Changes beyond the significant
+// percentage weigh SignificantWeight, smaller ones ChangeWeight, added for
+// raises and subtracted for cuts.
+type TargetWeights struct {
+	SignificantChangePercent float64 `json:"significant_change_percent"`
+	SignificantWeight        float64 `json:"significant_weight"`
+	ChangeWeight             float64 `json:"change_weight"`
+}
+
+// RatingChangeWeights score the move between the previous and new rating.
+// MaintainedPositive is added for unchanged positive ratings, MaintainedNegative
+// subtracted for unchanged negative ones.
+type RatingChangeWeights struct {
+	Multiplier         float64 `json:"multiplier"`
+	MaintainedPositive float64 `json:"maintained_positive"`
+	MaintainedNegative float64 `json:"maintained_negative"`
+}
+
+// RatingStrengthWeights scores the new rating on its own. Ratings scoring at
+// least a positive threshold add its weight, ratings scoring at most a
+// negative threshold subtract its weight.
+type RatingStrengthWeights struct {
+	StrongThreshold         float64 `json:"strong_threshold"`
+	StrongWeight            float64 `json:"strong_weight"`
+	PositiveThreshold       float64 `json:"positive_threshold"`
+	PositiveWeight          float64 `json:"positive_weight"`
+	NegativeThreshold       float64 `json:"negative_threshold"`
+	NegativeWeight          float64 `json:"negative_weight"`
+	StrongNegativeThreshold float64 `json:"strong_negative_threshold"`
+	StrongNegativeWeight    float64 `json:"strong_negative_weight"`
+}
+
+// RatingScores map the levels of the rating ladder to numeric scores.
+// Positive, Neutral and Negative score buy, hold and sell ratings.
+type RatingScores struct {
+	StrongBuy  float64 `json:"strong_buy"`
+	Positive   float64 `json:"positive"`
+	Neutral    float64 `json:"neutral"`
+	Negative   float64 `json:"negative"`
+	StrongSell float64 `json:"strong_sell"`
+}
+
+// ConsensusWeights configure the consensus strategy. Calls older than the
+// window are ignored, a call's weight halves every HalfLifeDays and tickers
+// need calls from at least MinAnalysts brokerages.
+type ConsensusWeights struct {
+	WindowDays   float64 `json:"window_days"`
+	HalfLifeDays float64 `json:"half_life_days"`
+	MinAnalysts  int     `json:"min_analysts"`
+}
+
+// Score returns the score of a rating level, unknown ratings score as Neutral
+func (s RatingScores) Score(level models.RatingLevel) float64 {
+	switch level {
+	case models.RatingStrongBuy:
+		return s.StrongBuy
+	case models.RatingBuy:
+		return s.Positive
+	case models.RatingSell:
+		return s.Negative
+	case models.RatingStrongSell:
+		return s.StrongSell
+	default:
+		return s.Neutral
+	}
+}
+
+// defaultRatingScores are the rating scores of the default configuration
+var defaultRatingScores = RatingScores{StrongBuy: 7, Positive: 5, Neutral: 3, Negative: 1, StrongSell: -1}
+
+// DefaultScoringConfig returns the weights used when no configuration is loaded
+func DefaultScoringConfig() ScoringConfig {
+	config := ScoringConfig{
+		Actions: ActionWeights{Upgraded: 2, Downgraded: -2},
+		Target: TargetWeights{
+			SignificantChangePercent: 10,
+			SignificantWeight:        2,
+			ChangeWeight:             1,
+		},
+		RatingChange: RatingChangeWeights{Multiplier: 0.5, MaintainedPositive: 0.5, MaintainedNegative: 0.5},
+		RatingStrength: RatingStrengthWeights{
+			StrongThreshold:         7,
+			StrongWeight:            2,
+			PositiveThreshold:       5,
+			PositiveWeight:          1,
+			NegativeThreshold:       1,
+			NegativeWeight:          1,
+			StrongNegativeThreshold: -1,
+			StrongNegativeWeight:    2,
+		},
+		RatingScores: defaultRatingScores,
+		Consensus:    ConsensusWeights{WindowDays: 30, HalfLifeDays: 7, MinAnalysts: 3},
+	}
+	config.Version = config.computeVersion()
+
+	return config
+}
+
+// Validate checks that the weights are consistent
+func (c ScoringConfig) Validate() error {
+	var errs []error
+
+	if c.Target.SignificantChangePercent <= 0 {
+		errs = append(errs, errors.New("target.significant_change_percent must be positive"))
+	}
+	if c.Target.SignificantWeight < c.Target.ChangeWeight {
+		errs = append(errs, errors.New("target.significant_weight must not be lower than target.change_weight"))
+	}
+	if c.RatingChange.Multiplier < 0 {
+		errs = append(errs, errors.New("rating_change.multiplier must not be negative"))
+	}
+	if c.RatingStrength.StrongThreshold < c.RatingStrength.PositiveThreshold {
+		errs = append(errs, errors.New("rating_strength.strong_threshold must not be lower than rating_strength.positive_threshold"))
+	}
+	if c.RatingStrength.NegativeThreshold >= c.RatingStrength.PositiveThreshold {
+		errs = append(errs, errors.New("rating_strength.negative_threshold must be lower than rating_strength.positive_threshold"))
+	}
+	if c.RatingStrength.StrongNegativeThreshold > c.RatingStrength.NegativeThreshold {
+		errs = append(errs, errors.New("rating_strength.strong_negative_threshold must not be higher than rating_strength.negative_threshold"))
+	}
+	scores := c.RatingScores
+	if !(scores.StrongSell < scores.Negative && scores.Negative < scores.Neutral &&
+		scores.Neutral < scores.Positive && scores.Positive < scores.StrongBuy) {
+		errs = append(errs, errors.New("rating_scores must increase from strong_sell to negative, neutral, positive and strong_buy"))
+	}
+	if c.Consensus.WindowDays <= 0 {
+		errs = append(errs, errors.New("consensus.window_days must be positive"))
+	}
+	if c.Consensus.HalfLifeDays <= 0 {
+		errs = append(errs, errors.New("consensus.half_life_days must be positive"))
+	}
+	if c.Consensus.MinAnalysts < 1 {
+		errs = append(errs, errors.New("consensus.min_analysts must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// computeVersion derives a short hash of the weights, ignoring Version itself
+func (c ScoringConfig) computeVersion() string {
+	c.Version = ""
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// ParseScoringConfig reads a scoring configuration as JSON. Omitted fields keep
+// their default value, unknown fields are rejected. The result is validated
+// and versioned.
+func ParseScoringConfig(r io.Reader) (ScoringConfig, error) {
+	config := DefaultScoringConfig()
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return ScoringConfig{}, fmt.Errorf("%w: %w", ErrInvalidScoringConfig, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return ScoringConfig{}, fmt.Errorf("%w: %w", ErrInvalidScoringConfig, err)
+	}
+
+	config.Version = config.computeVersion()
+
+	return config, nil
+}
+
+// LoadScoringConfig reads and validates the scoring configuration file at path
+func LoadScoringConfig(path string) (ScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ScoringConfig{}, fmt.Errorf("error reading scoring config %s: %w", path, err)
+	}
+
+	return ParseScoringConfig(bytes.NewReader(data))
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"stonks-api/cmd/apierrors"
+	recommendationModels "stonks-api/internal/recommendations/models"
+	"time"
+)
+
+// DefaultSnapshotSize is the number of recommendations kept per snapshot
+const DefaultSnapshotSize = 20
+
+// SnapshotRepository stores the daily recommendation snapshots
+type SnapshotRepository interface {
+	SaveSnapshot(snapshot recommendationModels.Snapshot) error
+	GetSnapshot(date, strategy string) (*recommendationModels.Snapshot, error)
+	ListSnapshots(strategy string, limit int) ([]recommendationModels.SnapshotSummary, error)
+}
+
+// SetSnapshotRepository sets where snapshots are stored
+func (s *RecommendationService) SetSnapshotRepository(snapshotRepository SnapshotRepository) {
+	s.snapshotRepository = snapshotRepository
+}
+
+// SetSnapshotSize sets the number of recommendations kept per snapshot
+func (s *RecommendationService) SetSnapshotSize(size int) {
+	s.snapshotSize = size
+}
+
+// TakeSnapshot stores the top recommendations of the named strategy, the
+// default one when name is empty, as today's snapshot
+func (s *RecommendationService) TakeSnapshot(strategyName string) (recommendationModels.Snapshot, error) {
+	size := s.snapshotSize
+	if size <= 0 {
+		size = DefaultSnapshotSize
+	}
+
+	result, err := s.GetRecommendations(RecommendationOptions{Strategy: strategyName, Limit: size})
+	if err != nil {
+		return recommendationModels.Snapshot{}, err
+	}
+
+	now := time.Now().UTC()
+	snapshot := recommendationModels.Snapshot{
+		Date:           now.Format(recommendationModels.SnapshotDateLayout),
+		Strategy:       result.Strategy,
+		ScoringVersion: result.Scoring.Version,
+		CreatedAt:      now,
+		Entries:        make([]recommendationModels.SnapshotEntry, len(result.Recommendations)),
+	}
+	for i, recommendation := range result.Recommendations {
+		snapshot.Entries[i] = recommendationModels.SnapshotEntry{
+			Rank:    i + 1,
+			Ticker:  recommendation.Stock.Ticker,
+			Company: recommendation.Stock.Company,
+			Score:   recommendation.Score,
+			Reason:  recommendation.Reason,
+		}
+	}
+
+	if err := s.snapshotRepository.SaveSnapshot(snapshot); err != nil {
+		return recommendationModels.Snapshot{}, err
+	}
+
+	// Cached snapshot responses may say today's snapshot does not exist
+	if s.readCache != nil {
+		s.readCache.Invalidate()
+	}
+
+	return snapshot, nil
+}
+
+// GetSnapshot returns the snapshot of the named strategy on date
+func (s *RecommendationService) GetSnapshot(date time.Time, strategyName string) (recommendationModels.Snapshot, error) {
+	strategy, err := s.strategies.Get(strategyName)
+	if err != nil {
+		return recommendationModels.Snapshot{}, err
+	}
+
+	day := date.Format(recommendationModels.SnapshotDateLayout)
+	snapshot, err := s.snapshotRepository.GetSnapshot(day, strategy.Name())
+	if err != nil {
+		return recommendationModels.Snapshot{}, err
+	}
+
+	if snapshot == nil {
+		return recommendationModels.Snapshot{}, apierrors.NotFound(
+			fmt.Sprintf("No %s recommendation snapshot on %s", strategy.Name(), day))
+	}
+
+	return *snapshot, nil
+}
+
+// ListSnapshots describes the latest snapshots of the named strategy, newest first
+func (s *RecommendationService) ListSnapshots(strategyName string, limit int) ([]recommendationModels.SnapshotSummary, error) {
+	strategy, err := s.strategies.Get(strategyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.snapshotRepository.ListSnapshots(strategy.Name(), limit)
+}
+
+// DiffSnapshots compares the snapshots of the named strategy on two dates
+func (s *RecommendationService) DiffSnapshots(from, to time.Time, strategyName string) (recommendationModels.SnapshotDiff, error) {
+	before, err := s.GetSnapshot(from, strategyName)
+	if err != nil {
+		return recommendationModels.SnapshotDiff{}, err
+	}
+
+	after, err := s.GetSnapshot(to, strategyName)
+	if err != nil {
+		return recommendationModels.SnapshotDiff{}, err
+	}
+
+	return diffSnapshots(before, after), nil
+}
+
+// RunSnapshotSchedule takes a snapshot of the default strategy every interval
+// until ctx is done
+func (s *RecommendationService) RunSnapshotSchedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.TakeSnapshot(""); err != nil {
+				fmt.Printf("Scheduled recommendation snapshot failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// diffSnapshots lists the entries, exits and rank changes from before to after
+func diffSnapshots(before, after recommendationModels.Snapshot) recommendationModels.SnapshotDiff {
+	diff := recommendationModels.SnapshotDiff{
+		Strategy:    after.Strategy,
+		From:        before.Date,
+		To:          after.Date,
+		Entered:     []recommendationModels.SnapshotEntry{},
+		Exited:      []recommendationModels.SnapshotEntry{},
+		RankChanges: []recommendationModels.RankChange{},
+	}
+
+	previous := make(map[string]recommendationModels.SnapshotEntry, len(before.Entries))
+	for _, entry := range before.Entries {
+		previous[entry.Ticker] = entry
+	}
+
+	current := make(map[string]bool, len(after.Entries))
+	for _, entry := range after.Entries {
+		current[entry.Ticker] = true
+
+		prev, ok := previous[entry.Ticker]
+		if !ok {
+			diff.Entered = append(diff.Entered, entry)
+			continue
+		}
+
+		diff.RankChanges = append(diff.RankChanges, recommendationModels.RankChange{
+			Ticker:      entry.Ticker,
+			Company:     entry.Company,
+			FromRank:    prev.Rank,
+			ToRank:      entry.Rank,
+			Change:      prev.Rank - entry.Rank,
+			ScoreChange: entry.Score - prev.Score,
+		})
+	}
+
+	for _, entry := range before.Entries {
+		if !current[entry.Ticker] {
+			diff.Exited = append(diff.Exited, entry)
+		}
+	}
+
+	return diff
+}
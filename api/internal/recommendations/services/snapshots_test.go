@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/recommendations/mocks"
+	recommendationModels "stonks-api/internal/recommendations/models"
+	"stonks-api/internal/recommendations/services"
+	"stonks-api/internal/stocks/models"
+	"testing"
+	"time"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	stocks := []models.Stock{
+		{Ticker: "AAPL", Company: "Apple Inc.", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 100, TargetTo: 150, Time: time.Now()},
+		{Ticker: "MSFT", Company: "Microsoft Corp", Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 100, TargetTo: 105, Time: time.Now()},
+		{Ticker: "GOOG", Company: "Alphabet Inc.", Action: "target raised by", TargetFrom: 100, TargetTo: 105, Time: time.Now()},
+	}
+
+	// The top recommendations are stored as today's snapshot, ranked
+	t.Run("successful snapshot", func(t *testing.T) {
+		var saved recommendationModels.Snapshot
+		service := services.NewRecommendationService(&mocks.MockStockRepository{
+			GetRecentStocksFn: func(filter models.StockFilter, limit int) ([]models.Stock, error) {
+				return stocks, nil
+			},
+		})
+		service.SetSnapshotRepository(&mocks.MockSnapshotRepository{
+			SaveSnapshotFn: func(snapshot recommendationModels.Snapshot) error {
+				saved = snapshot
+				return nil
+			},
+		})
+		service.SetSnapshotSize(2)
+
+		snapshot, err := service.TakeSnapshot("heuristic")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if saved.Date != time.Now().UTC().Format(recommendationModels.SnapshotDateLayout) || saved.Strategy != "heuristic" {
+			t.Errorf("Expected today's heuristic snapshot but got %s %s", saved.Date, saved.Strategy)
+		}
+
+		if len(saved.Entries) != 2 || saved.Entries[0].Ticker != "AAPL" || saved.Entries[0].Rank != 1 ||
+			saved.Entries[1].Ticker != "MSFT" || saved.Entries[1].Rank != 2 {
+			t.Errorf("Expected AAPL and MSFT ranked but got %+v", saved.Entries)
+		}
+
+		if saved.ScoringVersion != services.DefaultScoringConfig().Version || snapshot.Date != saved.Date {
+			t.Errorf("Expected the stored snapshot with the scoring version but got %+v", snapshot)
+		}
+	})
+
+	// Storage errors are returned
+	t.Run("save error", func(t *testing.T) {
+		service := services.NewRecommendationService(&mocks.MockStockRepository{})
+		service.SetSnapshotRepository(&mocks.MockSnapshotRepository{
+			SaveSnapshotFn: func(snapshot recommendationModels.Snapshot) error {
+				return errors.New("database error")
+			},
+		})
+
+		if _, err := service.TakeSnapshot(""); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestSnapshotHistory(t *testing.T) {
+	snapshots := map[string]*recommendationModels.Snapshot{
+		"2025-03-01": {Date: "2025-03-01", Strategy: "consensus", Entries: []recommendationModels.SnapshotEntry{
+			{Rank: 1, Ticker: "AAPL", Score: 4},
+			{Rank: 2, Ticker: "MSFT", Score: 3},
+			{Rank: 3, Ticker: "GOOG", Score: 2},
+		}},
+		"2025-03-08": {Date: "2025-03-08", Strategy: "consensus", Entries: []recommendationModels.SnapshotEntry{
+			{Rank: 1, Ticker: "MSFT", Score: 3.5},
+			{Rank: 2, Ticker: "NVDA", Score: 3},
+			{Rank: 3, Ticker: "AAPL", Score: 2.5},
+		}},
+	}
+
+	var gotStrategy string
+	service := services.NewRecommendationService(&mocks.MockStockRepository{})
+	service.SetSnapshotRepository(&mocks.MockSnapshotRepository{
+		GetSnapshotFn: func(date, strategy string) (*recommendationModels.Snapshot, error) {
+			gotStrategy = strategy
+			return snapshots[date], nil
+		},
+	})
+
+	// A stored snapshot is returned for the default strategy
+	t.Run("get snapshot", func(t *testing.T) {
+		snapshot, err := service.GetSnapshot(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(snapshot.Entries) != 3 || gotStrategy != services.DefaultStrategy {
+			t.Errorf("Expected the default strategy's snapshot but got %+v for %q", snapshot, gotStrategy)
+		}
+	})
+
+	// Days without a snapshot are not found
+	t.Run("missing snapshot", func(t *testing.T) {
+		_, err := service.GetSnapshot(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), "")
+		if apiErr, ok := apierrors.As(err); !ok || apiErr.Status() != http.StatusNotFound {
+			t.Errorf("Expected a not found error but got %v", err)
+		}
+	})
+
+	// Unknown strategies are rejected
+	t.Run("unknown strategy", func(t *testing.T) {
+		_, err := service.GetSnapshot(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "astrology")
+		if !errors.Is(err, services.ErrUnknownStrategy) {
+			t.Errorf("Expected an unknown strategy error but got %v", err)
+		}
+	})
+
+	// Entries, exits and rank changes between two days
+	t.Run("diff", func(t *testing.T) {
+		diff, err := service.DiffSnapshots(
+			time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+			"",
+		)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if diff.From != "2025-03-01" || diff.To != "2025-03-08" {
+			t.Errorf("Expected the dates of both snapshots but got %s and %s", diff.From, diff.To)
+		}
+
+		if len(diff.Entered) != 1 || diff.Entered[0].Ticker != "NVDA" {
+			t.Errorf("Expected NVDA to enter but got %+v", diff.Entered)
+		}
+
+		if len(diff.Exited) != 1 || diff.Exited[0].Ticker != "GOOG" {
+			t.Errorf("Expected GOOG to exit but got %+v", diff.Exited)
+		}
+
+		want := []recommendationModels.RankChange{
+			{Ticker: "MSFT", FromRank: 2, ToRank: 1, Change: 1, ScoreChange: 0.5},
+			{Ticker: "AAPL", FromRank: 1, ToRank: 3, Change: -2, ScoreChange: -1.5},
+		}
+		if len(diff.RankChanges) != len(want) {
+			t.Fatalf("Expected %d rank changes but got %+v", len(want), diff.RankChanges)
+		}
+		for i, change := range diff.RankChanges {
+			if change != want[i] {
+				t.Errorf("Expected %+v but got %+v", want[i], change)
+			}
+		}
+	})
+
+	// A missing side fails the diff
+	t.Run("diff missing snapshot", func(t *testing.T) {
+		_, err := service.DiffSnapshots(
+			time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+			"",
+		)
+		if apiErr, ok := apierrors.As(err); !ok || apiErr.Status() != http.StatusNotFound {
+			t.Errorf("Expected a not found error but got %v", err)
+		}
+	})
+}
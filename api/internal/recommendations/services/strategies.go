@@ -0,0 +1,376 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"stonks-api/internal/stocks/models"
+	"time"
+)
+
+// ConsensusStrategy scores tickers on the time-decayed average score of the
+// latest call of every brokerage within the consensus window, so one
+// brokerage's action does not decide a ticker's rank
+type ConsensusStrategy struct {
+	// Now returns the time calls are aged against, time.Now when nil
+	Now func() time.Time
+}
+
+func (ConsensusStrategy) Name() string { return "consensus" }
+
+func (ConsensusStrategy) Description() string {
+	return "Averages the latest call of every brokerage within the consensus window, weighting older calls less, for tickers covered by enough brokerages"
+}
+
+func (ConsensusStrategy) Window(scoring ScoringConfig) time.Duration {
+	return days(scoring.Consensus.WindowDays)
+}
+
+func (s ConsensusStrategy) Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionPositive)
+}
+
+func (s ConsensusStrategy) Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionNegative)
+}
+
+// rank scores the tickers, keeping those belonging to the direction
+func (s ConsensusStrategy) rank(stocks []models.Stock, scoring ScoringConfig, direction Direction) []StockRecommendation {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	window := s.Window(scoring)
+	halfLife := days(scoring.Consensus.HalfLifeDays)
+
+	tickers, events := groupByTicker(stocks)
+
+	var recommendations []StockRecommendation
+	for _, ticker := range tickers {
+		var latest models.Stock
+		var calls []ScoreFactor
+		var totalWeight float64
+		positive, negative := 0, 0
+		brokerages := make(map[string]bool)
+
+		for _, stock := range events[ticker] {
+			// Events are newest first, only the latest call of a brokerage counts
+			age := now.Sub(stock.Time)
+			if brokerages[stock.Brokerage] || age > window {
+				continue
+			}
+			if age < 0 {
+				age = 0
+			}
+
+			if len(brokerages) == 0 {
+				latest = stock
+			}
+			brokerages[stock.Brokerage] = true
+
+			score := scoreOf(calculateScore(stock, scoring, direction))
+			weight := math.Pow(0.5, float64(age)/float64(halfLife))
+			totalWeight += weight
+
+			// Contributions are weighted once the total weight is known
+			calls = append(calls, ScoreFactor{
+				ID:          FactorBrokerageCall,
+				Description: "Call by " + stock.Brokerage,
+				Inputs: map[string]interface{}{
+					"brokerage": stock.Brokerage,
+					"action":    stock.Action,
+					"rating_to": stock.RatingTo,
+					"score":     score,
+					"age_days":  float64(age) / float64(24*time.Hour),
+					"weight":    weight,
+				},
+				Contribution: weight * score,
+			})
+
+			if score > 0 {
+				positive++
+			} else if score < 0 {
+				negative++
+			}
+		}
+
+		if len(brokerages) < scoring.Consensus.MinAnalysts || totalWeight == 0 {
+			continue
+		}
+
+		for i := range calls {
+			calls[i].Contribution /= totalWeight
+		}
+
+		score := scoreOf(calls)
+		if direction.keeps(score) {
+			recommendations = append(recommendations, StockRecommendation{
+				Stock: latest,
+				Score: score,
+				Reason: fmt.Sprintf("Consensus of %d brokerages, %d positive and %d negative calls",
+					len(brokerages), positive, negative),
+				Factors: calls,
+			})
+		}
+	}
+
+	return recommendations
+}
+
+// HeuristicStrategy scores each event on its action, target change and
+// rating, using the latest event of a ticker that scores above zero, or below
+// zero for the tickers to avoid
+type HeuristicStrategy struct{}
+
+func (HeuristicStrategy) Name() string { return "heuristic" }
+
+func (HeuristicStrategy) Description() string {
+	return "Scores the latest event of each ticker on its action, price target change and rating, weighted by the scoring configuration"
+}
+
+func (s HeuristicStrategy) Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionPositive)
+}
+
+func (s HeuristicStrategy) Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionNegative)
+}
+
+// rank scores the events, keeping the latest one of each ticker belonging to
+// the direction
+func (HeuristicStrategy) rank(stocks []models.Stock, scoring ScoringConfig, direction Direction) []StockRecommendation {
+	recommendations := make([]StockRecommendation, 0, len(stocks)/2)
+	// Map to ensure to only include one recommendation per ticker
+	tickerMap := make(map[string]bool)
+
+	for _, stock := range stocks {
+		if _, exists := tickerMap[stock.Ticker]; exists {
+			continue
+		}
+
+		factors := calculateScore(stock, scoring, direction)
+
+		if score := scoreOf(factors); direction.keeps(score) {
+			recommendations = append(recommendations, StockRecommendation{
+				Stock:   stock,
+				Score:   score,
+				Reason:  reasonOf(factors),
+				Factors: factors,
+			})
+
+			// Mark ticker as processed
+			tickerMap[stock.Ticker] = true
+		}
+	}
+
+	return recommendations
+}
+
+// MomentumStrategy scores tickers on how many upgrades they received
+// recently, or downgrades for the tickers to avoid
+type MomentumStrategy struct{}
+
+func (MomentumStrategy) Name() string { return "momentum" }
+
+func (MomentumStrategy) Description() string {
+	return "Ranks tickers by the number of upgrades among the recent events, or downgrades for the tickers to avoid"
+}
+
+func (s MomentumStrategy) Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, models.ActionUpgraded, 1, FactorUpgrades, "upgrade")
+}
+
+func (s MomentumStrategy) Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, models.ActionDowngraded, -1, FactorDowngrades, "downgrade")
+}
+
+// rank scores the tickers on the number of events with the action, each
+// contributing sign, and names them noun in the reason
+func (MomentumStrategy) rank(stocks []models.Stock, action string, sign float64, factorID, noun string) []StockRecommendation {
+	tickers, events := groupByTicker(stocks)
+
+	var recommendations []StockRecommendation
+	for _, ticker := range tickers {
+		var latest models.Stock
+		count := 0
+		for _, stock := range events[ticker] {
+			if stock.Action != action {
+				continue
+			}
+			if count == 0 {
+				latest = stock
+			}
+			count++
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		reason := "1 recent " + noun
+		if count > 1 {
+			reason = fmt.Sprintf("%d recent %ss", count, noun)
+		}
+
+		recommendations = append(recommendations, StockRecommendation{
+			Stock:  latest,
+			Score:  sign * float64(count),
+			Reason: reason,
+			Factors: []ScoreFactor{{
+				ID:           factorID,
+				Description:  reason,
+				Inputs:       map[string]interface{}{noun + "s": count},
+				Contribution: sign * float64(count),
+			}},
+		})
+	}
+
+	return recommendations
+}
+
+// TargetUpsideStrategy scores tickers on the price target change of their
+// latest event carrying both targets, raises for the tickers to buy and cuts
+// for the tickers to avoid
+type TargetUpsideStrategy struct{}
+
+func (TargetUpsideStrategy) Name() string { return "target_upside" }
+
+func (TargetUpsideStrategy) Description() string {
+	return "Ranks tickers by the percentage price target raise of their latest event, or cut for the tickers to avoid"
+}
+
+func (s TargetUpsideStrategy) Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, DirectionPositive)
+}
+
+func (s TargetUpsideStrategy) Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, DirectionNegative)
+}
+
+// rank scores the tickers, keeping those belonging to the direction
+func (TargetUpsideStrategy) rank(stocks []models.Stock, direction Direction) []StockRecommendation {
+	tickers, events := groupByTicker(stocks)
+
+	var recommendations []StockRecommendation
+	for _, ticker := range tickers {
+		for _, stock := range events[ticker] {
+			if stock.TargetFrom <= 0 || stock.TargetTo <= 0 {
+				continue
+			}
+
+			upside := (stock.TargetTo - stock.TargetFrom) / stock.TargetFrom * 100
+			if direction.keeps(upside) {
+				factorID, reason := FactorTargetUpside, fmt.Sprintf("Price target raised %.1f%%", upside)
+				if upside < 0 {
+					factorID, reason = FactorTargetDownside, fmt.Sprintf("Price target cut %.1f%%", -upside)
+				}
+				recommendations = append(recommendations, StockRecommendation{
+					Stock:  stock,
+					Score:  upside,
+					Reason: reason,
+					Factors: []ScoreFactor{{
+						ID:          factorID,
+						Description: reason,
+						Inputs: map[string]interface{}{
+							"target_from": stock.TargetFrom,
+							"target_to":   stock.TargetTo,
+						},
+						Contribution: upside,
+					}},
+				})
+			}
+			break
+		}
+	}
+
+	return recommendations
+}
+
+// ConsensusChangeStrategy scores tickers on how much the average rating moved
+// across their recent events, using the rating scores of the configuration
+type ConsensusChangeStrategy struct{}
+
+func (ConsensusChangeStrategy) Name() string { return "consensus_change" }
+
+func (ConsensusChangeStrategy) Description() string {
+	return "Ranks tickers by the improvement of their average rating across the recent events, or deterioration for the tickers to avoid"
+}
+
+func (s ConsensusChangeStrategy) Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionPositive)
+}
+
+func (s ConsensusChangeStrategy) Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation {
+	return s.rank(stocks, scoring, DirectionNegative)
+}
+
+// rank scores the tickers, keeping those belonging to the direction
+func (ConsensusChangeStrategy) rank(stocks []models.Stock, scoring ScoringConfig, direction Direction) []StockRecommendation {
+	tickers, events := groupByTicker(stocks)
+
+	var recommendations []StockRecommendation
+	for _, ticker := range tickers {
+		var latest models.Stock
+		var before, after float64
+		ratings := 0
+		for _, stock := range events[ticker] {
+			if stock.RatingFrom == "" || stock.RatingTo == "" {
+				continue
+			}
+			if ratings == 0 {
+				latest = stock
+			}
+			before += scoring.RatingScores.Score(GetRatingLevel(stock.Brokerage, stock.RatingFrom))
+			after += scoring.RatingScores.Score(GetRatingLevel(stock.Brokerage, stock.RatingTo))
+			ratings++
+		}
+
+		if ratings == 0 {
+			continue
+		}
+
+		change := (after - before) / float64(ratings)
+		if direction.keeps(change) {
+			reason := fmt.Sprintf("Consensus improved by %.2f across %d ratings", change, ratings)
+			if change < 0 {
+				reason = fmt.Sprintf("Consensus worsened by %.2f across %d ratings", -change, ratings)
+			}
+			recommendations = append(recommendations, StockRecommendation{
+				Stock:  latest,
+				Score:  change,
+				Reason: reason,
+				Factors: []ScoreFactor{{
+					ID:          FactorConsensusChange,
+					Description: reason,
+					Inputs: map[string]interface{}{
+						"average_before": before / float64(ratings),
+						"average_after":  after / float64(ratings),
+						"ratings":        ratings,
+					},
+					Contribution: change,
+				}},
+			})
+		}
+	}
+
+	return recommendations
+}
+
+// days converts a number of days to a duration
+func days(n float64) time.Duration {
+	return time.Duration(n * float64(24*time.Hour))
+}
+
+// groupByTicker returns the tickers in order of first appearance and their
+// events in the order given
+func groupByTicker(stocks []models.Stock) ([]string, map[string][]models.Stock) {
+	var tickers []string
+	events := make(map[string][]models.Stock)
+	for _, stock := range stocks {
+		if _, seen := events[stock.Ticker]; !seen {
+			tickers = append(tickers, stock.Ticker)
+		}
+		events[stock.Ticker] = append(events[stock.Ticker], stock)
+	}
+
+	return tickers, events
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStrategy is the strategy used when a request names none
+const DefaultStrategy = "consensus"
+
+// ErrUnknownStrategy is returned when a request names an unregistered strategy
+var ErrUnknownStrategy = errors.New("unknown recommendation strategy")
+
+// ErrUnsupportedDirection is returned when a strategy cannot rank the
+// requested direction
+var ErrUnsupportedDirection = errors.New("direction not supported by the strategy")
+
+// Direction selects which end of the scores is ranked: the tickers to buy,
+// scoring above zero, or the tickers to avoid, scoring below zero
+type Direction string
+
+// Ranking directions, the zero value ranks the tickers to buy
+const (
+	DirectionPositive Direction = "positive"
+	DirectionNegative Direction = "negative"
+)
+
+// ParseDirection parses a direction name, empty names are DirectionPositive
+func ParseDirection(name string) (Direction, error) {
+	switch Direction(strings.ToLower(name)) {
+	case "", DirectionPositive:
+		return DirectionPositive, nil
+	case DirectionNegative:
+		return DirectionNegative, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q, expected %s or %s", name, DirectionPositive, DirectionNegative)
+	}
+}
+
+// keeps reports whether a score belongs to the ranking of the direction
+func (d Direction) keeps(score float64) bool {
+	if d == DirectionNegative {
+		return score < 0
+	}
+	return score > 0
+}
+
+// Strategy ranks stocks from the recent rating events. Recommend receives the
+// events newest first and returns at most one recommendation per ticker, only
+// for tickers it scores above zero.
+type Strategy interface {
+	Name() string
+	Description() string
+	Recommend(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation
+}
+
+// AvoidStrategy is implemented by strategies that also rank the tickers to
+// avoid. Avoid receives the events newest first and returns at most one
+// recommendation per ticker, only for tickers it scores below zero.
+type AvoidStrategy interface {
+	Strategy
+	Avoid(stocks []models.Stock, scoring ScoringConfig) []StockRecommendation
+}
+
+// WindowedStrategy is implemented by strategies ranking the latest call of
+// every brokerage within a time window instead of the most recent events
+type WindowedStrategy interface {
+	Strategy
+	Window(scoring ScoringConfig) time.Duration
+}
+
+// StrategyInfo describes a registered strategy to clients
+type StrategyInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Default     bool        `json:"default"`
+	Directions  []Direction `json:"directions"`
+}
+
+// StrategyRegistry holds the strategies selectable by name
+type StrategyRegistry struct {
+	mu         sync.RWMutex
+	strategies map[string]Strategy
+	names      []string
+}
+
+// NewStrategyRegistry returns a registry holding the given strategies
+func NewStrategyRegistry(strategies ...Strategy) (*StrategyRegistry, error) {
+	registry := &StrategyRegistry{strategies: make(map[string]Strategy)}
+	for _, strategy := range strategies {
+		if err := registry.Register(strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// DefaultStrategyRegistry returns a registry holding the built-in strategies
+func DefaultStrategyRegistry() *StrategyRegistry {
+	registry, _ := NewStrategyRegistry(
+		ConsensusStrategy{},
+		HeuristicStrategy{},
+		MomentumStrategy{},
+		TargetUpsideStrategy{},
+		ConsensusChangeStrategy{},
+	)
+
+	return registry
+}
+
+// Register adds a strategy, names must be unique
+func (r *StrategyRegistry) Register(strategy Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := strategy.Name()
+	if _, exists := r.strategies[name]; exists {
+		return fmt.Errorf("strategy %q is already registered", name)
+	}
+
+	r.strategies[name] = strategy
+	r.names = append(r.names, name)
+
+	return nil
+}
+
+// Get returns the strategy registered under name, or the default strategy
+// when name is empty
+func (r *StrategyRegistry) Get(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	strategy, ok := r.strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+
+	return strategy, nil
+}
+
+// List describes the registered strategies in registration order
+func (r *StrategyRegistry) List() []StrategyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]StrategyInfo, 0, len(r.names))
+	for _, name := range r.names {
+		strategy := r.strategies[name]
+		directions := []Direction{DirectionPositive}
+		if _, ok := strategy.(AvoidStrategy); ok {
+			directions = append(directions, DirectionNegative)
+		}
+
+		infos = append(infos, StrategyInfo{
+			Name:        name,
+			Description: strategy.Description(),
+			Default:     name == DefaultStrategy,
+			Directions:  directions,
+		})
+	}
+
+	return infos
+}
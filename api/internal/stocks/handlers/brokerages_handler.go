@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/stocks/models"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetBrokerages handles the API endpoint to retrieve the brokerage leaderboard
+func (h *StockHandler) GetBrokerages(c echo.Context) error {
+	window, err := models.ParseWindow(c.QueryParam("window"))
+	if err != nil {
+		return apierrors.InvalidParameter("window", "Invalid window parameter: "+err.Error())
+	}
+
+	limit, err := parseIntParam(c.QueryParam("limit"), 20, 1, 100)
+	if err != nil {
+		return apierrors.InvalidParameter("limit", "Invalid limit parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	sortField := models.BrokerageSortByCalls
+	sortAsc := false
+	if sort := c.QueryParam("sort"); sort != "" {
+		field, direction, _ := strings.Cut(sort, ":")
+		if !isBrokerageSortField(field) {
+			return apierrors.InvalidParameter("sort", "Invalid sort field "+field+", expected one of "+strings.Join(models.BrokerageSortFields, ", "))
+		}
+
+		switch strings.ToLower(direction) {
+		case "", "desc":
+		case "asc":
+			sortAsc = true
+		default:
+			return apierrors.InvalidParameter("sort", "Invalid sort direction "+direction+", expected asc or desc")
+		}
+
+		sortField = field
+	}
+
+	middleware.NowRelative(c)
+	leaderboard, err := h.stockService.GetBrokerageLeaderboard(window, sortField, sortAsc, limit)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve brokerages")
+	}
+
+	leaderboard.WindowStart = leaderboard.WindowStart.In(loc)
+	leaderboard.WindowEnd = leaderboard.WindowEnd.In(loc)
+	for i := range leaderboard.Brokerages {
+		leaderboard.Brokerages[i].LastActivity = leaderboard.Brokerages[i].LastActivity.In(loc)
+	}
+
+	return c.JSON(http.StatusOK, leaderboard)
+}
+
+// GetBrokerageCalls handles the API endpoint to retrieve the rating events of a
+// brokerage, accepting the same filters and pagination as GetAllStocks
+func (h *StockHandler) GetBrokerageCalls(c echo.Context) error {
+	// Echo unescapes params, except when it routes on the raw path because
+	// its escaping differs from the default, as for names containing a slash
+	name := c.Param("name")
+	if c.Request().URL.RawPath != "" {
+		unescaped, err := url.PathUnescape(name)
+		if err != nil {
+			return apierrors.InvalidParameter("name", "Invalid brokerage name parameter")
+		}
+		name = unescaped
+	}
+	if strings.TrimSpace(name) == "" {
+		return apierrors.InvalidParameter("name", "Brokerage name parameter is required")
+	}
+
+	return h.listStocks(c, func(filter *models.StockFilter) {
+		filter.Brokerages = []string{name}
+	})
+}
+
+// isBrokerageSortField reports whether field is a whitelisted leaderboard sort field
+func isBrokerageSortField(field string) bool {
+	for _, f := range models.BrokerageSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
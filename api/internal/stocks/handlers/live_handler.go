@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultHeartbeatInterval is how often idle live feeds send a heartbeat
+	// so clients and proxies keep the connection open
+	defaultHeartbeatInterval = 15 * time.Second
+
+	// sseRetry is the reconnect delay suggested to EventSource clients, in milliseconds
+	sseRetry = 3000
+
+	// wsWriteTimeout bounds how long a WebSocket client may take to accept a message
+	wsWriteTimeout = 10 * time.Second
+)
+
+// Live feed message types
+const (
+	liveEventStock     = "stock"
+	liveEventHeartbeat = "heartbeat"
+)
+
+// The API key authenticates WebSocket clients, no cookies are involved, so
+// connections are accepted from any origin
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// liveEvent is a message of the live feed. Stock events carry an ID to resume
+// from after a reconnect.
+type liveEvent struct {
+	Type  string        `json:"type"`
+	ID    string        `json:"id,omitempty"`
+	Stock *models.Stock `json:"stock,omitempty"`
+	Time  time.Time     `json:"time"`
+}
+
+// SetHeartbeatInterval sets how often idle live feeds send a heartbeat
+func (h *StockHandler) SetHeartbeatInterval(interval time.Duration) {
+	h.heartbeatInterval = interval
+}
+
+// StreamStocks handles the API endpoint pushing new and changed rating events
+// as Server-Sent Events
+func (h *StockHandler) StreamStocks(c echo.Context) error {
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	after, filter, loc, err := parseLiveParams(c, lastEventID)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", sseRetry); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	err = h.runLiveFeed(c.Request().Context(), after, filter, loc, func(event liveEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		var b strings.Builder
+		if event.ID != "" {
+			fmt.Fprintf(&b, "id: %s\n", event.ID)
+		}
+		fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", event.Type, data)
+
+		if _, err := res.Write([]byte(b.String())); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+
+	// The response is committed, failures can only be logged
+	if err != nil {
+		c.Logger().Errorf("live feed: %v", err)
+	}
+
+	return nil
+}
+
+// StreamStocksWebSocket handles the API endpoint pushing new and changed
+// rating events over a WebSocket
+func (h *StockHandler) StreamStocksWebSocket(c echo.Context) error {
+	after, filter, loc, err := parseLiveParams(c, c.QueryParam("last_event_id"))
+	if err != nil {
+		return err
+	}
+
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader already replied to the client
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	// The feed is one way, reading only notices when the client goes away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = h.runLiveFeed(ctx, after, filter, loc, func(event liveEvent) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(event)
+	})
+
+	closeCode, reason := websocket.CloseNormalClosure, ""
+	if err != nil {
+		c.Logger().Errorf("live feed: %v", err)
+		closeCode, reason = websocket.CloseInternalServerErr, "Internal server error"
+	}
+
+	message := websocket.FormatCloseMessage(closeCode, reason)
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+
+	return nil
+}
+
+// parseLiveParams reads the resume position, the filters and the time zone of
+// a live feed request. Without a last event ID the feed starts now.
+func parseLiveParams(c echo.Context, lastEventID string) (models.Cursor, models.StockFilter, *time.Location, error) {
+	after := models.Cursor{Time: time.Now().UTC()}
+	if lastEventID != "" {
+		cursor, err := models.DecodeCursor(lastEventID)
+		if err != nil {
+			return after, models.StockFilter{}, nil, apierrors.InvalidParameter("last_event_id", "Invalid last event ID")
+		}
+		after = *cursor
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return after, models.StockFilter{}, nil, apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	filter := models.StockFilter{
+		Tickers:    parseList(c, "ticker"),
+		Brokerages: parseList(c, "brokerage"),
+		Action:     strings.TrimSpace(c.QueryParam("action")),
+	}
+	for i, ticker := range filter.Tickers {
+		filter.Tickers[i] = strings.ToUpper(ticker)
+	}
+
+	return after, filter, loc, nil
+}
+
+// runLiveFeed sends the stocks changed after the position, interleaved with
+// heartbeats while idle, until ctx is done. A failed send means the client
+// went away and ends the feed without error.
+func (h *StockHandler) runLiveFeed(ctx context.Context, after models.Cursor, filter models.StockFilter, loc *time.Location, send func(event liveEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	interval := h.heartbeatInterval
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+
+	events := make(chan liveEvent)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- h.stockService.WatchStocks(ctx, after, filter, func(stock models.Stock, position models.Cursor) error {
+			stock = stock.InLocation(loc)
+			event := liveEvent{Type: liveEventStock, ID: position.Encode(), Stock: &stock, Time: stock.UpdatedAt}
+
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watchErr:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case event := <-events:
+			if err := send(event); err != nil {
+				return nil
+			}
+			heartbeat.Reset(interval)
+		case now := <-heartbeat.C:
+			if err := send(liveEvent{Type: liveEventHeartbeat, Time: now.In(loc)}); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/stocks/models"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxLookupTickers      = 500
+	defaultLookupEvents   = 5
+	maxLookupEvents       = 50
+	maxCompareTickers     = 10
+	defaultCompareActions = 3
+)
+
+// lookupRequest is the body of POST /stocks/lookup
+type lookupRequest struct {
+	Tickers []string `json:"tickers"`
+	Events  *int     `json:"events"`
+	Window  string   `json:"window"`
+}
+
+// LookupStocks handles the API endpoint to retrieve the latest events and
+// summary of many tickers in one round trip
+func (h *StockHandler) LookupStocks(c echo.Context) error {
+	var body lookupRequest
+	if err := c.Bind(&body); err != nil {
+		return apierrors.InvalidInput("Invalid request body: " + err.Error())
+	}
+
+	if len(body.Tickers) == 0 {
+		return apierrors.InvalidParameter("tickers", "Request body must contain at least one ticker")
+	}
+
+	if len(body.Tickers) > maxLookupTickers {
+		return apierrors.InvalidParameter("tickers", fmt.Sprintf("At most %d tickers can be looked up at once", maxLookupTickers))
+	}
+
+	events := defaultLookupEvents
+	if body.Events != nil {
+		events = *body.Events
+		if events < 0 || events > maxLookupEvents {
+			return apierrors.InvalidParameter("events", fmt.Sprintf("Invalid events value, expected 0 to %d", maxLookupEvents))
+		}
+	}
+
+	window, err := models.ParseWindow(body.Window)
+	if err != nil {
+		return apierrors.InvalidParameter("window", "Invalid window: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	lookups, err := h.stockService.LookupTickers(body.Tickers, events, window)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to look up stocks")
+	}
+
+	notFound := []string{}
+	for i, lookup := range lookups {
+		lookups[i] = lookup.InLocation(loc)
+		if !lookup.Found {
+			notFound = append(notFound, lookup.Ticker)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results":   lookups,
+		"not_found": notFound,
+	})
+}
+
+// CompareStocks handles the API endpoint to compare the consensus of a few
+// tickers side by side
+func (h *StockHandler) CompareStocks(c echo.Context) error {
+	tickers := parseList(c, "ticker")
+	if len(tickers) < 2 || len(tickers) > maxCompareTickers {
+		return apierrors.InvalidParameter("ticker", fmt.Sprintf("Between 2 and %d tickers are required", maxCompareTickers))
+	}
+
+	actions := defaultCompareActions
+	if value := c.QueryParam("actions"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > maxLookupEvents {
+			return apierrors.InvalidParameter("actions", fmt.Sprintf("Invalid actions parameter, expected 0 to %d", maxLookupEvents))
+		}
+		actions = n
+	}
+
+	window, err := models.ParseWindow(c.QueryParam("window"))
+	if err != nil {
+		return apierrors.InvalidParameter("window", "Invalid window parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	middleware.NowRelative(c)
+	comparisons, err := h.stockService.CompareTickers(tickers, actions, window)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to compare stocks")
+	}
+
+	for i := range comparisons {
+		for j, stock := range comparisons[i].RecentActions {
+			comparisons[i].RecentActions[j] = stock.InLocation(loc)
+		}
+	}
+
+	return c.JSON(http.StatusOK, comparisons)
+}
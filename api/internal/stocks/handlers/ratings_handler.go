@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/models"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxRatingLength is the longest rating string that can be mapped, the size
+// of the rating columns
+const maxRatingLength = 50
+
+// GetUnmappedRatings handles the API endpoint to list the ratings of the
+// stored events missing from the rating ladder
+func (h *StockHandler) GetUnmappedRatings(c echo.Context) error {
+	limit, err := parseIntParam(c.QueryParam("limit"), 50, 1, 500)
+	if err != nil {
+		return apierrors.InvalidParameter("limit", "Invalid limit parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	ratings, err := h.stockService.GetUnmappedRatings(limit)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve unmapped ratings")
+	}
+
+	if ratings == nil {
+		ratings = []models.UnmappedRating{}
+	}
+	for i := range ratings {
+		ratings[i].FirstSeen = ratings[i].FirstSeen.In(loc)
+		ratings[i].LastSeen = ratings[i].LastSeen.In(loc)
+	}
+
+	return c.JSON(http.StatusOK, ratings)
+}
+
+// GetRatingMappings handles the API endpoint to list the rating mappings
+// added at runtime
+func (h *StockHandler) GetRatingMappings(c echo.Context) error {
+	mappings, err := h.stockService.GetRatingMappings()
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve rating mappings")
+	}
+
+	if mappings == nil {
+		mappings = []models.RatingMapping{}
+	}
+
+	return c.JSON(http.StatusOK, mappings)
+}
+
+// MapRating handles the API endpoint to place a rating on the ladder
+func (h *StockHandler) MapRating(c echo.Context) error {
+	rating, err := ratingParam(c)
+	if err != nil {
+		return err
+	}
+
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := c.Bind(&body); err != nil || strings.TrimSpace(body.Level) == "" {
+		return apierrors.InvalidParameter("level", "Request body must contain a level, one of "+strings.Join(models.RatingLevelNames(), ", "))
+	}
+
+	level, err := models.ParseRatingLevel(strings.TrimSpace(body.Level))
+	if err != nil {
+		return apierrors.InvalidParameter("level", "Invalid level: "+err.Error())
+	}
+
+	mapping, err := h.stockService.MapRating(rating, level)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to map rating")
+	}
+
+	return c.JSON(http.StatusOK, mapping)
+}
+
+// UnmapRating handles the API endpoint to remove the mapping of a rating
+func (h *StockHandler) UnmapRating(c echo.Context) error {
+	rating, err := ratingParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.stockService.UnmapRating(rating); err != nil {
+		return apierrors.Wrap(err, "Failed to unmap rating")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ratingParam reads the rating string from the path
+func ratingParam(c echo.Context) (string, error) {
+	rating, err := url.PathUnescape(c.Param("rating"))
+	if err != nil || strings.TrimSpace(rating) == "" {
+		return "", apierrors.InvalidParameter("rating", "Rating parameter is required")
+	}
+
+	rating = strings.TrimSpace(rating)
+	if len(rating) > maxRatingLength {
+		return "", apierrors.InvalidParameter("rating", "Rating parameter must not be longer than 50 characters")
+	}
+
+	return rating, nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSentiment handles the API endpoint to retrieve the market sentiment time series
+func (h *StockHandler) GetSentiment(c echo.Context) error {
+	interval := strings.ToLower(c.QueryParam("interval"))
+	switch interval {
+	case "":
+		interval = models.IntervalDay
+	case models.IntervalDay, models.IntervalWeek, models.IntervalMonth:
+	default:
+		return apierrors.InvalidParameter("interval", "Invalid interval parameter, expected day, week or month")
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	since, until, err := parseTimeRange(c, loc)
+	if err != nil {
+		return apierrors.InvalidInput("Invalid time range: " + err.Error())
+	}
+
+	tickers := parseList(c, "ticker")
+	for i, ticker := range tickers {
+		tickers[i] = strings.ToUpper(ticker)
+	}
+
+	query := models.SentimentQuery{
+		Interval:   interval,
+		Location:   loc,
+		Since:      since,
+		Until:      until,
+		Tickers:    tickers,
+		Brokerages: parseList(c, "brokerage"),
+		Sector:     strings.TrimSpace(c.QueryParam("sector")),
+	}
+
+	series, err := h.stockService.GetSentimentSeries(query)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve sentiment")
+	}
+
+	series.WindowStart = series.WindowStart.In(loc)
+	series.WindowEnd = series.WindowEnd.In(loc)
+	for i := range series.Buckets {
+		series.Buckets[i].Bucket = series.Buckets[i].Bucket.In(loc)
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
+
+// SetTickerSector handles the API endpoint to assign a ticker to a sector
+func (h *StockHandler) SetTickerSector(c echo.Context) error {
+	ticker := c.Param("ticker")
+
+	var body struct {
+		Sector string `json:"sector"`
+	}
+	if err := c.Bind(&body); err != nil || strings.TrimSpace(body.Sector) == "" {
+		return apierrors.InvalidParameter("sector", "Request body must contain a sector")
+	}
+
+	if err := h.stockService.SetTickerSector(ticker, body.Sector); err != nil {
+		return apierrors.Wrap(err, "Failed to set sector")
+	}
+
+	return c.JSON(http.StatusOK, models.TickerSector{
+		Ticker:    strings.ToUpper(ticker),
+		Sector:    strings.TrimSpace(body.Sector),
+		UpdatedAt: time.Now().UTC(),
+	})
+}
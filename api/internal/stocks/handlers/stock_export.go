@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exportBatchSize is the number of rows fetched and flushed at a time
+const exportBatchSize = 1000
+
+// exportNumericColumns are the indexes of the numeric ExportColumns
+var exportNumericColumns = map[int]bool{7: true, 8: true}
+
+// csvFormulaPrefixes start the cells spreadsheets evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// exportContentTypes maps the export formats to their media types
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV:    "text/csv; charset=utf-8",
+	models.ExportFormatNDJSON: "application/x-ndjson",
+	models.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportMediaTypes maps the accepted media types to export formats
+var exportMediaTypes = map[string]string{
+	"text/csv":             models.ExportFormatCSV,
+	"application/x-ndjson": models.ExportFormatNDJSON,
+	"application/ndjson":   models.ExportFormatNDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": models.ExportFormatXLSX,
+}
+
+// ExportStocks handles the API endpoint to download every stock matching the
+// filters as CSV, NDJSON or XLSX
+func (h *StockHandler) ExportStocks(c echo.Context) error {
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = negotiateExportFormat(c.Request().Header.Get(echo.HeaderAccept))
+	}
+	if format == "" {
+		format = models.ExportFormatCSV
+	}
+
+	if _, ok := exportContentTypes[format]; !ok {
+		return apierrors.InvalidParameter("format", "Invalid format parameter, expected csv, ndjson or xlsx")
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	filter, err := parseStockFilter(c, loc)
+	if err != nil {
+		return apierrors.InvalidInput("Invalid filter: " + err.Error())
+	}
+
+	return h.streamExport(c, filter, format, loc)
+}
+
+// negotiateExportFormat returns the export format preferred by the Accept
+// header, or an empty string when JSON (or anything else) ranks higher
+func negotiateExportFormat(accept string) string {
+	type mediaRange struct {
+		format string
+		q      float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(name) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q > 0 {
+			ranges = append(ranges, mediaRange{format: exportMediaTypes[mediaType], q: q})
+		}
+	}
+
+	// Ties keep the header order
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	if len(ranges) == 0 {
+		return ""
+	}
+	return ranges[0].format
+}
+
+// streamExport writes the matching stocks to the response batch by batch,
+// flushing after each one. Once the first batch is out the status can no
+// longer change, so later failures simply end the download early.
+func (h *StockHandler) streamExport(c echo.Context, filter models.StockFilter, format string, loc *time.Location) error {
+	res := c.Response()
+	filename := fmt.Sprintf("stocks-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	res.Header().Set(echo.HeaderContentType, exportContentTypes[format])
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	writer := newStockExportWriter(format, res)
+	started := false
+
+	err := h.stockService.StreamStocks(filter, exportBatchSize, func(batch []models.Stock) error {
+		if !started {
+			res.WriteHeader(http.StatusOK)
+			if err := writer.WriteHeader(); err != nil {
+				return err
+			}
+			started = true
+		}
+
+		for _, stock := range batch {
+			if err := writer.Write(stock.InLocation(loc)); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+
+	if err != nil && !started {
+		return apierrors.Wrap(err, "Failed to export stocks")
+	}
+	if err != nil {
+		return err
+	}
+
+	if !started {
+		res.WriteHeader(http.StatusOK)
+		if err := writer.WriteHeader(); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// stockExportWriter encodes stocks in one export format
+type stockExportWriter interface {
+	WriteHeader() error
+	Write(stock models.Stock) error
+	Flush() error
+	Close() error
+}
+
+// newStockExportWriter returns the writer for a validated format
+func newStockExportWriter(format string, w io.Writer) stockExportWriter {
+	switch format {
+	case models.ExportFormatNDJSON:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w)}
+	case models.ExportFormatXLSX:
+		return &xlsxExportWriter{zip: zip.NewWriter(w)}
+	default:
+		return &csvExportWriter{csv: csv.NewWriter(w)}
+	}
+}
+
+type csvExportWriter struct {
+	csv *csv.Writer
+}
+
+func (w *csvExportWriter) WriteHeader() error {
+	return w.csv.Write(models.ExportColumns)
+}
+
+func (w *csvExportWriter) Write(stock models.Stock) error {
+	record := stock.ExportRecord()
+	for i, value := range record {
+		if !exportNumericColumns[i] {
+			record[i] = neutralizeFormula(value)
+		}
+	}
+	return w.csv.Write(record)
+}
+
+// neutralizeFormula quotes text that a spreadsheet opening the CSV would run
+// as a formula, such as a company name starting with =
+func neutralizeFormula(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvExportWriter) Close() error {
+	return w.Flush()
+}
+
+// ndjsonRow keeps the NDJSON keys in ExportColumns order
+type ndjsonRow struct {
+	ID         string    `json:"id"`
+	Ticker     string    `json:"ticker"`
+	Company    string    `json:"company"`
+	Brokerage  string    `json:"brokerage"`
+	Action     string    `json:"action"`
+	RatingFrom string    `json:"rating_from"`
+	RatingTo   string    `json:"rating_to"`
+	TargetFrom float64   `json:"target_from"`
+	TargetTo   float64   `json:"target_to"`
+	Time       time.Time `json:"time"`
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonExportWriter) WriteHeader() error {
+	return nil
+}
+
+func (w *ndjsonExportWriter) Write(stock models.Stock) error {
+	return w.encoder.Encode(ndjsonRow{
+		ID:         stock.ID,
+		Ticker:     stock.Ticker,
+		Company:    stock.Company,
+		Brokerage:  stock.Brokerage,
+		Action:     stock.Action,
+		RatingFrom: stock.RatingFrom,
+		RatingTo:   stock.RatingTo,
+		TargetFrom: stock.TargetFrom,
+		TargetTo:   stock.TargetTo,
+		Time:       stock.Time,
+	})
+}
+
+func (w *ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (w *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxParts are the fixed parts of a single sheet workbook
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Stocks" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxExportWriter streams a workbook with one sheet of inline strings. The
+// zip entries are written in order, so nothing is buffered beyond a row.
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func (w *xlsxExportWriter) WriteHeader() error {
+	for _, part := range xlsxParts {
+		f, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = sheet
+
+	_, err = io.WriteString(w.sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return err
+	}
+
+	return w.writeRow(models.ExportColumns, nil)
+}
+
+func (w *xlsxExportWriter) Write(stock models.Stock) error {
+	return w.writeRow(stock.ExportRecord(), exportNumericColumns)
+}
+
+// writeRow writes the values as one row, numeric columns as numbers
+func (w *xlsxExportWriter) writeRow(values []string, numeric map[int]bool) error {
+	w.row++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.row)
+	for i, value := range values {
+		if numeric[i] {
+			fmt.Fprintf(&b, `<c t="n"><v>%s</v></c>`, value)
+			continue
+		}
+		b.WriteString(`<c t="inlineStr"><is><t>`)
+		xml.EscapeText(&b, []byte(value))
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, b.String())
+	return err
+}
+
+func (w *xlsxExportWriter) Flush() error {
+	return w.zip.Flush()
+}
+
+func (w *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"fmt"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/stocks/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// dateLayout is the layout accepted for calendar dates in query parameters
+const dateLayout = "2006-01-02"
+
+// parseStockFilter reads the stock listing filters from the query string.
+// Calendar dates are evaluated in loc so day boundaries follow the caller's time zone.
+func parseStockFilter(c echo.Context, loc *time.Location) (models.StockFilter, error) {
+	filter := models.StockFilter{
+		Tickers:    parseList(c, "ticker"),
+		Brokerages: parseList(c, "brokerage"),
+		Action:     strings.TrimSpace(c.QueryParam("action")),
+		RatingFrom: strings.TrimSpace(c.QueryParam("rating_from")),
+		RatingTo:   strings.TrimSpace(c.QueryParam("rating_to")),
+		Sector:     strings.TrimSpace(c.QueryParam("sector")),
+	}
+
+	for i, ticker := range filter.Tickers {
+		filter.Tickers[i] = strings.ToUpper(ticker)
+	}
+
+	if category := c.QueryParam("rating_category"); category != "" {
+		switch strings.ToLower(category) {
+		case "positive":
+			filter.RatingCategory = models.RatingCategoryPositive
+		case "neutral":
+			filter.RatingCategory = models.RatingCategoryNeutral
+		case "negative":
+			filter.RatingCategory = models.RatingCategoryNegative
+		default:
+			return filter, fmt.Errorf("invalid rating_category %q, expected positive, neutral or negative", category)
+		}
+	}
+
+	for _, name := range parseList(c, "rating") {
+		level, err := models.ParseRatingLevel(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.Ratings = append(filter.Ratings, level)
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(c.QueryParam("from"), loc, false); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseTimeParam(c.QueryParam("to"), loc, true); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+
+	floatParams := []struct {
+		name string
+		dest **float64
+	}{
+		{"target_min", &filter.TargetMin},
+		{"target_max", &filter.TargetMax},
+		{"target_change_min", &filter.TargetChangeMin},
+		{"target_change_max", &filter.TargetChangeMax},
+	}
+	for _, param := range floatParams {
+		if *param.dest, err = parseFloatParam(c.QueryParam(param.name)); err != nil {
+			return filter, fmt.Errorf("invalid %s: %w", param.name, err)
+		}
+	}
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		field, direction, _ := strings.Cut(sort, ":")
+		if !isStockSortField(field) {
+			return filter, fmt.Errorf("invalid sort field %q, expected one of %s",
+				field, strings.Join(models.StockSortFields, ", "))
+		}
+
+		switch strings.ToLower(direction) {
+		case "", "asc":
+		case "desc":
+			filter.SortDesc = true
+		default:
+			return filter, fmt.Errorf("invalid sort direction %q, expected asc or desc", direction)
+		}
+
+		filter.SortField = field
+	}
+
+	return filter, nil
+}
+
+// parseList collects a repeatable, comma separated query parameter
+func parseList(c echo.Context, name string) []string {
+	var values []string
+	for _, raw := range c.QueryParams()[name] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// parseTimeParam accepts either RFC 3339 timestamps or calendar dates. When
+// endOfDay is set a calendar date covers the whole day, so the returned
+// exclusive bound is the start of the following day.
+func parseTimeParam(value string, loc *time.Location, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a date (YYYY-MM-DD) nor an RFC 3339 timestamp", value)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+// parseFloatParam parses an optional numeric query parameter
+func parseFloatParam(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", value)
+	}
+
+	return &f, nil
+}
+
+// parseIntParam parses an optional integer query parameter within [min, max],
+// returning def when it is not given
+func parseIntParam(value string, def, min, max int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%q is not an integer between %d and %d", value, min, max)
+	}
+
+	return n, nil
+}
+
+// parseTimeRange reads the from/to parameters of time series endpoints.
+// Without from, the range starts window (default 90 days) before its end;
+// without to, it ends now and the response is flagged as now-relative.
+func parseTimeRange(c echo.Context, loc *time.Location) (time.Time, time.Time, error) {
+	from, err := parseTimeParam(c.QueryParam("from"), loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+
+	to, err := parseTimeParam(c.QueryParam("to"), loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+
+	until := time.Now().UTC()
+	if to != nil {
+		until = *to
+	} else {
+		middleware.NowRelative(c)
+	}
+
+	window, err := models.ParseWindow(c.QueryParam("window"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	since := until.Add(-window)
+	if from != nil {
+		since = *from
+	}
+
+	if !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+
+	if until.Sub(since) > models.MaxWindowDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range exceeds %d days", models.MaxWindowDays)
+	}
+
+	return since, until, nil
+}
+
+// isStockSortField reports whether field is a whitelisted sort field
+func isStockSortField(field string) bool {
+	for _, f := range models.StockSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,25 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/stocks/models"
 	"stonks-api/internal/stocks/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
 type StockHandler struct {
 	stockService *services.StockService
+
+	heartbeatInterval time.Duration
 }
 
 func NewStockHandler(stockService *services.StockService) *StockHandler {
@@ -22,9 +32,7 @@ func NewStockHandler(stockService *services.StockService) *StockHandler {
 func (h *StockHandler) SyncStocks(c echo.Context) error {
 	count, err := h.stockService.SyncStocks()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to sync stocks: " + err.Error(),
-		})
+		return apierrors.Wrap(err, "Failed to sync stocks")
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -33,57 +41,272 @@ func (h *StockHandler) SyncStocks(c echo.Context) error {
 	})
 }
 
-// GetAllStocks handles the API endpoint to retrieve all stocks with pagination
+// GetAllStocks handles the API endpoint to retrieve all stocks with filters and pagination
 func (h *StockHandler) GetAllStocks(c echo.Context) error {
+	return h.listStocks(c, nil)
+}
+
+// listStocks serves a filtered, paginated stock listing. The optional scope
+// narrows the filter parsed from the query string.
+func (h *StockHandler) listStocks(c echo.Context, scope func(filter *models.StockFilter)) error {
 	// Parse pagination parameters
-	page, err := strconv.Atoi(c.QueryParam("page"))
-	if err != nil || page < 1 {
-		page = 1
+	page, err := parseIntParam(c.QueryParam("page"), 1, 1, math.MaxInt32)
+	if err != nil {
+		return apierrors.InvalidParameter("page", "Invalid page parameter: "+err.Error())
+	}
+
+	pageSize, err := parseIntParam(c.QueryParam("page_size"), 20, 1, 100)
+	if err != nil {
+		return apierrors.InvalidParameter("page_size", "Invalid page_size parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	filter, err := parseStockFilter(c, loc)
+	if err != nil {
+		return apierrors.InvalidInput("Invalid filter: " + err.Error())
+	}
+
+	if scope != nil {
+		scope(&filter)
+	}
+
+	// Spreadsheet and line-delimited clients get the whole filtered set streamed
+	if format := negotiateExportFormat(c.Request().Header.Get(echo.HeaderAccept)); format != "" {
+		return h.streamExport(c, filter, format, loc)
+	}
+
+	params := models.PaginationParams{
+		Page:     page,
+		PageSize: pageSize,
+		Filter:   filter,
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		if filter.SortField != "" {
+			return apierrors.InvalidInput("Cursor pagination only supports the default time order")
+		}
+
+		params.Cursor, err = models.DecodeCursor(cursor)
+		if err != nil {
+			return apierrors.InvalidParameter("cursor", "Invalid cursor parameter")
+		}
 	}
 
-	pageSize, err := strconv.Atoi(c.QueryParam("page_size"))
-	if err != nil || pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	switch c.QueryParam("count") {
+	case "", "cached":
+	case "exact":
+		params.ExactCount = true
+	default:
+		return apierrors.InvalidParameter("count", "Invalid count parameter, expected exact or cached")
 	}
 
-	paginatedStocks, err := h.stockService.GetAllStocks(page, pageSize)
+	paginatedStocks, err := h.stockService.GetAllStocks(params)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve stocks: " + err.Error(),
-		})
+		return apierrors.Wrap(err, "Failed to retrieve stocks")
+	}
+
+	for i := range paginatedStocks.Stocks {
+		paginatedStocks.Stocks[i] = paginatedStocks.Stocks[i].InLocation(loc)
 	}
 
+	setPaginationLinks(c, paginatedStocks, params.Cursor != nil)
+
 	return c.JSON(http.StatusOK, paginatedStocks)
 }
 
+// setPaginationLinks adds an RFC 8288 Link header pointing at the adjacent pages
+func setPaginationLinks(c echo.Context, result models.PaginatedStocks, cursorMode bool) {
+	var links []string
+
+	link := func(rel string, set func(q url.Values)) {
+		u := *c.Request().URL
+		q := u.Query()
+		set(q)
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel))
+	}
+
+	if cursorMode {
+		if result.NextCursor != "" {
+			link("next", func(q url.Values) { q.Del("page"); q.Set("cursor", result.NextCursor) })
+		}
+		if result.PrevCursor != "" {
+			link("prev", func(q url.Values) { q.Del("page"); q.Set("cursor", result.PrevCursor) })
+		}
+	} else {
+		if result.Page < result.TotalPages {
+			link("next", func(q url.Values) { q.Set("page", strconv.Itoa(result.Page+1)) })
+		}
+		if result.Page > 1 {
+			link("prev", func(q url.Values) { q.Set("page", strconv.Itoa(result.Page-1)) })
+		}
+	}
+
+	if len(links) > 0 {
+		c.Response().Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
 // GetStockByTicker handles the API endpoint to retrieve a stock by ticker
 func (h *StockHandler) GetStockByTicker(c echo.Context) error {
 	ticker := c.Param("ticker")
 	if ticker == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Ticker parameter is required",
-		})
+		return apierrors.InvalidParameter("ticker", "Ticker parameter is required")
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
 	}
 
 	stocks, err := h.stockService.GetStocksByTicker(ticker)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve stock: " + err.Error(),
-		})
+		return apierrors.Wrap(err, "Failed to retrieve stock")
 	}
 
 	if len(stocks) == 0 {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "No stock found with ticker: " + ticker,
-		})
+		notFound := apierrors.NotFound("No stock found with ticker: " + ticker)
+
+		// Suggestions are best effort, the lookup already failed
+		suggestions, err := h.stockService.SearchStocks(ticker, 5)
+		if err == nil && len(suggestions) > 0 {
+			notFound.WithDetail("suggestions", suggestions)
+		}
+
+		return notFound
+	}
+
+	for i := range stocks {
+		stocks[i] = stocks[i].InLocation(loc)
 	}
 
 	return c.JSON(http.StatusOK, stocks)
 }
 
+// SearchStocks handles the API endpoint to search stocks by ticker prefix or company name
+func (h *StockHandler) SearchStocks(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return apierrors.InvalidParameter("q", "Query parameter q is required")
+	}
+
+	limit, err := parseIntParam(c.QueryParam("limit"), 10, 1, 50)
+	if err != nil {
+		return apierrors.InvalidParameter("limit", "Invalid limit parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	results, err := h.stockService.SearchStocks(query, limit)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to search stocks")
+	}
+
+	for i := range results {
+		results[i].Time = results[i].Time.In(loc)
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// GetTickerSummary handles the API endpoint to retrieve the analyst consensus on a ticker
+func (h *StockHandler) GetTickerSummary(c echo.Context) error {
+	ticker := c.Param("ticker")
+	if ticker == "" {
+		return apierrors.InvalidParameter("ticker", "Ticker parameter is required")
+	}
+
+	window, err := models.ParseWindow(c.QueryParam("window"))
+	if err != nil {
+		return apierrors.InvalidParameter("window", "Invalid window parameter: "+err.Error())
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	middleware.NowRelative(c)
+	summary, err := h.stockService.GetTickerSummary(ticker, window)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve stock summary")
+	}
+
+	if summary.TotalEvents == 0 {
+		return apierrors.NotFound("No rating events found for ticker " + summary.Ticker + " in the requested window")
+	}
+
+	return c.JSON(http.StatusOK, summary.InLocation(loc))
+}
+
+// GetTargetHistory handles the API endpoint to retrieve the price target history of a ticker
+func (h *StockHandler) GetTargetHistory(c echo.Context) error {
+	ticker := c.Param("ticker")
+	if ticker == "" {
+		return apierrors.InvalidParameter("ticker", "Ticker parameter is required")
+	}
+
+	interval := strings.ToLower(c.QueryParam("interval"))
+	switch interval {
+	case "":
+		interval = models.IntervalDay
+	case models.IntervalDay, models.IntervalWeek, models.IntervalMonth:
+	default:
+		return apierrors.InvalidParameter("interval", "Invalid interval parameter, expected day, week or month")
+	}
+
+	loc, err := models.LoadLocation(c.QueryParam("tz"))
+	if err != nil {
+		return apierrors.InvalidParameter("tz", "Invalid tz parameter: "+c.QueryParam("tz"))
+	}
+
+	since, until, err := parseTimeRange(c, loc)
+	if err != nil {
+		return apierrors.InvalidInput("Invalid time range: " + err.Error())
+	}
+
+	history, err := h.stockService.GetTargetHistory(ticker, since, until, interval, loc)
+	if err != nil {
+		return apierrors.Wrap(err, "Failed to retrieve target history")
+	}
+
+	if len(history.Brokerages) == 0 {
+		return apierrors.NotFound("No price targets found for ticker " + history.Ticker)
+	}
+
+	return c.JSON(http.StatusOK, history.InLocation(loc))
+}
+
 // RegisterRoutes registers the stock routes with the Echo router
 func (h *StockHandler) RegisterRoutes(e *echo.Group) {
 	e.GET("/stocks", h.GetAllStocks)
+	e.GET("/stocks/search", h.SearchStocks)
+	e.GET("/stocks/compare", h.CompareStocks)
+	e.GET("/stocks/export", h.ExportStocks)
+	e.POST("/stocks/lookup", h.LookupStocks)
+	e.GET("/stocks/live", h.StreamStocks)
+	e.GET("/stocks/live/ws", h.StreamStocksWebSocket)
 	e.GET("/stock/:ticker", h.GetStockByTicker)
+	e.GET("/stock/:ticker/summary", h.GetTickerSummary)
+	e.GET("/stock/:ticker/targets", h.GetTargetHistory)
+	e.GET("/sentiment", h.GetSentiment)
+	e.GET("/brokerages", h.GetBrokerages)
+	e.GET("/brokerages/:name/calls", h.GetBrokerageCalls)
+	e.GET("/ratings/unmapped", h.GetUnmappedRatings)
 	e.POST("/refresh-stocks", h.SyncStocks)
 }
+
+// RegisterAdminRoutes registers the stock admin routes with the admin group
+func (h *StockHandler) RegisterAdminRoutes(e *echo.Group) {
+	e.GET("/ratings/mappings", h.GetRatingMappings)
+	e.PUT("/ratings/mappings/:rating", h.MapRating)
+	e.DELETE("/ratings/mappings/:rating", h.UnmapRating)
+	e.PUT("/stock/:ticker/sector", h.SetTickerSector)
+}
@@ -0,0 +1,1047 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/cmd/cache"
+	"stonks-api/cmd/middleware"
+	"stonks-api/internal/stocks/handlers"
+	"stonks-api/internal/stocks/mocks"
+	"stonks-api/internal/stocks/models"
+	"stonks-api/internal/stocks/services"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestHandler(repo *mocks.MockRepository) *handlers.StockHandler {
+	return handlers.NewStockHandler(services.NewStockService(repo))
+}
+
+// serve runs handler and renders its error like the router does
+func serve(c echo.Context, handler echo.HandlerFunc) {
+	if err := handler(c); err != nil {
+		apierrors.HTTPErrorHandler(err, c)
+	}
+}
+
+func TestGetAllStocksFilters(t *testing.T) {
+	// Filters are parsed and passed to the repository
+	t.Run("valid filters", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet,
+			"/api/v1/stonks-api/stocks?ticker=aapl,msft&brokerage=Example%20Brokerage&rating_category=positive&rating=strong_buy,BUY"+
+				"&from=2025-01-01&to=2025-01-31&target_min=100&target_change_min=10&sort=target_to:desc&tz=America/New_York", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got models.PaginationParams
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				got = params
+				return models.PaginatedStocks{Stocks: []models.Stock{{Ticker: "AAPL", Brokerage: "Example Brokerage", RatingTo: "Strong-Buy"}}}, nil
+			},
+		}
+
+		if err := newTestHandler(repo).GetAllStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if !strings.Contains(rec.Body.String(), `"rating_to":"Strong-Buy"`) || !strings.Contains(rec.Body.String(), `"rating":"strong_buy"`) {
+			t.Errorf("Expected the raw and normalized ratings but got %s", rec.Body.String())
+		}
+
+		filter := got.Filter
+		if len(filter.Tickers) != 2 || filter.Tickers[0] != "AAPL" || filter.Tickers[1] != "MSFT" {
+			t.Errorf("Expected tickers [AAPL MSFT] but got %v", filter.Tickers)
+		}
+
+		if len(filter.Brokerages) != 1 || filter.Brokerages[0] != "Example Brokerage" {
+			t.Errorf("Expected brokerage filter but got %v", filter.Brokerages)
+		}
+
+		if filter.RatingCategory != models.RatingCategoryPositive {
+			t.Errorf("Expected Positive category but got %s", filter.RatingCategory)
+		}
+
+		if len(filter.Ratings) != 2 || filter.Ratings[0] != models.RatingStrongBuy || filter.Ratings[1] != models.RatingBuy {
+			t.Errorf("Expected strong buy and buy ratings but got %v", filter.Ratings)
+		}
+
+		// Dates are evaluated in the caller's time zone, "to" covers the whole day
+		wantFrom := time.Date(2025, 1, 1, 5, 0, 0, 0, time.UTC)
+		wantTo := time.Date(2025, 2, 1, 5, 0, 0, 0, time.UTC)
+		if filter.From == nil || !filter.From.Equal(wantFrom) {
+			t.Errorf("Expected from %v but got %v", wantFrom, filter.From)
+		}
+		if filter.To == nil || !filter.To.Equal(wantTo) {
+			t.Errorf("Expected to %v but got %v", wantTo, filter.To)
+		}
+
+		if filter.TargetMin == nil || *filter.TargetMin != 100 {
+			t.Errorf("Expected target_min 100 but got %v", filter.TargetMin)
+		}
+
+		if filter.TargetChangeMin == nil || *filter.TargetChangeMin != 10 {
+			t.Errorf("Expected target_change_min 10 but got %v", filter.TargetChangeMin)
+		}
+
+		if filter.SortField != models.SortByTargetTo || !filter.SortDesc {
+			t.Errorf("Expected sort target_to desc but got %s desc=%v", filter.SortField, filter.SortDesc)
+		}
+	})
+
+	// Invalid filters are rejected
+	invalid := map[string]string{
+		"unknown sort field": "sort=password",
+		"bad sort direction": "sort=time:sideways",
+		"bad category":       "rating_category=great",
+		"bad rating":         "rating=great",
+		"bad date":           "from=yesterday",
+		"bad number":         "target_min=lots",
+		"bad page":           "page=abc",
+		"page below one":     "page=0",
+		"page size too big":  "page_size=500",
+		"local time zone":    "tz=Local",
+	}
+
+	for name, query := range invalid {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?"+query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			serve(c, newTestHandler(&mocks.MockRepository{}).GetAllStocks)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetAllStocksPagination(t *testing.T) {
+	// Link header and cursors in keyset mode
+	t.Run("cursor links", func(t *testing.T) {
+		cursor := models.Cursor{Time: time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), ID: "abc"}
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?page_size=2&cursor="+cursor.Encode(), nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got models.PaginationParams
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				got = params
+				return models.PaginatedStocks{NextCursor: "next-token", PrevCursor: "prev-token"}, nil
+			},
+		}
+
+		if err := newTestHandler(repo).GetAllStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got.Cursor == nil || got.Cursor.ID != "abc" {
+			t.Errorf("Expected decoded cursor but got %+v", got.Cursor)
+		}
+
+		link := rec.Header().Get("Link")
+		want := `</api/v1/stonks-api/stocks?cursor=next-token&page_size=2>; rel="next", </api/v1/stonks-api/stocks?cursor=prev-token&page_size=2>; rel="prev"`
+		if link != want {
+			t.Errorf("Expected Link header %s but got %s", want, link)
+		}
+	})
+
+	// Page links in offset mode
+	t.Run("page links", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?page=2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				return models.PaginatedStocks{Page: 2, TotalPages: 3}, nil
+			},
+		}
+
+		if err := newTestHandler(repo).GetAllStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		want := `</api/v1/stonks-api/stocks?page=3>; rel="next", </api/v1/stonks-api/stocks?page=1>; rel="prev"`
+		if link := rec.Header().Get("Link"); link != want {
+			t.Errorf("Expected Link header %s but got %s", want, link)
+		}
+	})
+
+	// Malformed cursors are rejected
+	t.Run("invalid cursor", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?cursor=not-a-cursor", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetAllStocks)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestErrorResponses(t *testing.T) {
+	// Unknown tickers are not found, with suggestions in the details
+	t.Run("ticker not found", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stock/APL", nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("ticker")
+		c.SetParamValues("APL")
+
+		repo := &mocks.MockRepository{
+			SearchStocksFn: func(query string, limit int) ([]models.StockSearchResult, error) {
+				return []models.StockSearchResult{{Ticker: "AAPL"}}, nil
+			},
+		}
+
+		serve(c, newTestHandler(repo).GetStockByTicker)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("Expected status code %d but got %d", http.StatusNotFound, rec.Code)
+		}
+
+		var response struct {
+			Error struct {
+				Code      string `json:"code"`
+				RequestID string `json:"request_id"`
+				Details   struct {
+					Suggestions []models.StockSearchResult `json:"suggestions"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected an error envelope, but got %v", err)
+		}
+
+		if response.Error.Code != string(apierrors.CodeNotFound) {
+			t.Errorf("Expected code not_found but got %s", response.Error.Code)
+		}
+
+		if response.Error.RequestID != "req-1" {
+			t.Errorf("Expected request ID req-1 but got %s", response.Error.RequestID)
+		}
+
+		if len(response.Error.Details.Suggestions) != 1 || response.Error.Details.Suggestions[0].Ticker != "AAPL" {
+			t.Errorf("Expected AAPL to be suggested but got %v", response.Error.Details.Suggestions)
+		}
+	})
+
+	// Invalid parameters name the parameter
+	t.Run("invalid parameter", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?page_size=0", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetAllStocks)
+
+		var response apierrors.Envelope
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected an error envelope, but got %v", err)
+		}
+
+		if rec.Code != http.StatusBadRequest || response.Error.Code != apierrors.CodeInvalidInput {
+			t.Errorf("Expected a 400 invalid_input error but got %d %s", rec.Code, response.Error.Code)
+		}
+
+		if response.Error.Details["parameter"] != "page_size" {
+			t.Errorf("Expected the page_size parameter in the details but got %v", response.Error.Details)
+		}
+	})
+
+	// Echo errors keep their status, with a code derived from it
+	t.Run("echo errors", func(t *testing.T) {
+		e := echo.New()
+		e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+		newTestHandler(&mocks.MockRepository{}).RegisterRoutes(e.Group("/api/v1/stonks-api"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/stonks-api/stocks", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed || !strings.Contains(rec.Body.String(), `"code":"method_not_allowed"`) {
+			t.Errorf("Expected a 405 method_not_allowed error but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		apierrors.HTTPErrorHandler(echo.ErrUnsupportedMediaType, e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec))
+
+		if rec.Code != http.StatusUnsupportedMediaType || !strings.Contains(rec.Body.String(), `"code":"unsupported_media_type"`) {
+			t.Errorf("Expected a 415 unsupported_media_type error but got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	// Database errors are reported without their cause
+	t.Run("repository error", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		repo := &mocks.MockRepository{
+			GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+				return models.PaginatedStocks{}, errors.New("pq: relation \"stocks\" does not exist")
+			},
+		}
+
+		serve(c, newTestHandler(repo).GetAllStocks)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d but got %d", http.StatusInternalServerError, rec.Code)
+		}
+
+		if strings.Contains(rec.Body.String(), "pq:") {
+			t.Errorf("Expected the database error to stay out of the response but got %s", rec.Body.String())
+		}
+	})
+}
+
+func TestGetTickerSummary(t *testing.T) {
+	// Window is passed on and an empty summary is a 404
+	t.Run("no events", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stock/aapl/summary?window=4w", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("ticker")
+		c.SetParamValues("aapl")
+
+		var gotTicker string
+		var gotWindow time.Duration
+		repo := &mocks.MockRepository{
+			GetTickerSummaryFn: func(ticker string, since, until time.Time) (models.TickerSummary, error) {
+				gotTicker, gotWindow = ticker, until.Sub(since)
+				return models.TickerSummary{Ticker: ticker}, nil
+			},
+		}
+
+		serve(c, newTestHandler(repo).GetTickerSummary)
+
+		if gotTicker != "AAPL" || gotWindow != 28*24*time.Hour {
+			t.Errorf("Expected AAPL over 28 days but got %s over %v", gotTicker, gotWindow)
+		}
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	// Invalid window
+	t.Run("invalid window", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stock/AAPL/summary?window=forever", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("ticker")
+		c.SetParamValues("AAPL")
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetTickerSummary)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	// Windows whose length in days overflows are rejected, not wrapped around
+	t.Run("overflowing window", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stock/AAPL/summary?window=7905747460161236407w", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("ticker")
+		c.SetParamValues("AAPL")
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetTickerSummary)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+// Summaries over a window ending now change without a write, so they must
+// never be replayed from the read cache or validated by the data version
+func TestGetTickerSummaryNotCached(t *testing.T) {
+	calls := 0
+	repo := &mocks.MockRepository{
+		GetTickerSummaryFn: func(ticker string, since, until time.Time) (models.TickerSummary, error) {
+			calls++
+			return models.TickerSummary{Ticker: ticker, TotalEvents: calls}, nil
+		},
+	}
+
+	e := echo.New()
+	api := e.Group("", middleware.HTTPCache(cache.NewReadCache(cache.NewMemoryBackend(10), time.Minute)))
+	api.GET("/stock/:ticker/summary", newTestHandler(repo).GetTickerSummary)
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stock/AAPL/summary", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("", "")
+	second := get(echo.HeaderIfModifiedSince, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+	if calls != 2 {
+		t.Errorf("Expected the summary to be computed twice but got %d", calls)
+	}
+	if cached := second.Header().Get("X-Cache"); cached != "MISS" {
+		t.Errorf("Expected X-Cache MISS but got %q", cached)
+	}
+	if second.Code != http.StatusOK || second.Body.String() == first.Body.String() {
+		t.Errorf("Expected a fresh summary but got %d %s", second.Code, second.Body.String())
+	}
+	if lastModified := first.Header().Get(echo.HeaderLastModified); lastModified != "" {
+		t.Errorf("Expected no Last-Modified but got %q", lastModified)
+	}
+
+	// The ETag still validates the body it was computed from
+	third := get("If-None-Match", second.Header().Get("ETag"))
+	if third.Code != http.StatusOK {
+		t.Errorf("Expected status code %d after the summary changed but got %d", http.StatusOK, third.Code)
+	}
+}
+
+func TestGetBrokerageCalls(t *testing.T) {
+	// Calls are scoped to the brokerage in the path, unescaped exactly once
+	for path, expected := range map[string]string{
+		"Example%20Brokerage":  "Example Brokerage",
+		"100%25%20Capital":     "100% Capital",
+		"Smith%2FJones%20Bank": "Smith/Jones Bank",
+	} {
+		t.Run(path, func(t *testing.T) {
+			var got models.StockFilter
+			repo := &mocks.MockRepository{
+				GetAllStocksFn: func(params models.PaginationParams) (models.PaginatedStocks, error) {
+					got = params.Filter
+					return models.PaginatedStocks{}, nil
+				},
+			}
+
+			e := echo.New()
+			e.GET("/brokerages/:name/calls", newTestHandler(repo).GetBrokerageCalls)
+			req := httptest.NewRequest(http.MethodGet, "/brokerages/"+path+"/calls?brokerage=Other&ticker=AAPL", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+			}
+
+			if len(got.Brokerages) != 1 || got.Brokerages[0] != expected {
+				t.Errorf("Expected brokerage filter [%s] but got %v", expected, got.Brokerages)
+			}
+
+			if len(got.Tickers) != 1 || got.Tickers[0] != "AAPL" {
+				t.Errorf("Expected other filters to be kept but got %v", got.Tickers)
+			}
+		})
+	}
+}
+
+func TestGetBrokerages(t *testing.T) {
+	// Unknown sort fields are rejected
+	t.Run("invalid sort", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/brokerages?sort=name", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetBrokerages)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestGetSentiment(t *testing.T) {
+	// Invalid interval
+	t.Run("invalid interval", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/sentiment?interval=hour", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).GetSentiment)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	// Filters and range are passed to the repository
+	t.Run("filters", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet,
+			"/api/v1/stonks-api/sentiment?interval=month&from=2025-01-01&to=2025-03-31&ticker=aapl&sector=Technology", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var got models.SentimentQuery
+		repo := &mocks.MockRepository{
+			GetSentimentFn: func(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+				got = query
+				return nil, nil
+			},
+		}
+
+		if err := newTestHandler(repo).GetSentiment(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if got.Interval != models.IntervalMonth || got.Sector != "Technology" || got.Tickers[0] != "AAPL" {
+			t.Errorf("Unexpected query: %+v", got)
+		}
+
+		if !got.Until.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected range to end on Apr 1 but got %v", got.Until)
+		}
+	})
+}
+
+func TestLookupStocks(t *testing.T) {
+	// Tickers from the body are looked up and missing ones reported
+	t.Run("valid body", func(t *testing.T) {
+		e := echo.New()
+		body := `{"tickers": ["aapl", "zzzz"], "events": 2, "window": "30d"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/stonks-api/stocks/lookup", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var gotPerTicker int
+		repo := &mocks.MockRepository{
+			GetLatestForTickersFn: func(tickers []string, perTicker int) ([]models.Stock, error) {
+				gotPerTicker = perTicker
+				return []models.Stock{{Ticker: "AAPL", Company: "Apple Inc."}}, nil
+			},
+		}
+
+		if err := newTestHandler(repo).LookupStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if gotPerTicker != 2 {
+			t.Errorf("Expected 2 events per ticker but got %d", gotPerTicker)
+		}
+
+		if !strings.Contains(rec.Body.String(), `"not_found":["ZZZZ"]`) {
+			t.Errorf("Expected ZZZZ to be reported as not found but got %s", rec.Body.String())
+		}
+	})
+
+	// Too many tickers
+	t.Run("too many tickers", func(t *testing.T) {
+		tickers := make([]string, 501)
+		for i := range tickers {
+			tickers[i] = `"T` + strconv.Itoa(i) + `"`
+		}
+		e := echo.New()
+		body := `{"tickers": [` + strings.Join(tickers, ",") + `]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/stonks-api/stocks/lookup", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).LookupStocks)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestCompareStocks(t *testing.T) {
+	// A single ticker cannot be compared
+	t.Run("single ticker", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/compare?ticker=AAPL", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).CompareStocks)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	// Recent actions count is passed on
+	t.Run("valid tickers", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/compare?ticker=AAPL,MSFT&actions=4", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var gotPerTicker int
+		repo := &mocks.MockRepository{
+			GetLatestForTickersFn: func(tickers []string, perTicker int) ([]models.Stock, error) {
+				gotPerTicker = perTicker
+				return []models.Stock{}, nil
+			},
+		}
+
+		if err := newTestHandler(repo).CompareStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status code %d but got %d", http.StatusOK, rec.Code)
+		}
+
+		if gotPerTicker != 4 {
+			t.Errorf("Expected 4 recent actions but got %d", gotPerTicker)
+		}
+	})
+}
+
+func TestExportStocks(t *testing.T) {
+	stocks := []models.Stock{
+		{ID: "1", Ticker: "AAPL", Company: "Apple, Inc.", Brokerage: "Example Brokerage", Action: models.ActionUpgraded,
+			RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 150, TargetTo: 200, Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	streamRepo := func() *mocks.MockRepository {
+		return &mocks.MockRepository{
+			StreamStocksFn: func(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+				return fn(stocks)
+			},
+		}
+	}
+
+	// CSV with a header row and a download filename
+	t.Run("csv", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/export?format=csv", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := newTestHandler(streamRepo()).ExportStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		expected := "id,ticker,company,brokerage,action,rating_from,rating_to,target_from,target_to,time\n" +
+			"1,AAPL,\"Apple, Inc.\",Example Brokerage,upgraded by,Hold,Buy,150.00,200.00,2025-01-01T00:00:00Z\n"
+		if rec.Body.String() != expected {
+			t.Errorf("Expected CSV %q but got %q", expected, rec.Body.String())
+		}
+
+		if !strings.HasPrefix(rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename="stocks-`) {
+			t.Errorf("Expected a download filename but got %q", rec.Header().Get(echo.HeaderContentDisposition))
+		}
+	})
+
+	// Text cells that a spreadsheet would run as formulas are quoted
+	t.Run("csv formulas", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/export?format=csv", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		repo := &mocks.MockRepository{
+			StreamStocksFn: func(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+				return fn([]models.Stock{{ID: "1", Ticker: "+AAPL", Company: "=HYPERLINK(\"http://example.com\")", Brokerage: "@Broker",
+					Action: "-down", RatingFrom: "Hold", RatingTo: "Buy", TargetFrom: 150, TargetTo: 200, Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}})
+			},
+		}
+
+		if err := newTestHandler(repo).ExportStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		expected := "1,'+AAPL,\"'=HYPERLINK(\"\"http://example.com\"\")\",'@Broker,'-down,Hold,Buy,150.00,200.00,2025-01-01T00:00:00Z\n"
+		if _, row, _ := strings.Cut(rec.Body.String(), "\n"); row != expected {
+			t.Errorf("Expected CSV row %q but got %q", expected, row)
+		}
+	})
+
+	// Accept negotiation on the listing streams NDJSON
+	t.Run("ndjson via accept", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks?ticker=aapl", nil)
+		req.Header.Set(echo.HeaderAccept, "application/json;q=0.5, application/x-ndjson")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var gotFilter models.StockFilter
+		repo := streamRepo()
+		repo.StreamStocksFn = func(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+			gotFilter = filter
+			return fn(stocks)
+		}
+
+		if err := newTestHandler(repo).GetAllStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if rec.Header().Get(echo.HeaderContentType) != "application/x-ndjson" {
+			t.Errorf("Expected NDJSON content type but got %q", rec.Header().Get(echo.HeaderContentType))
+		}
+
+		expected := `{"id":"1","ticker":"AAPL","company":"Apple, Inc.","brokerage":"Example Brokerage","action":"upgraded by",` +
+			`"rating_from":"Hold","rating_to":"Buy","target_from":150,"target_to":200,"time":"2025-01-01T00:00:00Z"}` + "\n"
+		if rec.Body.String() != expected {
+			t.Errorf("Expected NDJSON %q but got %q", expected, rec.Body.String())
+		}
+
+		if len(gotFilter.Tickers) != 1 || gotFilter.Tickers[0] != "AAPL" {
+			t.Errorf("Expected ticker filter AAPL but got %v", gotFilter.Tickers)
+		}
+	})
+
+	// XLSX is a readable zip with the worksheet
+	t.Run("xlsx", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/export?format=xlsx", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := newTestHandler(streamRepo()).ExportStocks(c); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("Expected a zip archive, but got %v", err)
+		}
+
+		var sheet string
+		for _, f := range archive.File {
+			if f.Name == "xl/worksheets/sheet1.xml" {
+				r, _ := f.Open()
+				content, _ := io.ReadAll(r)
+				sheet = string(content)
+			}
+		}
+
+		if !strings.Contains(sheet, "<t>Apple, Inc.</t>") || !strings.Contains(sheet, "<v>200.00</v>") {
+			t.Errorf("Expected worksheet with the stock row but got %q", sheet)
+		}
+	})
+
+	// Unknown format
+	t.Run("invalid format", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stonks-api/stocks/export?format=pdf", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		serve(c, newTestHandler(&mocks.MockRepository{}).ExportStocks)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+// newLiveServer serves the stock routes with a feed that returns stock on its
+// first poll when filtered by its ticker, then stays idle. The returned
+// function reports the position of the first poll.
+func newLiveServer(t *testing.T, stock models.Stock) (*httptest.Server, func() models.Cursor) {
+	var mu sync.Mutex
+	var positions []models.Cursor
+	repo := &mocks.MockRepository{
+		GetStocksChangedAfterFn: func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			positions = append(positions, after)
+			if len(positions) == 1 && len(filter.Tickers) == 1 && filter.Tickers[0] == stock.Ticker {
+				return []models.Stock{stock}, nil
+			}
+			return []models.Stock{}, nil
+		},
+	}
+
+	service := services.NewStockService(repo)
+	service.SetFeedPollInterval(time.Millisecond)
+	handler := handlers.NewStockHandler(service)
+	handler.SetHeartbeatInterval(10 * time.Millisecond)
+
+	e := echo.New()
+	e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+	handler.RegisterRoutes(e.Group(""))
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	return server, func() models.Cursor {
+		mu.Lock()
+		defer mu.Unlock()
+		return positions[0]
+	}
+}
+
+func TestStreamStocks(t *testing.T) {
+	updated := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	stock := models.Stock{ID: "2", Ticker: "AAPL", Brokerage: "Example Brokerage", UpdatedAt: updated}
+	resume := models.Cursor{Time: updated.Add(-time.Hour), ID: "1"}
+
+	// Stock events resume after the last event ID and heartbeats follow
+	t.Run("events and heartbeat", func(t *testing.T) {
+		server, firstPosition := newLiveServer(t, stock)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/stocks/live?ticker=aapl", nil)
+		req.Header.Set("Last-Event-ID", resume.Encode())
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		defer res.Body.Close()
+
+		if ct := res.Header.Get(echo.HeaderContentType); ct != "text/event-stream" {
+			t.Fatalf("Expected an event stream but got %q", ct)
+		}
+
+		var events []string
+		var ids []string
+		var event models.Stock
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() && len(events) < 2 {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ids = append(ids, strings.TrimPrefix(line, "id: "))
+			case strings.HasPrefix(line, "event: "):
+				events = append(events, strings.TrimPrefix(line, "event: "))
+			case strings.HasPrefix(line, "data: ") && len(events) == 1:
+				var message struct {
+					Stock models.Stock `json:"stock"`
+				}
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &message)
+				event = message.Stock
+			}
+		}
+
+		if len(events) != 2 || events[0] != "stock" || events[1] != "heartbeat" {
+			t.Fatalf("Expected a stock event then a heartbeat but got %v", events)
+		}
+
+		if event.ID != "2" || event.Ticker != "AAPL" {
+			t.Errorf("Expected stock 2 in the event but got %+v", event)
+		}
+
+		// The event ID is the position after the stock
+		want := models.Cursor{Time: updated, ID: "2"}.Encode()
+		if len(ids) != 1 || ids[0] != want {
+			t.Errorf("Expected event ID %s but got %v", want, ids)
+		}
+
+		if first := firstPosition(); first.ID != "1" || !first.Time.Equal(resume.Time) {
+			t.Errorf("Expected the feed to resume after stock 1 but got %+v", first)
+		}
+	})
+
+	// An undecodable last event ID is rejected before streaming
+	t.Run("invalid last event ID", func(t *testing.T) {
+		server, _ := newLiveServer(t, stock)
+
+		res, err := http.Get(server.URL + "/stocks/live?last_event_id=garbage")
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, res.StatusCode)
+		}
+	})
+}
+
+func TestStreamStocksWebSocket(t *testing.T) {
+	updated := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	stock := models.Stock{ID: "2", Ticker: "AAPL", UpdatedAt: updated}
+	server, _ := newLiveServer(t, stock)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/stocks/live/ws?ticker=AAPL&tz=America/New_York"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	type liveMessage struct {
+		Type  string        `json:"type"`
+		ID    string        `json:"id"`
+		Stock *models.Stock `json:"stock"`
+	}
+
+	var messages []liveMessage
+	for len(messages) < 2 {
+		var message liveMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if messages[0].Type != "stock" || messages[0].Stock == nil || messages[0].Stock.ID != "2" {
+		t.Fatalf("Expected a stock message first but got %+v", messages[0])
+	}
+
+	if messages[0].ID != (models.Cursor{Time: updated, ID: "2"}).Encode() {
+		t.Errorf("Expected the stock message to carry its position but got %s", messages[0].ID)
+	}
+
+	// Timestamps follow the requested time zone
+	if _, offset := messages[0].Stock.UpdatedAt.Zone(); offset != -5*60*60 {
+		t.Errorf("Expected New York time but got %v", messages[0].Stock.UpdatedAt)
+	}
+
+	if messages[1].Type != "heartbeat" {
+		t.Errorf("Expected a heartbeat but got %+v", messages[1])
+	}
+}
+
+func TestRatingEndpoints(t *testing.T) {
+	defer models.SetRatingLadder(models.DefaultRatingLadder())
+
+	serveRoute := func(ratingRepo *mocks.MockRatingRepository, method, target, body string) *httptest.ResponseRecorder {
+		service := services.NewStockService(&mocks.MockRepository{})
+		service.SetRatingRepository(ratingRepo)
+
+		e := echo.New()
+		e.HTTPErrorHandler = apierrors.HTTPErrorHandler
+		handler := handlers.NewStockHandler(service)
+		handler.RegisterRoutes(e.Group("/api/v1/stonks-api"))
+		handler.RegisterAdminRoutes(e.Group("/api/v1/stonks-api/admin"))
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Unmapped ratings are listed, most used first
+	t.Run("list unmapped", func(t *testing.T) {
+		var gotLimit int
+		repo := &mocks.MockRatingRepository{
+			GetUnmappedRatingsFn: func(limit int) ([]models.UnmappedRating, error) {
+				gotLimit = limit
+				return []models.UnmappedRating{{Rating: "Speculative Buy", Occurrences: 12}}, nil
+			},
+		}
+
+		rec := serveRoute(repo, http.MethodGet, "/api/v1/stonks-api/ratings/unmapped?limit=10", "")
+
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"occurrences":12`) {
+			t.Errorf("Expected the unmapped ratings but got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if gotLimit != 10 {
+			t.Errorf("Expected limit 10 but got %d", gotLimit)
+		}
+	})
+
+	// A rating from the path is mapped to the level of the body
+	t.Run("map rating", func(t *testing.T) {
+		var saved models.RatingMapping
+		repo := &mocks.MockRatingRepository{
+			SaveRatingMappingFn: func(mapping models.RatingMapping) error {
+				saved = mapping
+				return nil
+			},
+		}
+
+		rec := serveRoute(repo, http.MethodPut, "/api/v1/stonks-api/admin/ratings/mappings/Speculative%20Buy", `{"level": "buy"}`)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d but got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if saved.Rating != "Speculative Buy" || saved.Level != models.RatingBuy {
+			t.Errorf("Expected Speculative Buy mapped to buy but got %+v", saved)
+		}
+
+		if !strings.Contains(rec.Body.String(), `"level":"buy"`) || !strings.Contains(rec.Body.String(), `"category":"Positive"`) {
+			t.Errorf("Expected the mapping with its category but got %s", rec.Body.String())
+		}
+	})
+
+	// Invalid levels and limits are rejected
+	invalid := map[string]struct{ method, target, body string }{
+		"bad level":     {http.MethodPut, "/api/v1/stonks-api/admin/ratings/mappings/Accumulate", `{"level": "great"}`},
+		"missing level": {http.MethodPut, "/api/v1/stonks-api/admin/ratings/mappings/Accumulate", `{}`},
+		"bad limit":     {http.MethodGet, "/api/v1/stonks-api/ratings/unmapped?limit=0", ""},
+	}
+	for name, request := range invalid {
+		t.Run(name, func(t *testing.T) {
+			rec := serveRoute(&mocks.MockRatingRepository{}, request.method, request.target, request.body)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d but got %d", http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+
+	// Removing a mapping that does not exist is not found
+	t.Run("unmap missing", func(t *testing.T) {
+		rec := serveRoute(&mocks.MockRatingRepository{}, http.MethodDelete, "/api/v1/stonks-api/admin/ratings/mappings/Accumulate", "")
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	// Removing a mapping returns no content
+	t.Run("unmap", func(t *testing.T) {
+		repo := &mocks.MockRatingRepository{
+			GetRatingMappingsFn: func() ([]models.RatingMapping, error) {
+				return []models.RatingMapping{{Rating: "Accumulate", Level: models.RatingBuy}}, nil
+			},
+		}
+
+		rec := serveRoute(repo, http.MethodDelete, "/api/v1/stonks-api/admin/ratings/mappings/Accumulate", "")
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("Expected status code %d but got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+		}
+	})
+}
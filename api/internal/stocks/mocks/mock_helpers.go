@@ -14,6 +14,9 @@ func CreateMockQueryChain(stocks []models.Stock) database.Query {
 			*stocksPtr = stocks
 			return nil
 		},
+		CountFn: func() (int64, error) {
+			return int64(len(stocks)), nil
+		},
 		OrderFn: func(value interface{}) database.Query {
 			return &database.MockQuery{
 				FindFn: func(dest interface{}, conditions ...interface{}) error {
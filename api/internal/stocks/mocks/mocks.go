@@ -3,30 +3,182 @@ package mocks
 import (
 	"net/http"
 	"stonks-api/internal/stocks/models"
+	"time"
 )
 
-// MockRepository is a simplified mock implementation of the Repository interface
+// MockRepository implements the services.StockRepository interface for testing
 type MockRepository struct {
-	StockByTicker *models.Stock
-	Stocks        []models.Stock
-	PaginatedData models.PaginatedStocks
-	ErrorToReturn error
+	SaveStocksFn            func(stocks []models.Stock) error
+	GetAllStocksFn          func(params models.PaginationParams) (models.PaginatedStocks, error)
+	GetStocksByTickerFn     func(ticker string) ([]models.Stock, error)
+	GetRecentStocksFn       func(filter models.StockFilter, limit int) ([]models.Stock, error)
+	SearchStocksFn          func(query string, limit int) ([]models.StockSearchResult, error)
+	GetTickerSummaryFn      func(ticker string, since, until time.Time) (models.TickerSummary, error)
+	GetBrokerageStatsFn     func(query models.BrokerageQuery) ([]models.BrokerageStats, error)
+	GetSentimentFn          func(query models.SentimentQuery) ([]models.SentimentBucket, error)
+	SetTickerSectorFn       func(ticker, sector string) error
+	GetTargetEventsFn       func(ticker string, since, until time.Time) ([]models.Stock, error)
+	GetTickerSummariesFn    func(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error)
+	GetLatestForTickersFn   func(tickers []string, perTicker int) ([]models.Stock, error)
+	StreamStocksFn          func(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error
+	GetStocksChangedAfterFn func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error)
 }
 
 func (m *MockRepository) SaveStocks(stocks []models.Stock) error {
-	return m.ErrorToReturn
+	if m.SaveStocksFn != nil {
+		return m.SaveStocksFn(stocks)
+	}
+	return nil
 }
 
-func (m *MockRepository) GetAllStocks(page, pageSize int) (models.PaginatedStocks, error) {
-	return m.PaginatedData, m.ErrorToReturn
+func (m *MockRepository) GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error) {
+	if m.GetAllStocksFn != nil {
+		return m.GetAllStocksFn(params)
+	}
+	return models.PaginatedStocks{}, nil
 }
 
-func (m *MockRepository) GetStockByTicker(ticker string) (*models.Stock, error) {
-	return m.StockByTicker, m.ErrorToReturn
+func (m *MockRepository) GetStocksByTicker(ticker string) ([]models.Stock, error) {
+	if m.GetStocksByTickerFn != nil {
+		return m.GetStocksByTickerFn(ticker)
+	}
+	return []models.Stock{}, nil
 }
 
-func (m *MockRepository) GetRecentStocks(limit int) ([]models.Stock, error) {
-	return m.Stocks, m.ErrorToReturn
+func (m *MockRepository) GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error) {
+	if m.GetRecentStocksFn != nil {
+		return m.GetRecentStocksFn(filter, limit)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) SearchStocks(query string, limit int) ([]models.StockSearchResult, error) {
+	if m.SearchStocksFn != nil {
+		return m.SearchStocksFn(query, limit)
+	}
+	return []models.StockSearchResult{}, nil
+}
+
+func (m *MockRepository) GetTickerSummary(ticker string, since, until time.Time) (models.TickerSummary, error) {
+	if m.GetTickerSummaryFn != nil {
+		return m.GetTickerSummaryFn(ticker, since, until)
+	}
+	return models.TickerSummary{Ticker: ticker}, nil
+}
+
+func (m *MockRepository) GetBrokerageStats(query models.BrokerageQuery) ([]models.BrokerageStats, error) {
+	if m.GetBrokerageStatsFn != nil {
+		return m.GetBrokerageStatsFn(query)
+	}
+	return []models.BrokerageStats{}, nil
+}
+
+func (m *MockRepository) GetSentimentSeries(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+	if m.GetSentimentFn != nil {
+		return m.GetSentimentFn(query)
+	}
+	return []models.SentimentBucket{}, nil
+}
+
+func (m *MockRepository) SetTickerSector(ticker, sector string) error {
+	if m.SetTickerSectorFn != nil {
+		return m.SetTickerSectorFn(ticker, sector)
+	}
+	return nil
+}
+
+func (m *MockRepository) GetTargetEvents(ticker string, since, until time.Time) ([]models.Stock, error) {
+	if m.GetTargetEventsFn != nil {
+		return m.GetTargetEventsFn(ticker, since, until)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) GetTickerSummaries(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error) {
+	if m.GetTickerSummariesFn != nil {
+		return m.GetTickerSummariesFn(tickers, since, until)
+	}
+	return map[string]models.TickerSummary{}, nil
+}
+
+func (m *MockRepository) GetLatestStocksForTickers(tickers []string, perTicker int) ([]models.Stock, error) {
+	if m.GetLatestForTickersFn != nil {
+		return m.GetLatestForTickersFn(tickers, perTicker)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) StreamStocks(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+	if m.StreamStocksFn != nil {
+		return m.StreamStocksFn(filter, batchSize, fn)
+	}
+	return nil
+}
+
+func (m *MockRepository) GetStocksChangedAfter(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+	if m.GetStocksChangedAfterFn != nil {
+		return m.GetStocksChangedAfterFn(after, filter, limit)
+	}
+	return []models.Stock{}, nil
+}
+
+// MockRatingRepository implements the services.RatingRepository interface for testing
+type MockRatingRepository struct {
+	GetRatingMappingsFn      func() ([]models.RatingMapping, error)
+	SaveRatingMappingFn      func(mapping models.RatingMapping) error
+	DeleteRatingMappingFn    func(rating string) error
+	InvalidateRatingsFn      func()
+	RefreshUnmappedRatingsFn func() error
+	RecordUnmappedRatingsFn  func(ratings []string) error
+	GetUnmappedRatingsFn     func(limit int) ([]models.UnmappedRating, error)
+}
+
+func (m *MockRatingRepository) GetRatingMappings() ([]models.RatingMapping, error) {
+	if m.GetRatingMappingsFn != nil {
+		return m.GetRatingMappingsFn()
+	}
+	return nil, nil
+}
+
+func (m *MockRatingRepository) SaveRatingMapping(mapping models.RatingMapping) error {
+	if m.SaveRatingMappingFn != nil {
+		return m.SaveRatingMappingFn(mapping)
+	}
+	return nil
+}
+
+func (m *MockRatingRepository) DeleteRatingMapping(rating string) error {
+	if m.DeleteRatingMappingFn != nil {
+		return m.DeleteRatingMappingFn(rating)
+	}
+	return nil
+}
+
+func (m *MockRatingRepository) InvalidateRatings() {
+	if m.InvalidateRatingsFn != nil {
+		m.InvalidateRatingsFn()
+	}
+}
+
+func (m *MockRatingRepository) RefreshUnmappedRatings() error {
+	if m.RefreshUnmappedRatingsFn != nil {
+		return m.RefreshUnmappedRatingsFn()
+	}
+	return nil
+}
+
+func (m *MockRatingRepository) RecordUnmappedRatings(ratings []string) error {
+	if m.RecordUnmappedRatingsFn != nil {
+		return m.RecordUnmappedRatingsFn(ratings)
+	}
+	return nil
+}
+
+func (m *MockRatingRepository) GetUnmappedRatings(limit int) ([]models.UnmappedRating, error) {
+	if m.GetUnmappedRatingsFn != nil {
+		return m.GetUnmappedRatingsFn(limit)
+	}
+	return nil, nil
 }
 
 type MockHTTPClient struct {
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Sortable fields for the brokerage leaderboard
+const (
+	BrokerageSortByCalls        = "calls"
+	BrokerageSortByUpgrades     = "upgrades"
+	BrokerageSortByDowngrades   = "downgrades"
+	BrokerageSortByRatio        = "upgrade_downgrade_ratio"
+	BrokerageSortByTargetChange = "avg_target_change"
+	BrokerageSortByTickers      = "tickers_covered"
+	BrokerageSortByLastActivity = "last_activity"
+)
+
+// BrokerageSortFields lists the fields the brokerage leaderboard can be sorted by
+var BrokerageSortFields = []string{
+	BrokerageSortByCalls,
+	BrokerageSortByUpgrades,
+	BrokerageSortByDowngrades,
+	BrokerageSortByRatio,
+	BrokerageSortByTargetChange,
+	BrokerageSortByTickers,
+	BrokerageSortByLastActivity,
+}
+
+// BrokerageStats represents the activity of a brokerage over a time window
+type BrokerageStats struct {
+	Brokerage             string    `json:"brokerage"`
+	Calls                 int       `json:"calls"`
+	Upgrades              int       `json:"upgrades"`
+	Downgrades            int       `json:"downgrades"`
+	UpgradeDowngradeRatio *float64  `json:"upgrade_downgrade_ratio"`
+	AvgTargetChange       float64   `json:"avg_target_change"`
+	TickersCovered        int       `json:"tickers_covered"`
+	LastActivity          time.Time `json:"last_activity"`
+}
+
+// BrokerageQuery selects and orders the brokerage leaderboard
+type BrokerageQuery struct {
+	Since     time.Time
+	Until     time.Time
+	SortField string
+	SortAsc   bool
+	Limit     int
+}
+
+// BrokerageLeaderboard represents the ranked brokerages for a time window
+type BrokerageLeaderboard struct {
+	Brokerages  []BrokerageStats `json:"brokerages"`
+	WindowStart time.Time        `json:"window_start"`
+	WindowEnd   time.Time        `json:"window_end"`
+}
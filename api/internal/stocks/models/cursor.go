@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Cursor marks a position in the (time, id) ordering of stock listings
+type Cursor struct {
+	Time     time.Time `json:"t"`
+	ID       string    `json:"id"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// NewCursor creates a cursor positioned at the given stock
+func NewCursor(stock Stock, backward bool) Cursor {
+	return Cursor{Time: stock.Time.UTC(), ID: stock.ID, Backward: backward}
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.Time.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
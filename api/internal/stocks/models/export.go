@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// Export formats supported by the stock export
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// ExportColumns is the column order shared by every export format
+var ExportColumns = []string{
+	"id", "ticker", "company", "brokerage", "action",
+	"rating_from", "rating_to", "target_from", "target_to", "time",
+}
+
+// ExportRecord returns the stock's values in ExportColumns order
+func (s Stock) ExportRecord() []string {
+	return []string{
+		s.ID,
+		s.Ticker,
+		s.Company,
+		s.Brokerage,
+		s.Action,
+		s.RatingFrom,
+		s.RatingTo,
+		strconv.FormatFloat(s.TargetFrom, 'f', 2, 64),
+		strconv.FormatFloat(s.TargetTo, 'f', 2, 64),
+		s.Time.Format(time.RFC3339),
+	}
+}
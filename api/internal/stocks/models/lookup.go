@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// TickerLookup holds the latest events and summary of one requested ticker
+type TickerLookup struct {
+	Ticker       string         `json:"ticker"`
+	Found        bool           `json:"found"`
+	LatestEvents []Stock        `json:"latest_events"`
+	Summary      *TickerSummary `json:"summary,omitempty"`
+}
+
+// InLocation returns a copy of the lookup with its timestamps expressed in loc
+func (l TickerLookup) InLocation(loc *time.Location) TickerLookup {
+	events := make([]Stock, len(l.LatestEvents))
+	for i, event := range l.LatestEvents {
+		events[i] = event.InLocation(loc)
+	}
+	l.LatestEvents = events
+
+	if l.Summary != nil {
+		summary := l.Summary.InLocation(loc)
+		l.Summary = &summary
+	}
+
+	return l
+}
+
+// TickerComparison lines up the consensus of a ticker for side by side views
+type TickerComparison struct {
+	Ticker             string       `json:"ticker"`
+	Company            string       `json:"company"`
+	Found              bool         `json:"found"`
+	ConsensusRating    string       `json:"consensus_rating"`
+	ConsensusScore     float64      `json:"consensus_score"`
+	CoveringBrokerages int          `json:"covering_brokerages"`
+	Target             *TargetStats `json:"target,omitempty"`
+	Upgrades           int          `json:"upgrades"`
+	Downgrades         int          `json:"downgrades"`
+	RecentActions      []Stock      `json:"recent_actions"`
+}
@@ -0,0 +1,288 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Rating categories used to group brokerage ratings
+const (
+	RatingCategoryPositive = "Positive"
+	RatingCategoryNeutral  = "Neutral"
+	RatingCategoryNegative = "Negative"
+)
+
+// RatingLevel is the position of a rating on the ordinal ladder from strong
+// sell to strong buy. RatingUnknown is the level of unmapped ratings.
+type RatingLevel int
+
+// Levels of the rating ladder, from lowest to highest
+const (
+	RatingUnknown RatingLevel = iota
+	RatingStrongSell
+	RatingSell
+	RatingHold
+	RatingBuy
+	RatingStrongBuy
+)
+
+// RatingLevels lists the ladder from lowest to highest
+var RatingLevels = []RatingLevel{RatingStrongSell, RatingSell, RatingHold, RatingBuy, RatingStrongBuy}
+
+// ratingLevelNames are the normalized names of the levels
+var ratingLevelNames = map[RatingLevel]string{
+	RatingStrongSell: "strong_sell",
+	RatingSell:       "sell",
+	RatingHold:       "hold",
+	RatingBuy:        "buy",
+	RatingStrongBuy:  "strong_buy",
+}
+
+// String returns the normalized name of the level, empty for unknown ratings
+func (l RatingLevel) String() string {
+	return ratingLevelNames[l]
+}
+
+// Category returns the category of the level, unknown ratings are Neutral
+func (l RatingLevel) Category() string {
+	switch l {
+	case RatingBuy, RatingStrongBuy:
+		return RatingCategoryPositive
+	case RatingSell, RatingStrongSell:
+		return RatingCategoryNegative
+	default:
+		return RatingCategoryNeutral
+	}
+}
+
+// MarshalText encodes the level as its normalized name
+func (l RatingLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a normalized level name
+func (l *RatingLevel) UnmarshalText(text []byte) error {
+	level, err := ParseRatingLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseRatingLevel parses a normalized level name such as "strong_buy"
+func ParseRatingLevel(name string) (RatingLevel, error) {
+	for _, level := range RatingLevels {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return RatingUnknown, fmt.Errorf("invalid rating %q, expected one of %s", name, strings.Join(RatingLevelNames(), ", "))
+}
+
+// RatingLevelNames returns the normalized names of the ladder, lowest first
+func RatingLevelNames() []string {
+	names := make([]string, len(RatingLevels))
+	for i, level := range RatingLevels {
+		names[i] = level.String()
+	}
+	return names
+}
+
+// LevelsInCategory returns the levels belonging to a category, including
+// RatingUnknown for Neutral
+func LevelsInCategory(category string) []RatingLevel {
+	var levels []RatingLevel
+	for _, level := range append([]RatingLevel{RatingUnknown}, RatingLevels...) {
+		if level.Category() == category {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// ErrInvalidRatingLadder is returned for rating ladders that cannot be parsed
+var ErrInvalidRatingLadder = errors.New("invalid rating ladder")
+
+// RatingLadder places rating strings on the ladder. BrokerageOverrides holds
+// the house-specific scales of brokerages whose ratings mean something else
+// than the shared Ratings, keyed by brokerage and then rating.
+type RatingLadder struct {
+	Ratings            map[string]RatingLevel            `json:"ratings"`
+	BrokerageOverrides map[string]map[string]RatingLevel `json:"brokerage_overrides"`
+}
+
+// Level returns the level of a rating published by a brokerage
+func (l RatingLadder) Level(brokerage, rating string) RatingLevel {
+	if level, ok := l.BrokerageOverrides[brokerage][rating]; ok {
+		return level
+	}
+	return l.Ratings[rating]
+}
+
+// WithRatings returns a copy of the ladder with the ratings added, replacing
+// those of the same name
+func (l RatingLadder) WithRatings(ratings map[string]RatingLevel) RatingLadder {
+	merged := RatingLadder{
+		Ratings:            make(map[string]RatingLevel, len(l.Ratings)+len(ratings)),
+		BrokerageOverrides: l.BrokerageOverrides,
+	}
+	for rating, level := range l.Ratings {
+		merged.Ratings[rating] = level
+	}
+	for rating, level := range ratings {
+		merged.Ratings[rating] = level
+	}
+	return merged
+}
+
+// DefaultRatingLadder returns the ladder used when no configuration is loaded
+func DefaultRatingLadder() RatingLadder {
+	return RatingLadder{
+		Ratings: map[string]RatingLevel{
+			// Strong positive ratings
+			"Strong-Buy":     RatingStrongBuy,
+			"Strong Buy":     RatingStrongBuy,
+			"Conviction Buy": RatingStrongBuy,
+			"Top Pick":       RatingStrongBuy,
+
+			// Positive ratings
+			"Buy":               RatingBuy,
+			"Outperform":        RatingBuy,
+			"Outperformer":      RatingBuy,
+			"Overweight":        RatingBuy,
+			"Positive":          RatingBuy,
+			"Market Outperform": RatingBuy,
+			"Sector Outperform": RatingBuy,
+
+			// Neutral ratings
+			"Hold":           RatingHold,
+			"Neutral":        RatingHold,
+			"Equal Weight":   RatingHold,
+			"Market Perform": RatingHold,
+			"Sector Perform": RatingHold,
+			"In-Line":        RatingHold,
+			"Inline":         RatingHold,
+			"Peer Perform":   RatingHold,
+			"Sector Weight":  RatingHold,
+
+			// Negative ratings
+			"Sell":                RatingSell,
+			"Reduce":              RatingSell,
+			"Underperform":        RatingSell,
+			"Underweight":         RatingSell,
+			"Negative":            RatingSell,
+			"Sector Underperform": RatingSell,
+
+			// Strong negative ratings
+			"Strong-Sell": RatingStrongSell,
+			"Strong Sell": RatingStrongSell,
+		},
+		BrokerageOverrides: map[string]map[string]RatingLevel{},
+	}
+}
+
+// ParseRatingLadder reads a rating ladder as JSON. Its ratings are added to
+// the default ones, replacing those of the same name, and unknown fields or
+// levels are rejected.
+func ParseRatingLadder(r io.Reader) (RatingLadder, error) {
+	var parsed RatingLadder
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&parsed); err != nil {
+		return RatingLadder{}, fmt.Errorf("%w: %w", ErrInvalidRatingLadder, err)
+	}
+
+	ladder := DefaultRatingLadder().WithRatings(parsed.Ratings)
+	for brokerage, overrides := range parsed.BrokerageOverrides {
+		ladder.BrokerageOverrides[brokerage] = overrides
+	}
+
+	return ladder, nil
+}
+
+// LoadRatingLadder reads the rating ladder file at path
+func LoadRatingLadder(path string) (RatingLadder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RatingLadder{}, fmt.Errorf("error reading rating ladder %s: %w", path, err)
+	}
+
+	return ParseRatingLadder(bytes.NewReader(data))
+}
+
+var (
+	ladderMu sync.RWMutex
+	// ladder is the rating ladder in use
+	ladder = DefaultRatingLadder()
+)
+
+// SetRatingLadder replaces the rating ladder in use
+func SetRatingLadder(l RatingLadder) {
+	ladderMu.Lock()
+	defer ladderMu.Unlock()
+	ladder = l
+}
+
+// CurrentRatingLadder returns the rating ladder in use
+func CurrentRatingLadder() RatingLadder {
+	ladderMu.RLock()
+	defer ladderMu.RUnlock()
+	return ladder
+}
+
+// GetRatingLevel returns the level of a rating published by a brokerage
+func GetRatingLevel(brokerage, rating string) RatingLevel {
+	return CurrentRatingLadder().Level(brokerage, rating)
+}
+
+// GetRatingCategory returns the category of a rating, unknown ratings are Neutral
+func GetRatingCategory(rating string) string {
+	return GetRatingLevel("", rating).Category()
+}
+
+// GetBrokerageRatingCategory returns the category of a rating published by a
+// brokerage, honoring its overrides
+func GetBrokerageRatingCategory(brokerage, rating string) string {
+	return GetRatingLevel(brokerage, rating).Category()
+}
+
+// RatingsInLevels returns the shared rating strings placed on one of the levels
+func RatingsInLevels(levels []RatingLevel) []string {
+	ratings := make([]string, 0)
+	for rating, level := range CurrentRatingLadder().Ratings {
+		if containsLevel(levels, level) {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.Strings(ratings)
+	return ratings
+}
+
+// RatingsInCategory returns the shared rating strings belonging to a category
+func RatingsInCategory(category string) []string {
+	return RatingsInLevels(LevelsInCategory(category))
+}
+
+// KnownRatings returns every shared rating string with a known level
+func KnownRatings() []string {
+	return RatingsInLevels(RatingLevels)
+}
+
+// containsLevel reports whether level is one of levels
+func containsLevel(levels []RatingLevel, level RatingLevel) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
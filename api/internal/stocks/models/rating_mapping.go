@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RatingMapping places a rating string on the ladder at runtime. Mappings are
+// stored in the database and take precedence over the built-in ratings.
+type RatingMapping struct {
+	Rating    string      `json:"rating"`
+	Level     RatingLevel `json:"level"`
+	Category  string      `json:"category"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// UnmappedRating is a rating string found in the stored events without a
+// level on the ladder. FirstSeen and LastSeen are the times of the first and
+// last events using it.
+type UnmappedRating struct {
+	Rating      string    `json:"rating"`
+	Occurrences int       `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Bucket intervals for time series
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// TickerSector assigns a ticker to a sector
+type TickerSector struct {
+	Ticker    string    `json:"ticker" gorm:"size:10;primary_key"`
+	Sector    string    `json:"sector" gorm:"size:100;not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamptz;autoUpdateTime"`
+}
+
+// SentimentQuery selects the events aggregated into a sentiment time series
+type SentimentQuery struct {
+	Interval   string
+	Location   *time.Location
+	Since      time.Time
+	Until      time.Time
+	Tickers    []string
+	Brokerages []string
+	Sector     string
+}
+
+// SentimentBucket holds the analyst activity within one time bucket
+type SentimentBucket struct {
+	Bucket       time.Time `json:"bucket"`
+	Upgrades     int       `json:"upgrades"`
+	Downgrades   int       `json:"downgrades"`
+	Initiations  int       `json:"initiations"`
+	TargetRaises int       `json:"target_raises"`
+	TargetCuts   int       `json:"target_cuts"`
+	TotalEvents  int       `json:"total_events"`
+	NetSentiment float64   `json:"net_sentiment"`
+}
+
+// SentimentSeries represents market sentiment over time
+type SentimentSeries struct {
+	Interval    string            `json:"interval"`
+	WindowStart time.Time         `json:"window_start"`
+	WindowEnd   time.Time         `json:"window_end"`
+	Buckets     []SentimentBucket `json:"buckets"`
+}
+
+// AddInterval advances t by one bucket interval in its location
+func AddInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// TruncateToInterval returns the start of the bucket containing t, in loc.
+// Weeks start on Monday, matching date_trunc.
+func TruncateToInterval(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	switch interval {
+	case IntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
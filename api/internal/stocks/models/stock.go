@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,11 +17,36 @@ type Stock struct {
 	RatingTo   string    `json:"rating_to" gorm:"size:50"`
 	TargetFrom float64   `json:"target_from"`
 	TargetTo   float64   `json:"target_to"`
-	Time       time.Time `json:"time" gorm:"type:timestamp;not null"`
-	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp;autoCreateTime"`
-	UpdatedAt  time.Time `json:"updated_at" gorm:"type:timestamp;autoUpdateTime"`
+	Time       time.Time `json:"time" gorm:"type:timestamptz;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamptz;autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"type:timestamptz;autoUpdateTime"`
 }
 
+// NormalizedRating returns the level of the new rating on the rating ladder
+func (s Stock) NormalizedRating() RatingLevel {
+	return GetRatingLevel(s.Brokerage, s.RatingTo)
+}
+
+// MarshalJSON adds the normalized rating to the stored fields
+func (s Stock) MarshalJSON() ([]byte, error) {
+	type stock Stock
+	return json.Marshal(struct {
+		stock
+		Rating RatingLevel `json:"rating,omitempty"`
+	}{stock(s), s.NormalizedRating()})
+}
+
+// Actions reported by the upstream API for rating events
+const (
+	ActionUpgraded      = "upgraded by"
+	ActionDowngraded    = "downgraded by"
+	ActionInitiated     = "initiated by"
+	ActionReiterated    = "reiterated by"
+	ActionTargetRaised  = "target raised by"
+	ActionTargetLowered = "target lowered by"
+	ActionTargetSet     = "target set by"
+)
+
 // PaginatedStocks represents paginated stock data
 type PaginatedStocks struct {
 	Stocks     []Stock `json:"stocks"`
@@ -27,9 +54,90 @@ type PaginatedStocks struct {
 	PageSize   int     `json:"page_size"`
 	Page       int     `json:"page"`
 	TotalPages int     `json:"total_pages"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
 }
 
+// PaginationParams selects a page either by number or by cursor. When Cursor
+// is set the page number is ignored and keyset pagination on (time, id) is used.
 type PaginationParams struct {
-	Page     int
-	PageSize int
+	Page       int
+	PageSize   int
+	Filter     StockFilter
+	Cursor     *Cursor
+	ExactCount bool
+}
+
+// Sortable fields for stock listings
+const (
+	SortByTime         = "time"
+	SortByTicker       = "ticker"
+	SortByCompany      = "company"
+	SortByBrokerage    = "brokerage"
+	SortByTargetTo     = "target_to"
+	SortByTargetChange = "target_change"
+)
+
+// StockSortFields lists the fields stock listings can be sorted by
+var StockSortFields = []string{
+	SortByTime,
+	SortByTicker,
+	SortByCompany,
+	SortByBrokerage,
+	SortByTargetTo,
+	SortByTargetChange,
+}
+
+// StockFilter narrows and orders a stock listing, zero values are ignored
+type StockFilter struct {
+	Tickers         []string
+	ExcludeTickers  []string
+	Brokerages      []string
+	Action          string
+	RatingFrom      string
+	RatingTo        string
+	RatingCategory  string
+	Ratings         []RatingLevel
+	Sector          string
+	From            *time.Time // inclusive
+	To              *time.Time // exclusive
+	TargetMin       *float64
+	TargetMax       *float64
+	TargetChangeMin *float64 // percent
+	TargetChangeMax *float64 // percent
+	SortField       string
+	SortDesc        bool
+}
+
+// LoadLocation resolves an IANA time zone name such as "America/New_York",
+// defaulting to UTC when no name is given. "Local" is rejected, it names the
+// server's zone in Go but is unknown to the database.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %s", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// InLocation returns a copy of the stock with its timestamps expressed in loc
+func (s Stock) InLocation(loc *time.Location) Stock {
+	s.Time = s.Time.In(loc)
+	s.CreatedAt = s.CreatedAt.In(loc)
+	s.UpdatedAt = s.UpdatedAt.In(loc)
+	return s
+}
+
+// StockSearchResult represents a ticker matched by a search with its latest event
+type StockSearchResult struct {
+	Ticker    string    `json:"ticker"`
+	Company   string    `json:"company"`
+	Brokerage string    `json:"brokerage"`
+	Action    string    `json:"action"`
+	RatingTo  string    `json:"rating_to"`
+	TargetTo  float64   `json:"target_to"`
+	Time      time.Time `json:"time"`
+	Score     float64   `json:"score"`
 }
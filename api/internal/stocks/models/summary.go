@@ -0,0 +1,87 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// BrokerageRating is the latest call of a single brokerage on a ticker
+type BrokerageRating struct {
+	Brokerage      string      `json:"brokerage"`
+	Action         string      `json:"action"`
+	RatingFrom     string      `json:"rating_from"`
+	RatingTo       string      `json:"rating_to"`
+	RatingCategory string      `json:"rating_category"`
+	Rating         RatingLevel `json:"rating,omitempty"`
+	TargetFrom     float64     `json:"target_from"`
+	TargetTo       float64     `json:"target_to"`
+	Time           time.Time   `json:"time"`
+}
+
+// TargetStats summarizes the price targets of the covering brokerages
+type TargetStats struct {
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Count  int     `json:"count"`
+}
+
+// NewTargetStats returns mean, median, high and low of the targets, or nil
+// when there are none
+func NewTargetStats(targets []float64) *TargetStats {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	sorted := append([]float64(nil), targets...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, target := range sorted {
+		sum += target
+	}
+
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	return &TargetStats{
+		Mean:   sum / float64(len(sorted)),
+		Median: median,
+		High:   sorted[len(sorted)-1],
+		Low:    sorted[0],
+		Count:  len(sorted),
+	}
+}
+
+// TickerSummary represents the analyst consensus on a ticker over a time window
+type TickerSummary struct {
+	Ticker             string            `json:"ticker"`
+	Company            string            `json:"company"`
+	WindowStart        time.Time         `json:"window_start"`
+	WindowEnd          time.Time         `json:"window_end"`
+	LatestRatings      []BrokerageRating `json:"latest_ratings"`
+	ConsensusRating    string            `json:"consensus_rating"`
+	ConsensusScore     float64           `json:"consensus_score"`
+	RatingDistribution map[string]int    `json:"rating_distribution"`
+	Target             *TargetStats      `json:"target,omitempty"`
+	CoveringBrokerages int               `json:"covering_brokerages"`
+	Upgrades           int               `json:"upgrades"`
+	Downgrades         int               `json:"downgrades"`
+	TotalEvents        int               `json:"total_events"`
+}
+
+// InLocation returns a copy of the summary with its timestamps expressed in loc
+func (s TickerSummary) InLocation(loc *time.Location) TickerSummary {
+	s.WindowStart = s.WindowStart.In(loc)
+	s.WindowEnd = s.WindowEnd.In(loc)
+	ratings := make([]BrokerageRating, len(s.LatestRatings))
+	for i, rating := range s.LatestRatings {
+		rating.Time = rating.Time.In(loc)
+		ratings[i] = rating
+	}
+	s.LatestRatings = ratings
+	return s
+}
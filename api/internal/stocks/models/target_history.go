@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// TargetPoint is a price target that applies from Time onwards
+type TargetPoint struct {
+	Time   time.Time `json:"time"`
+	Target float64   `json:"target"`
+}
+
+// BrokerageTargetSeries is the step series of one brokerage's price target
+type BrokerageTargetSeries struct {
+	Brokerage string        `json:"brokerage"`
+	Points    []TargetPoint `json:"points"`
+}
+
+// ConsensusTargetPoint is the consensus price target at the end of a bucket
+type ConsensusTargetPoint struct {
+	Time       time.Time `json:"time"`
+	Mean       float64   `json:"mean"`
+	Median     float64   `json:"median"`
+	Brokerages int       `json:"brokerages"`
+}
+
+// TargetHistory represents the evolution of price targets on a ticker
+type TargetHistory struct {
+	Ticker      string                  `json:"ticker"`
+	Interval    string                  `json:"interval"`
+	WindowStart time.Time               `json:"window_start"`
+	WindowEnd   time.Time               `json:"window_end"`
+	Brokerages  []BrokerageTargetSeries `json:"brokerages"`
+	Consensus   []ConsensusTargetPoint  `json:"consensus"`
+}
+
+// InLocation returns a copy of the history with its timestamps expressed in loc
+func (h TargetHistory) InLocation(loc *time.Location) TargetHistory {
+	h.WindowStart = h.WindowStart.In(loc)
+	h.WindowEnd = h.WindowEnd.In(loc)
+
+	brokerages := make([]BrokerageTargetSeries, len(h.Brokerages))
+	for i, series := range h.Brokerages {
+		points := make([]TargetPoint, len(series.Points))
+		for j, point := range series.Points {
+			point.Time = point.Time.In(loc)
+			points[j] = point
+		}
+		brokerages[i] = BrokerageTargetSeries{Brokerage: series.Brokerage, Points: points}
+	}
+	h.Brokerages = brokerages
+
+	consensus := make([]ConsensusTargetPoint, len(h.Consensus))
+	for i, point := range h.Consensus {
+		point.Time = point.Time.In(loc)
+		consensus[i] = point
+	}
+	h.Consensus = consensus
+
+	return h
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DefaultWindow is the lookback used when no window parameter is given
+const DefaultWindow = 90 * 24 * time.Hour
+
+// MaxWindowDays caps the lookback windows accepted from clients
+const MaxWindowDays = 5 * 365
+
+// ParseWindow reads a lookback window such as "30d", "12w", "6m" or "1y".
+// A bare number is taken as days, months count as 30 days and years as 365.
+func ParseWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultWindow, nil
+	}
+
+	unitDays := 1
+	number := value
+	switch value[len(value)-1] {
+	case 'd':
+		number = value[:len(value)-1]
+	case 'w':
+		unitDays, number = 7, value[:len(value)-1]
+	case 'm':
+		unitDays, number = 30, value[:len(value)-1]
+	case 'y':
+		unitDays, number = 365, value[:len(value)-1]
+	}
+
+	// Bound n before multiplying so huge values cannot wrap around
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > MaxWindowDays/unitDays {
+		return 0, fmt.Errorf("invalid window %q, expected a positive number of days (d), weeks (w), months (m) or years (y) up to %d days", value, MaxWindowDays)
+	}
+
+	return time.Duration(n*unitDays) * 24 * time.Hour, nil
+}
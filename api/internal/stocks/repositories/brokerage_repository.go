@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"fmt"
+	"stonks-api/internal/stocks/models"
+)
+
+// brokerageSortColumns maps the whitelisted leaderboard sort fields to SQL expressions
+var brokerageSortColumns = map[string]string{
+	models.BrokerageSortByCalls:        "calls",
+	models.BrokerageSortByUpgrades:     "upgrades",
+	models.BrokerageSortByDowngrades:   "downgrades",
+	models.BrokerageSortByRatio:        "upgrades::FLOAT / NULLIF(downgrades, 0)",
+	models.BrokerageSortByTargetChange: "avg_target_change",
+	models.BrokerageSortByTickers:      "tickers_covered",
+	models.BrokerageSortByLastActivity: "last_activity",
+}
+
+// GetBrokerageStats aggregates the rating events of each brokerage within the
+// query window, ranked by the requested field
+func (r *StockRepository) GetBrokerageStats(query models.BrokerageQuery) ([]models.BrokerageStats, error) {
+	column, ok := brokerageSortColumns[query.SortField]
+	if !ok {
+		column = brokerageSortColumns[models.BrokerageSortByCalls]
+	}
+
+	direction := "DESC"
+	if query.SortAsc {
+		direction = "ASC"
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var stats []models.BrokerageStats
+	err := r.db.Raw(fmt.Sprintf(`
+		SELECT brokerage, calls, upgrades, downgrades, avg_target_change, tickers_covered, last_activity
+		FROM (
+			SELECT
+				brokerage,
+				count(*) AS calls,
+				count(*) FILTER (WHERE action = ?) AS upgrades,
+				count(*) FILTER (WHERE action = ?) AS downgrades,
+				coalesce(avg(`+targetChangeExpr+`) FILTER (WHERE target_from > 0), 0)::FLOAT AS avg_target_change,
+				count(DISTINCT ticker) AS tickers_covered,
+				max(time) AS last_activity
+			FROM stocks
+			WHERE time >= ? AND time < ?
+			GROUP BY brokerage
+		) brokerages
+		ORDER BY %s %s NULLS LAST, brokerage
+		LIMIT ?`, column, direction),
+		models.ActionUpgraded, models.ActionDowngraded, query.Since.UTC(), query.Until.UTC(), limit,
+	).Scan(&stats)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate brokerage stats: %w", err)
+	}
+
+	for i := range stats {
+		if stats[i].Downgrades > 0 {
+			ratio := float64(stats[i].Upgrades) / float64(stats[i].Downgrades)
+			stats[i].UpgradeDowngradeRatio = &ratio
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"fmt"
+	"stonks-api/internal/stocks/models"
+	"time"
+)
+
+// ratingMappingRow is a stored rating mapping with its level still encoded
+type ratingMappingRow struct {
+	Rating    string
+	Level     string
+	UpdatedAt time.Time
+}
+
+// GetRatingMappings retrieves the rating mappings added at runtime, by rating
+func (r *StockRepository) GetRatingMappings() ([]models.RatingMapping, error) {
+	var rows []ratingMappingRow
+	err := r.db.Raw(`
+		SELECT rating, level, updated_at
+		FROM rating_mappings
+		ORDER BY rating`,
+	).Scan(&rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rating mappings: %w", err)
+	}
+
+	mappings := make([]models.RatingMapping, 0, len(rows))
+	for _, row := range rows {
+		level, err := models.ParseRatingLevel(row.Level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode rating mapping of %q: %w", row.Rating, err)
+		}
+
+		mappings = append(mappings, models.RatingMapping{
+			Rating:    row.Rating,
+			Level:     level,
+			Category:  level.Category(),
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+
+	return mappings, nil
+}
+
+// SaveRatingMapping stores the mapping, replacing the one of the same rating,
+// and drops the rating from the unmapped ones
+func (r *StockRepository) SaveRatingMapping(mapping models.RatingMapping) error {
+	err := r.db.Exec(`
+		INSERT INTO rating_mappings (rating, level, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (rating) DO UPDATE SET
+			level = excluded.level,
+			updated_at = excluded.updated_at`,
+		mapping.Rating, mapping.Level.String(), mapping.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save rating mapping of %q: %w", mapping.Rating, err)
+	}
+
+	if err := r.db.Exec("DELETE FROM unmapped_ratings WHERE rating = ?", mapping.Rating); err != nil {
+		return fmt.Errorf("failed to drop unmapped rating %q: %w", mapping.Rating, err)
+	}
+
+	return nil
+}
+
+// DeleteRatingMapping removes the mapping of a rating
+func (r *StockRepository) DeleteRatingMapping(rating string) error {
+	if err := r.db.Exec("DELETE FROM rating_mappings WHERE rating = ?", rating); err != nil {
+		return fmt.Errorf("failed to delete rating mapping of %q: %w", rating, err)
+	}
+
+	return nil
+}
+
+// InvalidateRatings drops the cached counts and responses, which carry
+// normalized ratings, once a new rating ladder is in use
+func (r *StockRepository) InvalidateRatings() {
+	r.invalidateCounts()
+}
+
+// RefreshUnmappedRatings records the previous and new ratings of the stored
+// events missing from the rating ladder in use, with their number of
+// occurrences and the times of their first and last events. Ratings mapped
+// since the last refresh are dropped.
+func (r *StockRepository) RefreshUnmappedRatings() error {
+	refreshedAt := time.Now().UTC()
+	condition, args := ratingCondition("rating", []models.RatingLevel{models.RatingUnknown})
+
+	err := r.db.Exec(`
+		INSERT INTO unmapped_ratings (rating, occurrences, first_seen, last_seen, updated_at)
+		SELECT rating, COUNT(*), MIN(time), MAX(time), ?
+		FROM (
+			SELECT rating_from AS rating, brokerage, time FROM stocks
+			UNION ALL
+			SELECT rating_to AS rating, brokerage, time FROM stocks
+		) AS ratings
+		WHERE rating <> '' AND `+condition+`
+		GROUP BY rating
+		ON CONFLICT (rating) DO UPDATE SET
+			occurrences = excluded.occurrences,
+			first_seen = excluded.first_seen,
+			last_seen = excluded.last_seen,
+			updated_at = excluded.updated_at`,
+		append([]interface{}{refreshedAt}, args...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record unmapped ratings: %w", err)
+	}
+
+	if err := r.db.Exec("DELETE FROM unmapped_ratings WHERE updated_at < ?", refreshedAt); err != nil {
+		return fmt.Errorf("failed to drop mapped ratings: %w", err)
+	}
+
+	return nil
+}
+
+// RecordUnmappedRatings refreshes the record of the given ratings only, for
+// the ratings of newly saved events. It reads the events carrying them
+// rather than every stored event.
+func (r *StockRepository) RecordUnmappedRatings(ratings []string) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	refreshedAt := time.Now().UTC()
+	condition, args := ratingCondition("rating", []models.RatingLevel{models.RatingUnknown})
+
+	err := r.db.Exec(`
+		INSERT INTO unmapped_ratings (rating, occurrences, first_seen, last_seen, updated_at)
+		SELECT rating, COUNT(*), MIN(time), MAX(time), ?
+		FROM (
+			SELECT rating_from AS rating, brokerage, time FROM stocks WHERE rating_from IN ?
+			UNION ALL
+			SELECT rating_to AS rating, brokerage, time FROM stocks WHERE rating_to IN ?
+		) AS ratings
+		WHERE `+condition+`
+		GROUP BY rating
+		ON CONFLICT (rating) DO UPDATE SET
+			occurrences = excluded.occurrences,
+			first_seen = excluded.first_seen,
+			last_seen = excluded.last_seen,
+			updated_at = excluded.updated_at`,
+		append([]interface{}{refreshedAt, ratings, ratings}, args...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record unmapped ratings: %w", err)
+	}
+
+	if err := r.db.Exec("DELETE FROM unmapped_ratings WHERE rating IN ? AND updated_at < ?", ratings, refreshedAt); err != nil {
+		return fmt.Errorf("failed to drop mapped ratings: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnmappedRatings retrieves up to limit unmapped ratings, most used first
+func (r *StockRepository) GetUnmappedRatings(limit int) ([]models.UnmappedRating, error) {
+	var ratings []models.UnmappedRating
+	err := r.db.Raw(`
+		SELECT rating, occurrences, first_seen, last_seen
+		FROM unmapped_ratings
+		ORDER BY occurrences DESC, rating
+		LIMIT ?`,
+		limit,
+	).Scan(&ratings)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve unmapped ratings: %w", err)
+	}
+
+	return ratings, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+)
+
+// sentimentIntervals whitelists the date_trunc units used for buckets
+var sentimentIntervals = map[string]bool{
+	models.IntervalDay:   true,
+	models.IntervalWeek:  true,
+	models.IntervalMonth: true,
+}
+
+// GetSentimentSeries counts analyst actions per time bucket. Buckets are
+// truncated in the query location so days start at the caller's midnight.
+// Buckets without events are omitted.
+func (r *StockRepository) GetSentimentSeries(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+	if !sentimentIntervals[query.Interval] {
+		return nil, fmt.Errorf("unsupported interval %q", query.Interval)
+	}
+
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	conditions := []string{"time >= ?", "time < ?"}
+	args := []interface{}{
+		loc.String(), query.Interval, loc.String(),
+		models.ActionUpgraded, models.ActionDowngraded, models.ActionInitiated,
+		query.Since.UTC(), query.Until.UTC(),
+	}
+
+	if len(query.Tickers) > 0 {
+		conditions = append(conditions, "ticker IN ?")
+		args = append(args, query.Tickers)
+	}
+	if len(query.Brokerages) > 0 {
+		conditions = append(conditions, "brokerage IN ?")
+		args = append(args, query.Brokerages)
+	}
+	if query.Sector != "" {
+		conditions = append(conditions, "ticker IN (SELECT ticker FROM ticker_sectors WHERE sector = ?)")
+		args = append(args, query.Sector)
+	}
+
+	var buckets []models.SentimentBucket
+	err := r.db.Raw(`
+		SELECT
+			timezone(?, date_trunc(?, timezone(?, time))) AS bucket,
+			count(*) FILTER (WHERE action = ?) AS upgrades,
+			count(*) FILTER (WHERE action = ?) AS downgrades,
+			count(*) FILTER (WHERE action = ?) AS initiations,
+			count(*) FILTER (WHERE target_from > 0 AND target_to > target_from) AS target_raises,
+			count(*) FILTER (WHERE target_from > 0 AND target_to < target_from) AS target_cuts,
+			count(*) AS total_events
+		FROM stocks
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY 1
+		ORDER BY 1`,
+		args...,
+	).Scan(&buckets)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate sentiment: %w", err)
+	}
+
+	for i := range buckets {
+		buckets[i].NetSentiment = netSentiment(buckets[i])
+	}
+
+	return buckets, nil
+}
+
+// netSentiment scores a bucket between -1 (all bearish) and 1 (all bullish)
+// from its upgrades, downgrades, target raises and target cuts
+func netSentiment(bucket models.SentimentBucket) float64 {
+	bullish := bucket.Upgrades + bucket.TargetRaises
+	bearish := bucket.Downgrades + bucket.TargetCuts
+	if bullish+bearish == 0 {
+		return 0
+	}
+	return float64(bullish-bearish) / float64(bullish+bearish)
+}
+
+// SetTickerSector assigns a ticker to a sector, replacing any previous sector
+func (r *StockRepository) SetTickerSector(ticker, sector string) error {
+	err := r.db.Exec(`
+		INSERT INTO ticker_sectors (ticker, sector, updated_at)
+		VALUES (?, ?, now())
+		ON CONFLICT (ticker) DO UPDATE SET sector = excluded.sector, updated_at = excluded.updated_at`,
+		ticker, sector,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set sector for ticker %s: %w", ticker, err)
+	}
+
+	r.invalidateCounts()
+
+	return nil
+}
@@ -1,22 +1,45 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
+	"stonks-api/cmd/cache"
 	"stonks-api/cmd/database"
 	"stonks-api/internal/stocks/models"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	// countCacheTTL bounds how stale a cached total count can get
+	countCacheTTL = time.Minute
+	// countCacheSize bounds the number of filters whose count is cached
+	countCacheSize = 1000
+)
+
 type StockRepository struct {
 	db database.Database
+
+	countCache *cache.MemoryBackend
+
+	readCache *cache.ReadCache
 }
 
 func NewStockRepository(db database.Database) *StockRepository {
 	return &StockRepository{
-		db: db,
+		db:         db,
+		countCache: cache.NewMemoryBackend(countCacheSize),
 	}
 }
 
+// SetReadCache sets the read cache to invalidate whenever stock data changes
+func (r *StockRepository) SetReadCache(readCache *cache.ReadCache) {
+	r.readCache = readCache
+}
+
 // SaveStocks saves a batch of stock data to the database
 func (r *StockRepository) SaveStocks(stocks []models.Stock) error {
 	if len(stocks) == 0 {
@@ -26,6 +49,12 @@ func (r *StockRepository) SaveStocks(stocks []models.Stock) error {
 	// Process the entire batch in a single transaction
 	err := r.db.Transaction(func(tx database.Transaction) error {
 		for _, stock := range stocks {
+			// Timestamps are stored as TIMESTAMPTZ, always write them as UTC
+			stock.Time = stock.Time.UTC()
+			// Stamped at write time, as close to the commit as possible, for
+			// the live feed
+			stock.UpdatedAt = time.Now().UTC()
+
 			var count int64
 			query := tx.Model(&models.Stock{}).Where("ticker = ? AND time = ?", stock.Ticker, stock.Time)
 
@@ -47,11 +76,19 @@ func (r *StockRepository) SaveStocks(stocks []models.Stock) error {
 					"rating_to":   stock.RatingTo,
 					"target_from": stock.TargetFrom,
 					"target_to":   stock.TargetTo,
-					"updated_at":  time.Now(),
+					"updated_at":  stock.UpdatedAt,
 				}
 
+				// Only touch rows whose values changed so updated_at keeps
+				// marking real changes for the live feed
 				if err := tx.Model(&models.Stock{}).Where("ticker = ? AND time = ?",
-					stock.Ticker, stock.Time).Updates(updates); err != nil {
+					stock.Ticker, stock.Time).
+					Where("company IS DISTINCT FROM ? OR brokerage IS DISTINCT FROM ? OR action IS DISTINCT FROM ? OR "+
+						"rating_from IS DISTINCT FROM ? OR rating_to IS DISTINCT FROM ? OR "+
+						"target_from IS DISTINCT FROM ? OR target_to IS DISTINCT FROM ?",
+						stock.Company, stock.Brokerage, stock.Action, stock.RatingFrom, stock.RatingTo,
+						stock.TargetFrom, stock.TargetTo).
+					Updates(updates); err != nil {
 					return err
 				}
 			}
@@ -64,10 +101,12 @@ func (r *StockRepository) SaveStocks(stocks []models.Stock) error {
 		return fmt.Errorf("failed to save stock batch: %w", err)
 	}
 
+	r.invalidateCounts()
+
 	return nil
 }
 
-// GetAllStocks retrieves all stocks from the database with pagination
+// GetAllStocks retrieves all stocks from the database with pagination and filters
 func (r *StockRepository) GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error) {
 	page := params.Page
 	pageSize := params.PageSize
@@ -79,18 +118,22 @@ func (r *StockRepository) GetAllStocks(params models.PaginationParams) (models.P
 		pageSize = 20
 	}
 
-	offset := (page - 1) * pageSize
-
-	totalCount, err := r.db.Count(&models.Stock{})
+	totalCount, err := r.countStocks(params.Filter, params.ExactCount)
 	if err != nil {
 		return models.PaginatedStocks{}, fmt.Errorf("failed to get stock count: %w", err)
 	}
 
 	totalPages := int((totalCount + int64(pageSize) - 1) / int64(pageSize))
 
+	if params.Cursor != nil {
+		return r.getStocksAfterCursor(params.Filter, *params.Cursor, pageSize, totalCount, totalPages)
+	}
+
+	offset := (page - 1) * pageSize
+
 	var stocks []models.Stock
-	err = r.db.Select("id, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time, updated_at").
-		Order("time DESC").
+	err = applyStockFilter(r.db.Select(stockListColumns), params.Filter).
+		Order(stockOrderClause(params.Filter)).
 		Limit(pageSize).
 		Offset(offset).
 		Find(&stocks)
@@ -99,20 +142,393 @@ func (r *StockRepository) GetAllStocks(params models.PaginationParams) (models.P
 		return models.PaginatedStocks{}, fmt.Errorf("failed to retrieve stocks: %w", err)
 	}
 
-	return models.PaginatedStocks{
+	result := models.PaginatedStocks{
 		Stocks:     stocks,
 		TotalCount: totalCount,
 		PageSize:   pageSize,
 		Page:       page,
 		TotalPages: totalPages,
-	}, nil
+	}
+
+	// Offer cursors so clients can switch to keyset pagination from any page
+	if params.Filter.SortField == "" && len(stocks) > 0 {
+		if int64(offset+len(stocks)) < totalCount {
+			result.NextCursor = models.NewCursor(stocks[len(stocks)-1], false).Encode()
+		}
+		if page > 1 {
+			result.PrevCursor = models.NewCursor(stocks[0], true).Encode()
+		}
+	}
+
+	return result, nil
+}
+
+// stockListColumns are the columns returned by stock listings
+const stockListColumns = "id, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time, updated_at"
+
+// getStocksAfterCursor fetches the page adjacent to the cursor in (time, id)
+// order. Unlike OFFSET paging, rows inserted by a concurrent sync cannot shift
+// the page boundaries.
+func (r *StockRepository) getStocksAfterCursor(filter models.StockFilter, cursor models.Cursor, pageSize int, totalCount int64, totalPages int) (models.PaginatedStocks, error) {
+	query := applyStockFilter(r.db.Select(stockListColumns), filter)
+	if cursor.Backward {
+		query = query.Where("(time, id) > (?, ?)", cursor.Time, cursor.ID).Order("time ASC, id ASC")
+	} else {
+		query = query.Where("(time, id) < (?, ?)", cursor.Time, cursor.ID).Order("time DESC, id DESC")
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	var stocks []models.Stock
+	if err := query.Limit(pageSize + 1).Find(&stocks); err != nil {
+		return models.PaginatedStocks{}, fmt.Errorf("failed to retrieve stocks: %w", err)
+	}
+
+	hasMore := len(stocks) > pageSize
+	if hasMore {
+		stocks = stocks[:pageSize]
+	}
+
+	if cursor.Backward {
+		for i, j := 0, len(stocks)-1; i < j; i, j = i+1, j-1 {
+			stocks[i], stocks[j] = stocks[j], stocks[i]
+		}
+	}
+
+	result := models.PaginatedStocks{
+		Stocks:     stocks,
+		TotalCount: totalCount,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+
+	if len(stocks) == 0 {
+		return result, nil
+	}
+
+	first := models.NewCursor(stocks[0], true).Encode()
+	last := models.NewCursor(stocks[len(stocks)-1], false).Encode()
+
+	// The page we came from always exists in the opposite direction
+	if cursor.Backward {
+		result.NextCursor = last
+		if hasMore {
+			result.PrevCursor = first
+		}
+	} else {
+		result.PrevCursor = first
+		if hasMore {
+			result.NextCursor = last
+		}
+	}
+
+	return result, nil
+}
+
+// StreamStocks passes every stock matching the filter to fn in batches, so
+// exports never hold the whole result set in memory. Every order is paged by
+// keyset, so saves during the export cannot shift the batch boundaries.
+func (r *StockRepository) StreamStocks(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var last *models.Stock
+	for {
+		query := applyStockFilter(r.db.Select(stockListColumns), filter).Order(stockOrderClause(filter))
+		if last != nil {
+			condition, args := stockKeysetCondition(filter, *last)
+			query = query.Where(condition, args...)
+		}
+
+		var stocks []models.Stock
+		if err := query.Limit(batchSize).Find(&stocks); err != nil {
+			return fmt.Errorf("failed to stream stocks: %w", err)
+		}
+
+		if len(stocks) == 0 {
+			return nil
+		}
+
+		if err := fn(stocks); err != nil {
+			return err
+		}
+
+		if len(stocks) < batchSize {
+			return nil
+		}
+
+		last = &stocks[len(stocks)-1]
+	}
+}
+
+// countStocks returns the number of stocks matching the filter. Unless exact
+// is requested, counts are served from a short-lived cache that is dropped
+// whenever SaveStocks commits new data.
+func (r *StockRepository) countStocks(filter models.StockFilter, exact bool) (int64, error) {
+	filter.SortField, filter.SortDesc = "", false
+	keyBytes, _ := json.Marshal(filter)
+	key := string(keyBytes)
+
+	if !exact {
+		if value, ok := r.countCache.Get(key); ok {
+			if count, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+				return count, nil
+			}
+		}
+	}
+
+	count, err := applyStockFilter(r.db.Model(&models.Stock{}), filter).Count()
+	if err != nil {
+		return 0, err
+	}
+
+	r.countCache.Set(key, []byte(strconv.FormatInt(count, 10)), countCacheTTL)
+
+	return count, nil
+}
+
+// invalidateCounts drops all cached counts and moves the read cache to a new
+// version
+func (r *StockRepository) invalidateCounts() {
+	r.countCache.Clear()
+
+	if r.readCache != nil {
+		r.readCache.Invalidate()
+	}
+}
+
+// targetChangeExpr computes the target price change in percent
+const targetChangeExpr = "(target_to - target_from) / target_from * 100"
+
+// stockSortColumns maps the whitelisted sort fields to SQL expressions
+var stockSortColumns = map[string]string{
+	models.SortByTime:         "time",
+	models.SortByTicker:       "ticker",
+	models.SortByCompany:      "company",
+	models.SortByBrokerage:    "brokerage",
+	models.SortByTargetTo:     "target_to",
+	models.SortByTargetChange: "CASE WHEN target_from > 0 THEN " + targetChangeExpr + " END",
+}
+
+// stockSortKeys render the sort key of a stock as SQL for keyset paging.
+// Targets are bound as exact decimals so the key compares equal to the stored
+// one; ok is false when the key is NULL.
+var stockSortKeys = map[string]func(stock models.Stock) (sql string, args []interface{}, ok bool){
+	models.SortByTime: func(stock models.Stock) (string, []interface{}, bool) {
+		return "?", []interface{}{stock.Time}, true
+	},
+	models.SortByTicker: func(stock models.Stock) (string, []interface{}, bool) {
+		return "?", []interface{}{stock.Ticker}, true
+	},
+	models.SortByCompany: func(stock models.Stock) (string, []interface{}, bool) {
+		return "?", []interface{}{stock.Company}, true
+	},
+	models.SortByBrokerage: func(stock models.Stock) (string, []interface{}, bool) {
+		return "?", []interface{}{stock.Brokerage}, true
+	},
+	models.SortByTargetTo: func(stock models.Stock) (string, []interface{}, bool) {
+		return "?::DECIMAL", []interface{}{decimalArg(stock.TargetTo)}, true
+	},
+	models.SortByTargetChange: func(stock models.Stock) (string, []interface{}, bool) {
+		if stock.TargetFrom <= 0 {
+			return "", nil, false
+		}
+		from, to := decimalArg(stock.TargetFrom), decimalArg(stock.TargetTo)
+		return "(?::DECIMAL - ?::DECIMAL) / ?::DECIMAL * 100", []interface{}{to, from, from}, true
+	},
+}
+
+// decimalArg formats a DECIMAL column read into a float64 back to its exact value
+func decimalArg(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// stockKeysetCondition selects the stocks following last in the order of
+// stockOrderClause, where NULL keys sort last in both directions
+func stockKeysetCondition(filter models.StockFilter, last models.Stock) (string, []interface{}) {
+	column, ok := stockSortColumns[filter.SortField]
+	if !ok {
+		return "(time, id) < (?, ?)", []interface{}{last.Time, last.ID}
+	}
+
+	op := ">"
+	if filter.SortDesc {
+		op = "<"
+	}
+
+	key, keyArgs, ok := stockSortKeys[filter.SortField](last)
+	if !ok {
+		return fmt.Sprintf("%s IS NULL AND id %s ?", column, op), []interface{}{last.ID}
+	}
+
+	condition := fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s ?) OR %[1]s IS NULL)", column, op, key)
+	args := append(append(append([]interface{}{}, keyArgs...), keyArgs...), last.ID)
+	return condition, args
+}
+
+// applyStockFilter adds the WHERE conditions for the filter to the query
+func applyStockFilter(query database.Query, filter models.StockFilter) database.Query {
+	for _, condition := range stockFilterConditions(filter) {
+		query = query.Where(condition.sql, condition.args...)
+	}
+	return query
+}
+
+// sqlCondition is a WHERE condition with its arguments
+type sqlCondition struct {
+	sql  string
+	args []interface{}
+}
+
+// stockFilterConditions builds the conditions of the filter, for the queries
+// written in raw SQL
+func stockFilterConditions(filter models.StockFilter) []sqlCondition {
+	var conditions []sqlCondition
+	where := func(sql string, args ...interface{}) {
+		conditions = append(conditions, sqlCondition{sql: sql, args: args})
+	}
+
+	if len(filter.Tickers) > 0 {
+		where("ticker IN ?", filter.Tickers)
+	}
+	if len(filter.ExcludeTickers) > 0 {
+		where("ticker NOT IN ?", filter.ExcludeTickers)
+	}
+	if len(filter.Brokerages) > 0 {
+		where("brokerage IN ?", filter.Brokerages)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.RatingFrom != "" {
+		where("rating_from = ?", filter.RatingFrom)
+	}
+	if filter.RatingTo != "" {
+		where("rating_to = ?", filter.RatingTo)
+	}
+	if filter.RatingCategory != "" {
+		condition, args := ratingCondition("rating_to", models.LevelsInCategory(filter.RatingCategory))
+		where(condition, args...)
+	}
+	if len(filter.Ratings) > 0 {
+		condition, args := ratingCondition("rating_to", filter.Ratings)
+		where(condition, args...)
+	}
+	if filter.Sector != "" {
+		where("ticker IN (SELECT ticker FROM ticker_sectors WHERE sector = ?)", filter.Sector)
+	}
+	if filter.From != nil {
+		where("time >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		where("time < ?", filter.To.UTC())
+	}
+	if filter.TargetMin != nil {
+		where("target_to >= ?", *filter.TargetMin)
+	}
+	if filter.TargetMax != nil {
+		where("target_to <= ?", *filter.TargetMax)
+	}
+	if filter.TargetChangeMin != nil {
+		where("target_from > 0 AND "+targetChangeExpr+" >= ?", *filter.TargetChangeMin)
+	}
+	if filter.TargetChangeMax != nil {
+		where("target_from > 0 AND "+targetChangeExpr+" <= ?", *filter.TargetChangeMax)
+	}
+	return conditions
+}
+
+// ratingCondition matches the events whose rating in column is on one of the
+// levels, honoring the brokerage overrides of the rating ladder. Unknown and
+// missing ratings match RatingUnknown, matching GetRatingLevel.
+func ratingCondition(column string, levels []models.RatingLevel) (string, []interface{}) {
+	ladder := models.CurrentRatingLadder()
+
+	var inside, outside []string
+	for rating, level := range ladder.Ratings {
+		if slices.Contains(levels, level) {
+			inside = append(inside, rating)
+		} else {
+			outside = append(outside, rating)
+		}
+	}
+	sort.Strings(inside)
+	sort.Strings(outside)
+
+	var condition string
+	var args []interface{}
+	switch {
+	case slices.Contains(levels, models.RatingUnknown) && len(outside) > 0:
+		condition, args = "("+column+" NOT IN ? OR "+column+" IS NULL)", []interface{}{outside}
+	case slices.Contains(levels, models.RatingUnknown):
+		condition = "TRUE"
+	case len(inside) > 0:
+		condition, args = column+" IN ?", []interface{}{inside}
+	default:
+		condition = "FALSE"
+	}
+
+	brokerages := make([]string, 0, len(ladder.BrokerageOverrides))
+	for brokerage := range ladder.BrokerageOverrides {
+		brokerages = append(brokerages, brokerage)
+	}
+	sort.Strings(brokerages)
+
+	// Overrides only matter where they move a rating across the levels
+	var included []string
+	var includedArgs []interface{}
+	for _, brokerage := range brokerages {
+		var excludes, includes []string
+		for rating, level := range ladder.BrokerageOverrides[brokerage] {
+			shared := slices.Contains(levels, ladder.Ratings[rating])
+			overridden := slices.Contains(levels, level)
+			if shared && !overridden {
+				excludes = append(excludes, rating)
+			} else if overridden && !shared {
+				includes = append(includes, rating)
+			}
+		}
+		sort.Strings(excludes)
+		sort.Strings(includes)
+
+		if len(excludes) > 0 {
+			condition += " AND NOT (brokerage = ? AND COALESCE(" + column + ", '') IN ?)"
+			args = append(args, brokerage, excludes)
+		}
+		if len(includes) > 0 {
+			included = append(included, "(brokerage = ? AND "+column+" IN ?)")
+			includedArgs = append(includedArgs, brokerage, includes)
+		}
+	}
+
+	if len(included) == 0 {
+		return condition, args
+	}
+
+	return "((" + condition + ") OR " + strings.Join(included, " OR ") + ")", append(args, includedArgs...)
+}
+
+// stockOrderClause builds the ORDER BY clause, defaulting to newest first.
+// The id tie-breaker keeps pages stable when sort values repeat.
+func stockOrderClause(filter models.StockFilter) string {
+	column, ok := stockSortColumns[filter.SortField]
+	if !ok {
+		return "time DESC, id DESC"
+	}
+
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s NULLS LAST, id %s", column, direction, direction)
 }
 
 // GetStocksByTicker retrieves stocks by ticker with optimized query
 func (r *StockRepository) GetStocksByTicker(ticker string) ([]models.Stock, error) {
 	var stocks []models.Stock
 
-	err := r.db.Select("id, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time, updated_at").
+	err := r.db.Select(stockListColumns).
 		Where("ticker = ?", ticker).
 		Order("time DESC").
 		Find(&stocks)
@@ -124,12 +540,13 @@ func (r *StockRepository) GetStocksByTicker(ticker string) ([]models.Stock, erro
 	return stocks, nil
 }
 
-// GetRecentStocks retrieves the most recent stocks up to the limit
-func (r *StockRepository) GetRecentStocks(limit int) ([]models.Stock, error) {
+// GetRecentStocks retrieves the most recent stocks matching the filter up to
+// the limit
+func (r *StockRepository) GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error) {
 	var stocks []models.Stock
 
 	// Select only the fields needed for recommendations
-	err := r.db.Select("ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time").
+	err := applyStockFilter(r.db.Select("ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time"), filter).
 		Order("time DESC").
 		Limit(limit).
 		Find(&stocks)
@@ -140,3 +557,102 @@ func (r *StockRepository) GetRecentStocks(limit int) ([]models.Stock, error) {
 
 	return stocks, nil
 }
+
+// GetLatestCallsSince retrieves the latest event of every brokerage for every
+// ticker at or after since, among the events matching the filter, newest
+// first. A limit of zero returns every call.
+func (r *StockRepository) GetLatestCallsSince(since time.Time, filter models.StockFilter, limit int) ([]models.Stock, error) {
+	conditions := []string{"time >= ?"}
+	args := []interface{}{since.UTC()}
+
+	for _, condition := range stockFilterConditions(filter) {
+		conditions = append(conditions, "("+condition.sql+")")
+		args = append(args, condition.args...)
+	}
+
+	limitClause := ""
+	if limit > 0 {
+		limitClause = " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var stocks []models.Stock
+	err := r.db.Raw(`
+		SELECT `+stockListColumns+`
+		FROM (
+			SELECT `+stockListColumns+`,
+				row_number() OVER (PARTITION BY ticker, brokerage ORDER BY time DESC, id DESC) AS rank
+			FROM stocks
+			WHERE `+strings.Join(conditions, " AND ")+`
+		) ranked
+		WHERE rank = 1
+		ORDER BY time DESC, id DESC`+limitClause,
+		args...,
+	).Scan(&stocks)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve latest calls: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// likeEscaper escapes LIKE wildcards so user input is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchStocks finds distinct tickers whose ticker starts with the query or whose
+// company name matches it, ranked by match quality, with the latest event for each
+func (r *StockRepository) SearchStocks(query string, limit int) ([]models.StockSearchResult, error) {
+	escaped := likeEscaper.Replace(query)
+	tickerPrefix := strings.ToUpper(escaped) + "%"
+	companyPattern := "%" + escaped + "%"
+
+	var results []models.StockSearchResult
+
+	// Exact ticker matches rank above prefix matches, company names are ranked
+	// by trigram similarity
+	err := r.db.Raw(`
+		SELECT ticker, company, brokerage, action, rating_to, target_to, time, score
+		FROM (
+			SELECT DISTINCT ON (ticker)
+				ticker, company, brokerage, action, rating_to, target_to, time,
+				CASE
+					WHEN ticker = upper(?) THEN 3.0
+					WHEN ticker LIKE ? THEN 2.0
+					ELSE 0.0
+				END + similarity(company, ?) AS score
+			FROM stocks
+			WHERE ticker LIKE ? OR company ILIKE ? OR company % ?
+			ORDER BY ticker, time DESC
+		) matches
+		ORDER BY score DESC, ticker
+		LIMIT ?`,
+		query, tickerPrefix, query, tickerPrefix, companyPattern, query, limit,
+	).Scan(&results)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to search stocks for %q: %w", query, err)
+	}
+
+	return results, nil
+}
+
+// GetStocksChangedAfter returns up to limit stocks matching the filter that
+// were inserted or changed after the position, in (updated_at, id) order. A
+// position without an ID starts right after its time.
+func (r *StockRepository) GetStocksChangedAfter(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+	query := applyStockFilter(r.db.Select(stockListColumns+", created_at"), filter)
+	if after.ID == "" {
+		query = query.Where("updated_at > ?", after.Time.UTC())
+	} else {
+		query = query.Where("(updated_at, id) > (?, ?)", after.Time.UTC(), after.ID)
+	}
+
+	var stocks []models.Stock
+	err := query.Order("updated_at ASC, id ASC").Limit(limit).Find(&stocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve changed stocks: %w", err)
+	}
+
+	return stocks, nil
+}
@@ -1,11 +1,16 @@
 package repository_test
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"stonks-api/cmd/cache"
 	"stonks-api/cmd/database"
 	"stonks-api/internal/stocks/mocks"
 	"stonks-api/internal/stocks/models"
 	repository "stonks-api/internal/stocks/repositories"
+	"strings"
 	"testing"
 	"time"
 )
@@ -202,7 +207,7 @@ func TestGetRecentStocks(t *testing.T) {
 		mockDB := mocks.CreateMockDBWithStocks(expectedStocks)
 		repo := repository.NewStockRepository(mockDB)
 
-		stocks, err := repo.GetRecentStocks(10)
+		stocks, err := repo.GetRecentStocks(models.StockFilter{}, 10)
 
 		if err != nil {
 			t.Errorf("Expected no error but got: %v", err)
@@ -224,7 +229,7 @@ func TestGetRecentStocks(t *testing.T) {
 
 		repo := repository.NewStockRepository(mockDB)
 
-		stocks, err := repo.GetRecentStocks(10)
+		stocks, err := repo.GetRecentStocks(models.StockFilter{}, 10)
 
 		// Check that either we got an error OR we got nil/empty results
 		if err == nil && len(stocks) > 0 {
@@ -232,3 +237,921 @@ func TestGetRecentStocks(t *testing.T) {
 		}
 	})
 }
+
+func TestGetLatestCallsSince(t *testing.T) {
+	// The latest call of each brokerage since the given time is requested
+	t.Run("successful retrieval", func(t *testing.T) {
+		since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+		var gotSQL string
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotSQL, gotArgs = sql, values
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						*dest.(*[]models.Stock) = []models.Stock{{Ticker: "AAPL", Brokerage: "Example Brokerage"}}
+						return nil
+					},
+				}
+			},
+		}
+
+		stocks, err := repository.NewStockRepository(mockDB).GetLatestCallsSince(since, models.StockFilter{}, 0)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(stocks) != 1 || stocks[0].Ticker != "AAPL" {
+			t.Errorf("Expected the AAPL call but got %v", stocks)
+		}
+
+		if !strings.Contains(gotSQL, "PARTITION BY ticker, brokerage") {
+			t.Errorf("Expected one call per ticker and brokerage but got: %s", gotSQL)
+		}
+
+		if len(gotArgs) != 1 || !reflect.DeepEqual(gotArgs[0], since.UTC()) {
+			t.Errorf("Expected the time in UTC but got %v", gotArgs)
+		}
+	})
+
+	// Filters and the limit are added to the query
+	t.Run("filters and limit", func(t *testing.T) {
+		var gotSQL string
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotSQL, gotArgs = sql, values
+				return &database.MockQuery{ScanFn: func(dest interface{}) error { return nil }}
+			},
+		}
+
+		filter := models.StockFilter{
+			Tickers:        []string{"AAPL"},
+			ExcludeTickers: []string{"TSLA"},
+			Brokerages:     []string{"Example Brokerage"},
+			Sector:         "Technology",
+			RatingTo:       "Buy",
+		}
+		if _, err := repository.NewStockRepository(mockDB).GetLatestCallsSince(time.Now(), filter, 50); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		for _, fragment := range []string{"ticker IN ?", "ticker NOT IN ?", "brokerage IN ?", "ticker_sectors", "rating_to = ?", "LIMIT ?"} {
+			if !strings.Contains(gotSQL, fragment) {
+				t.Errorf("Expected %q in the query but got: %s", fragment, gotSQL)
+			}
+		}
+
+		if len(gotArgs) != 7 || gotArgs[6] != 50 {
+			t.Errorf("Expected the filter values and limit as arguments but got %v", gotArgs)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		mockDB := database.NewMockDatabaseWithError(errors.New("database error"))
+
+		_, err := repository.NewStockRepository(mockDB).GetLatestCallsSince(time.Now(), models.StockFilter{}, 0)
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestSearchStocks(t *testing.T) {
+	// Successful search
+	t.Run("successful search", func(t *testing.T) {
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotArgs = values
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						resultsPtr := dest.(*[]models.StockSearchResult)
+						*resultsPtr = []models.StockSearchResult{
+							{Ticker: "AAPL", Company: "Apple Inc.", Score: 3.5},
+						}
+						return nil
+					},
+				}
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		results, err := repo.SearchStocks("aa_", 10)
+
+		if err != nil {
+			t.Errorf("Expected no error but got: %v", err)
+		}
+
+		if len(results) != 1 || results[0].Ticker != "AAPL" {
+			t.Errorf("Expected AAPL result but got %v", results)
+		}
+
+		// LIKE wildcards in the query must be escaped
+		if len(gotArgs) < 2 || gotArgs[1] != `AA\_%` {
+			t.Errorf("Expected escaped ticker prefix but got %v", gotArgs)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		mockDB := database.NewMockDatabaseWithError(errors.New("database error"))
+
+		repo := repository.NewStockRepository(mockDB)
+
+		_, err := repo.SearchStocks("apple", 10)
+
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestGetAllStocksFilters(t *testing.T) {
+	// Filter conditions and sort order are applied to the query
+	t.Run("filters applied", func(t *testing.T) {
+		var conditions []string
+		var order interface{}
+
+		var query *database.MockQuery
+		query = &database.MockQuery{
+			WhereFn: func(q interface{}, args ...interface{}) database.Query {
+				conditions = append(conditions, q.(string))
+				return query
+			},
+			OrderFn: func(value interface{}) database.Query {
+				order = value
+				return query
+			},
+			CountFn: func() (int64, error) {
+				return 1, nil
+			},
+		}
+
+		mockDB := &database.MockDatabase{
+			ModelFn: func(value interface{}) database.Query {
+				return query
+			},
+			SelectFn: func(q interface{}, args ...interface{}) database.Query {
+				return query
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		targetMin := 100.0
+		params := models.PaginationParams{
+			Page:     1,
+			PageSize: 10,
+			Filter: models.StockFilter{
+				Tickers:   []string{"AAPL"},
+				TargetMin: &targetMin,
+				SortField: models.SortByTargetTo,
+				SortDesc:  true,
+			},
+		}
+
+		result, err := repo.GetAllStocks(params)
+
+		if err != nil {
+			t.Errorf("Expected no error but got: %v", err)
+		}
+
+		if result.TotalCount != 1 {
+			t.Errorf("Expected total count 1 but got %d", result.TotalCount)
+		}
+
+		// Count and select queries both get the two conditions
+		if len(conditions) != 4 {
+			t.Errorf("Expected 4 conditions but got %v", conditions)
+		}
+
+		if order != "target_to DESC NULLS LAST, id DESC" {
+			t.Errorf("Expected target_to descending order but got %v", order)
+		}
+	})
+}
+
+func TestGetAllStocksRatingFilters(t *testing.T) {
+	ladder := models.DefaultRatingLadder()
+	ladder.BrokerageOverrides["Oppenheimer"] = map[string]models.RatingLevel{"Outperform": models.RatingStrongBuy}
+	ladder.BrokerageOverrides["Example Securities"] = map[string]models.RatingLevel{"Buy": models.RatingHold}
+	models.SetRatingLadder(ladder)
+	defer models.SetRatingLadder(models.DefaultRatingLadder())
+
+	filterCondition := func(filter models.StockFilter) (string, []interface{}) {
+		var condition string
+		var conditionArgs []interface{}
+
+		var query *database.MockQuery
+		query = &database.MockQuery{
+			WhereFn: func(q interface{}, args ...interface{}) database.Query {
+				condition, conditionArgs = q.(string), args
+				return query
+			},
+		}
+		mockDB := &database.MockDatabase{
+			ModelFn:  func(value interface{}) database.Query { return query },
+			SelectFn: func(q interface{}, args ...interface{}) database.Query { return query },
+		}
+
+		if _, err := repository.NewStockRepository(mockDB).GetAllStocks(models.PaginationParams{Page: 1, PageSize: 10, Filter: filter}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		return condition, conditionArgs
+	}
+
+	// Overrides moving a rating onto the level are added
+	t.Run("overrides included", func(t *testing.T) {
+		condition, args := filterCondition(models.StockFilter{Ratings: []models.RatingLevel{models.RatingStrongBuy}})
+
+		if condition != "((rating_to IN ?) OR (brokerage = ? AND rating_to IN ?))" {
+			t.Errorf("Expected the Oppenheimer override to be included but got %q", condition)
+		}
+
+		if len(args) != 3 || args[1] != "Oppenheimer" || fmt.Sprint(args[2]) != "[Outperform]" ||
+			fmt.Sprint(args[0]) != "[Conviction Buy Strong Buy Strong-Buy Top Pick]" {
+			t.Errorf("Expected the strong buy ratings and the override but got %v", args)
+		}
+	})
+
+	// Overrides moving a rating off the category are excluded
+	t.Run("overrides excluded", func(t *testing.T) {
+		condition, args := filterCondition(models.StockFilter{RatingCategory: models.RatingCategoryPositive})
+
+		if condition != "rating_to IN ? AND NOT (brokerage = ? AND COALESCE(rating_to, '') IN ?)" {
+			t.Errorf("Expected the Example Securities override to be excluded but got %q", condition)
+		}
+
+		if len(args) != 3 || args[1] != "Example Securities" || fmt.Sprint(args[2]) != "[Buy]" {
+			t.Errorf("Expected the excluded override but got %v", args)
+		}
+	})
+
+	// Unknown ratings count as Neutral
+	t.Run("neutral", func(t *testing.T) {
+		condition, _ := filterCondition(models.StockFilter{RatingCategory: models.RatingCategoryNeutral})
+
+		if condition != "(((rating_to NOT IN ? OR rating_to IS NULL)) OR (brokerage = ? AND rating_to IN ?))" {
+			t.Errorf("Expected unknown ratings and the Example Securities override but got %q", condition)
+		}
+	})
+}
+
+func TestGetAllStocksCursor(t *testing.T) {
+	base := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	rows := []models.Stock{
+		{ID: "3", Ticker: "AAPL", Time: base.Add(-1 * time.Hour)},
+		{ID: "2", Ticker: "MSFT", Time: base.Add(-2 * time.Hour)},
+		{ID: "1", Ticker: "GOOG", Time: base.Add(-3 * time.Hour)},
+	}
+
+	newMockDB := func(condition *string) *database.MockDatabase {
+		var query *database.MockQuery
+		query = &database.MockQuery{
+			WhereFn: func(q interface{}, args ...interface{}) database.Query {
+				*condition = q.(string)
+				return query
+			},
+			FindFn: func(dest interface{}, conditions ...interface{}) error {
+				*dest.(*[]models.Stock) = append([]models.Stock(nil), rows...)
+				return nil
+			},
+			CountFn: func() (int64, error) {
+				return 10, nil
+			},
+		}
+
+		return &database.MockDatabase{
+			ModelFn:  func(value interface{}) database.Query { return query },
+			SelectFn: func(q interface{}, args ...interface{}) database.Query { return query },
+		}
+	}
+
+	// Forward page with more rows available
+	t.Run("forward", func(t *testing.T) {
+		var condition string
+		repo := repository.NewStockRepository(newMockDB(&condition))
+
+		cursor := models.Cursor{Time: base, ID: "4"}
+		result, err := repo.GetAllStocks(models.PaginationParams{PageSize: 2, Cursor: &cursor})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if condition != "(time, id) < (?, ?)" {
+			t.Errorf("Expected keyset condition but got %q", condition)
+		}
+
+		if len(result.Stocks) != 2 || result.Stocks[0].ID != "3" {
+			t.Errorf("Expected first two rows but got %v", result.Stocks)
+		}
+
+		next, err := models.DecodeCursor(result.NextCursor)
+		if err != nil || next.ID != "2" || next.Backward {
+			t.Errorf("Expected next cursor at row 2 but got %+v (%v)", next, err)
+		}
+
+		prev, err := models.DecodeCursor(result.PrevCursor)
+		if err != nil || prev.ID != "3" || !prev.Backward {
+			t.Errorf("Expected prev cursor at row 3 but got %+v (%v)", prev, err)
+		}
+	})
+
+	// Backward pages are returned newest first
+	t.Run("backward", func(t *testing.T) {
+		var condition string
+		repo := repository.NewStockRepository(newMockDB(&condition))
+
+		cursor := models.Cursor{Time: base.Add(-4 * time.Hour), ID: "0", Backward: true}
+		result, err := repo.GetAllStocks(models.PaginationParams{PageSize: 5, Cursor: &cursor})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if condition != "(time, id) > (?, ?)" {
+			t.Errorf("Expected backward keyset condition but got %q", condition)
+		}
+
+		if len(result.Stocks) != 3 || result.Stocks[0].ID != "1" {
+			t.Errorf("Expected reversed rows but got %v", result.Stocks)
+		}
+
+		if result.PrevCursor != "" {
+			t.Errorf("Expected no prev cursor on the first page but got %s", result.PrevCursor)
+		}
+	})
+}
+
+func TestGetAllStocksCountCache(t *testing.T) {
+	t.Run("cached until save", func(t *testing.T) {
+		counts := 0
+		query := &database.MockQuery{
+			CountFn: func() (int64, error) {
+				counts++
+				return 5, nil
+			},
+		}
+
+		mockDB := &database.MockDatabase{
+			ModelFn:  func(value interface{}) database.Query { return query },
+			SelectFn: func(q interface{}, args ...interface{}) database.Query { return query },
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+		params := models.PaginationParams{Page: 1, PageSize: 10}
+
+		repo.GetAllStocks(params)
+		repo.GetAllStocks(params)
+		if counts != 1 {
+			t.Errorf("Expected 1 count query but got %d", counts)
+		}
+
+		params.ExactCount = true
+		repo.GetAllStocks(params)
+		if counts != 2 {
+			t.Errorf("Expected exact count to bypass the cache, got %d count queries", counts)
+		}
+
+		if err := repo.SaveStocks([]models.Stock{{Ticker: "AAPL", Time: time.Now()}}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		params.ExactCount = false
+		repo.GetAllStocks(params)
+		if counts != 3 {
+			t.Errorf("Expected save to invalidate the cache, got %d count queries", counts)
+		}
+	})
+
+	t.Run("read cache versioned on save", func(t *testing.T) {
+		readCache := cache.NewReadCache(cache.NewMemoryBackend(10), time.Minute)
+		readCache.Set("/stocks", []byte("cached"))
+		version := readCache.Version()
+
+		repo := repository.NewStockRepository(&database.MockDatabase{})
+		repo.SetReadCache(readCache)
+
+		if err := repo.SaveStocks([]models.Stock{{Ticker: "AAPL", Time: time.Now()}}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if _, ok := readCache.Get("/stocks"); ok {
+			t.Errorf("Expected save to drop cached responses")
+		}
+
+		if readCache.Version() == version {
+			t.Errorf("Expected save to move the read cache to a new version")
+		}
+	})
+}
+
+func TestGetTickerSummary(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Consensus computed from the latest call of each brokerage
+	t.Run("summary computed", func(t *testing.T) {
+		latest := []models.Stock{
+			{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "A", Action: "upgraded by", RatingTo: "Buy", TargetTo: 200, Time: now.Add(-1 * time.Hour)},
+			{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "B", Action: "reiterated by", RatingTo: "Outperform", TargetTo: 180, Time: now.Add(-2 * time.Hour)},
+			{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "C", Action: "downgraded by", RatingTo: "Hold", TargetTo: 150, Time: now.Add(-3 * time.Hour)},
+			{Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "D", Action: "reiterated by", RatingTo: "Buy", TargetTo: 0, Time: now.Add(-4 * time.Hour)},
+		}
+
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						if stocks, ok := dest.(*[]models.Stock); ok {
+							*stocks = latest
+							return nil
+						}
+						// Event counts
+						counts := reflect.ValueOf(dest).Elem()
+						counts.FieldByName("Upgrades").SetInt(2)
+						counts.FieldByName("Downgrades").SetInt(1)
+						counts.FieldByName("TotalEvents").SetInt(7)
+						return nil
+					},
+				}
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		summary, err := repo.GetTickerSummary("AAPL", now.AddDate(0, 0, -90), now)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if summary.Upgrades != 2 || summary.Downgrades != 1 || summary.TotalEvents != 7 {
+			t.Errorf("Unexpected event counts: %+v", summary)
+		}
+
+		if summary.CoveringBrokerages != 4 {
+			t.Errorf("Expected 4 covering brokerages but got %d", summary.CoveringBrokerages)
+		}
+
+		if summary.RatingDistribution[models.RatingCategoryPositive] != 3 || summary.RatingDistribution[models.RatingCategoryNeutral] != 1 {
+			t.Errorf("Unexpected rating distribution: %v", summary.RatingDistribution)
+		}
+
+		if summary.ConsensusRating != models.RatingCategoryPositive {
+			t.Errorf("Expected Positive consensus but got %s", summary.ConsensusRating)
+		}
+
+		// Missing targets are ignored
+		if summary.Target == nil || summary.Target.Count != 3 || summary.Target.Median != 180 ||
+			summary.Target.High != 200 || summary.Target.Low != 150 {
+			t.Errorf("Unexpected target stats: %+v", summary.Target)
+		}
+
+		if summary.LatestRatings[0].Brokerage != "A" {
+			t.Errorf("Expected most recent call first but got %s", summary.LatestRatings[0].Brokerage)
+		}
+
+		if summary.LatestRatings[0].Rating != models.RatingBuy || summary.LatestRatings[2].Rating != models.RatingHold {
+			t.Errorf("Expected the normalized ratings but got %+v", summary.LatestRatings)
+		}
+	})
+
+	// No events in the window
+	t.Run("no events", func(t *testing.T) {
+		repo := repository.NewStockRepository(&database.MockDatabase{})
+
+		summary, err := repo.GetTickerSummary("AAPL", now.AddDate(0, 0, -90), now)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if summary.TotalEvents != 0 || len(summary.LatestRatings) != 0 {
+			t.Errorf("Expected empty summary but got %+v", summary)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		repo := repository.NewStockRepository(database.NewMockDatabaseWithError(errors.New("database error")))
+
+		_, err := repo.GetTickerSummary("AAPL", now.AddDate(0, 0, -90), now)
+
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestGetBrokerageStats(t *testing.T) {
+	// Ratio computed and sort column whitelisted
+	t.Run("successful aggregation", func(t *testing.T) {
+		var gotSQL string
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotSQL = sql
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						*dest.(*[]models.BrokerageStats) = []models.BrokerageStats{
+							{Brokerage: "A", Calls: 10, Upgrades: 6, Downgrades: 2},
+							{Brokerage: "B", Calls: 4, Upgrades: 1, Downgrades: 0},
+						}
+						return nil
+					},
+				}
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		stats, err := repo.GetBrokerageStats(models.BrokerageQuery{
+			Since:     time.Now().AddDate(0, 0, -90),
+			Until:     time.Now(),
+			SortField: models.BrokerageSortByTickers,
+			SortAsc:   true,
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if stats[0].UpgradeDowngradeRatio == nil || *stats[0].UpgradeDowngradeRatio != 3 {
+			t.Errorf("Expected ratio 3 but got %v", stats[0].UpgradeDowngradeRatio)
+		}
+
+		if stats[1].UpgradeDowngradeRatio != nil {
+			t.Errorf("Expected no ratio without downgrades but got %v", *stats[1].UpgradeDowngradeRatio)
+		}
+
+		if !strings.Contains(gotSQL, "ORDER BY tickers_covered ASC") {
+			t.Errorf("Expected tickers_covered ascending order in query: %s", gotSQL)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		repo := repository.NewStockRepository(database.NewMockDatabaseWithError(errors.New("database error")))
+
+		_, err := repo.GetBrokerageStats(models.BrokerageQuery{})
+
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestGetSentimentSeries(t *testing.T) {
+	// Net sentiment computed from the bucket counts
+	t.Run("successful aggregation", func(t *testing.T) {
+		var gotArgs []interface{}
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				gotArgs = values
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						*dest.(*[]models.SentimentBucket) = []models.SentimentBucket{
+							{Upgrades: 3, TargetRaises: 1, Downgrades: 1, TargetCuts: 1, TotalEvents: 8},
+							{Initiations: 2, TotalEvents: 2},
+						}
+						return nil
+					},
+				}
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		buckets, err := repo.GetSentimentSeries(models.SentimentQuery{
+			Interval: models.IntervalWeek,
+			Location: time.UTC,
+			Since:    time.Now().AddDate(0, 0, -30),
+			Until:    time.Now(),
+			Tickers:  []string{"AAPL"},
+			Sector:   "Technology",
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if buckets[0].NetSentiment != 1.0/3.0 {
+			t.Errorf("Expected net sentiment 1/3 but got %f", buckets[0].NetSentiment)
+		}
+
+		if buckets[1].NetSentiment != 0 {
+			t.Errorf("Expected neutral net sentiment but got %f", buckets[1].NetSentiment)
+		}
+
+		if gotArgs[1] != models.IntervalWeek || gotArgs[len(gotArgs)-1] != "Technology" {
+			t.Errorf("Unexpected query arguments: %v", gotArgs)
+		}
+	})
+
+	// Unsupported intervals never reach the database
+	t.Run("invalid interval", func(t *testing.T) {
+		repo := repository.NewStockRepository(&database.MockDatabase{})
+
+		_, err := repo.GetSentimentSeries(models.SentimentQuery{Interval: "minute"})
+
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestStreamStocks(t *testing.T) {
+	base := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	rows := []models.Stock{
+		{ID: "3", Ticker: "AAPL", Time: base.Add(-1 * time.Hour)},
+		{ID: "2", Ticker: "MSFT", Time: base.Add(-2 * time.Hour)},
+		{ID: "1", Ticker: "GOOG", Time: base.Add(-3 * time.Hour)},
+	}
+
+	// Batches are paged by keyset until a short batch ends the stream
+	t.Run("keyset batches", func(t *testing.T) {
+		var afterIDs []string
+		var query *database.MockQuery
+		remaining := rows
+		limit := 0
+		query = &database.MockQuery{
+			WhereFn: func(q interface{}, args ...interface{}) database.Query {
+				if q == "(time, id) < (?, ?)" {
+					afterIDs = append(afterIDs, args[1].(string))
+				}
+				return query
+			},
+			LimitFn: func(l int) database.Query {
+				limit = l
+				return query
+			},
+			FindFn: func(dest interface{}, conditions ...interface{}) error {
+				n := min(limit, len(remaining))
+				*dest.(*[]models.Stock) = append([]models.Stock(nil), remaining[:n]...)
+				remaining = remaining[n:]
+				return nil
+			},
+		}
+		mockDB := &database.MockDatabase{
+			SelectFn: func(q interface{}, args ...interface{}) database.Query { return query },
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		var batches [][]models.Stock
+		err := repo.StreamStocks(models.StockFilter{}, 2, func(batch []models.Stock) error {
+			batches = append(batches, batch)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+			t.Errorf("Expected batches of 2 and 1 rows but got %v", batches)
+		}
+
+		if len(afterIDs) != 1 || afterIDs[0] != "2" {
+			t.Errorf("Expected the second batch to start after row 2 but got %v", afterIDs)
+		}
+	})
+
+	// Explicit sorts resume after the sort key and id of the last row instead
+	// of an offset, and NULL keys are paged by id at the end
+	t.Run("keyset batches for explicit sorts", func(t *testing.T) {
+		sorted := []models.Stock{
+			{ID: "1", Ticker: "AAPL", TargetFrom: 100, TargetTo: 110.5},
+			{ID: "2", Ticker: "MSFT", TargetFrom: 0, TargetTo: 50},
+			{ID: "3", Ticker: "GOOG", TargetFrom: 0, TargetTo: 60},
+		}
+		var conditions []string
+		var conditionArgs [][]interface{}
+		var query *database.MockQuery
+		remaining := sorted
+		limit := 0
+		query = &database.MockQuery{
+			WhereFn: func(q interface{}, args ...interface{}) database.Query {
+				if condition := q.(string); strings.Contains(condition, "IS NULL") {
+					conditions = append(conditions, condition)
+					conditionArgs = append(conditionArgs, args)
+				}
+				return query
+			},
+			LimitFn: func(l int) database.Query {
+				limit = l
+				return query
+			},
+			OffsetFn: func(offset int) database.Query {
+				t.Errorf("Expected no offset but got %d", offset)
+				return query
+			},
+			FindFn: func(dest interface{}, conditions ...interface{}) error {
+				n := min(limit, len(remaining))
+				*dest.(*[]models.Stock) = append([]models.Stock(nil), remaining[:n]...)
+				remaining = remaining[n:]
+				return nil
+			},
+		}
+		mockDB := &database.MockDatabase{
+			SelectFn: func(q interface{}, args ...interface{}) database.Query { return query },
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		filter := models.StockFilter{SortField: models.SortByTargetChange, SortDesc: true}
+		rows := 0
+		err := repo.StreamStocks(filter, 1, func(batch []models.Stock) error {
+			rows += len(batch)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if rows != 3 || len(conditions) != 3 {
+			t.Fatalf("Expected 3 rows paged by 3 keyset conditions but got %d rows and %v", rows, conditions)
+		}
+
+		if !strings.Contains(conditions[0], "< (?::DECIMAL - ?::DECIMAL) / ?::DECIMAL * 100") {
+			t.Errorf("Expected the first batch to continue below its target change but got %s", conditions[0])
+		}
+		if want := []interface{}{"110.5", "100", "100", "110.5", "100", "100", "1"}; !reflect.DeepEqual(conditionArgs[0], want) {
+			t.Errorf("Expected exact decimal arguments %v but got %v", want, conditionArgs[0])
+		}
+
+		if !strings.HasSuffix(conditions[1], "IS NULL AND id < ?") || !reflect.DeepEqual(conditionArgs[1], []interface{}{"2"}) {
+			t.Errorf("Expected rows without a target change to be paged by id but got %s %v", conditions[1], conditionArgs[1])
+		}
+	})
+
+	// Errors from the callback stop the stream
+	t.Run("callback error", func(t *testing.T) {
+		mockDB := &database.MockDatabase{
+			SelectFn: func(q interface{}, args ...interface{}) database.Query {
+				return mocks.CreateMockQueryChain(rows)
+			},
+		}
+
+		repo := repository.NewStockRepository(mockDB)
+
+		calls := 0
+		err := repo.StreamStocks(models.StockFilter{}, 1, func(batch []models.Stock) error {
+			calls++
+			return errors.New("client went away")
+		})
+
+		if err == nil || calls != 1 {
+			t.Errorf("Expected the stream to stop after one batch but got %d calls and error %v", calls, err)
+		}
+	})
+}
+
+func TestRatingMappingsRepository(t *testing.T) {
+	// Stored levels are decoded with their category
+	t.Run("get mappings", func(t *testing.T) {
+		mockDB := &database.MockDatabase{
+			RawFn: func(sql string, values ...interface{}) database.Query {
+				return &database.MockQuery{
+					ScanFn: func(dest interface{}) error {
+						return json.Unmarshal([]byte(`[{"Rating":"Accumulate","Level":"buy"}]`), dest)
+					},
+				}
+			},
+		}
+
+		mappings, err := repository.NewStockRepository(mockDB).GetRatingMappings()
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(mappings) != 1 || mappings[0].Level != models.RatingBuy || mappings[0].Category != models.RatingCategoryPositive {
+			t.Errorf("Expected Accumulate mapped to buy but got %+v", mappings)
+		}
+	})
+
+	// Saving a mapping drops the rating from the unmapped ones, the caches are
+	// left to InvalidateRatings once the ladder changed
+	t.Run("save mapping", func(t *testing.T) {
+		var statements []string
+		var mappingArgs []interface{}
+		mockDB := &database.MockDatabase{
+			ExecFn: func(sql string, values ...interface{}) error {
+				if len(statements) == 0 {
+					mappingArgs = values
+				}
+				statements = append(statements, sql)
+				return nil
+			},
+		}
+
+		readCache := cache.NewReadCache(cache.NewMemoryBackend(10), time.Minute)
+		version := readCache.Version()
+		repo := repository.NewStockRepository(mockDB)
+		repo.SetReadCache(readCache)
+
+		mapping := models.RatingMapping{Rating: "Accumulate", Level: models.RatingBuy, UpdatedAt: time.Now()}
+		if err := repo.SaveRatingMapping(mapping); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(statements) != 2 || !strings.Contains(statements[1], "DELETE FROM unmapped_ratings") {
+			t.Errorf("Expected the mapping upsert and the unmapped rating delete but got %v", statements)
+		}
+
+		if len(mappingArgs) != 3 || mappingArgs[0] != "Accumulate" || mappingArgs[1] != "buy" {
+			t.Errorf("Expected the rating and level name as arguments but got %v", mappingArgs)
+		}
+
+		if readCache.Version() != version {
+			t.Errorf("Expected the read cache to keep its version")
+		}
+
+		repo.InvalidateRatings()
+		if readCache.Version() == version {
+			t.Errorf("Expected the read cache to move to a new version")
+		}
+	})
+
+	// Only the events carrying the given ratings are read
+	t.Run("record unmapped", func(t *testing.T) {
+		var statements []string
+		var recordArgs []interface{}
+		mockDB := &database.MockDatabase{
+			ExecFn: func(sql string, values ...interface{}) error {
+				if len(statements) == 0 {
+					recordArgs = values
+				}
+				statements = append(statements, sql)
+				return nil
+			},
+		}
+		repo := repository.NewStockRepository(mockDB)
+
+		if err := repo.RecordUnmappedRatings(nil); err != nil || len(statements) != 0 {
+			t.Errorf("Expected no statements without ratings but got %v, %v", statements, err)
+		}
+
+		ratings := []string{"Speculative Buy"}
+		if err := repo.RecordUnmappedRatings(ratings); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(statements) != 2 || !strings.Contains(statements[0], "WHERE rating_from IN ?") ||
+			!strings.Contains(statements[0], "WHERE rating_to IN ?") || !strings.Contains(statements[1], "rating IN ?") {
+			t.Errorf("Expected the upsert and delete limited to the ratings but got %v", statements)
+		}
+
+		if len(recordArgs) != 4 || !reflect.DeepEqual(recordArgs[1], ratings) || !reflect.DeepEqual(recordArgs[2], ratings) {
+			t.Errorf("Expected the ratings as arguments but got %v", recordArgs)
+		}
+	})
+
+	// Unmapped ratings are recomputed from both rating columns
+	t.Run("refresh unmapped", func(t *testing.T) {
+		var statements []string
+		var refreshArgs []interface{}
+		mockDB := &database.MockDatabase{
+			ExecFn: func(sql string, values ...interface{}) error {
+				if len(statements) == 0 {
+					refreshArgs = values
+				}
+				statements = append(statements, sql)
+				return nil
+			},
+		}
+
+		if err := repository.NewStockRepository(mockDB).RefreshUnmappedRatings(); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(statements) != 2 || !strings.Contains(statements[0], "rating NOT IN ?") ||
+			!strings.Contains(statements[0], "UNION ALL") || !strings.Contains(statements[1], "updated_at < ?") {
+			t.Errorf("Expected the unmapped ratings upsert and stale rows delete but got %v", statements)
+		}
+
+		if len(refreshArgs) != 2 || !reflect.DeepEqual(refreshArgs[1], models.KnownRatings()) {
+			t.Errorf("Expected the refresh time and known ratings as arguments but got %v", refreshArgs)
+		}
+	})
+
+	// Database error
+	t.Run("database error", func(t *testing.T) {
+		mockDB := database.NewMockDatabaseWithError(errors.New("database error"))
+		repo := repository.NewStockRepository(mockDB)
+
+		if err := repo.RefreshUnmappedRatings(); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+
+		if _, err := repo.GetUnmappedRatings(10); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
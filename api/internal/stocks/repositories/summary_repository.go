@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"stonks-api/internal/stocks/models"
+	"time"
+)
+
+// consensusThreshold is the mean category value needed to call a positive or
+// negative consensus, otherwise the consensus is neutral
+const consensusThreshold = 1.0 / 3.0
+
+// eventCounts holds the per-action event counts of a ticker
+type eventCounts struct {
+	Upgrades    int
+	Downgrades  int
+	TotalEvents int
+}
+
+// GetTickerSummary computes the analyst consensus on a ticker from the events
+// between since and until, using the latest call of each brokerage
+func (r *StockRepository) GetTickerSummary(ticker string, since, until time.Time) (models.TickerSummary, error) {
+	summary := models.TickerSummary{
+		Ticker:             ticker,
+		WindowStart:        since.UTC(),
+		WindowEnd:          until.UTC(),
+		LatestRatings:      []models.BrokerageRating{},
+		RatingDistribution: map[string]int{},
+	}
+
+	var counts eventCounts
+	err := r.db.Raw(`
+		SELECT
+			count(*) FILTER (WHERE action = ?) AS upgrades,
+			count(*) FILTER (WHERE action = ?) AS downgrades,
+			count(*) AS total_events
+		FROM stocks
+		WHERE ticker = ? AND time >= ? AND time < ?`,
+		models.ActionUpgraded, models.ActionDowngraded, ticker, since.UTC(), until.UTC(),
+	).Scan(&counts)
+	if err != nil {
+		return summary, fmt.Errorf("failed to count events for ticker %s: %w", ticker, err)
+	}
+
+	summary.Upgrades = counts.Upgrades
+	summary.Downgrades = counts.Downgrades
+	summary.TotalEvents = counts.TotalEvents
+
+	if counts.TotalEvents == 0 {
+		return summary, nil
+	}
+
+	var latest []models.Stock
+	err = r.db.Raw(`
+		SELECT DISTINCT ON (brokerage)
+			ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time
+		FROM stocks
+		WHERE ticker = ? AND time >= ? AND time < ?
+		ORDER BY brokerage, time DESC`,
+		ticker, since.UTC(), until.UTC(),
+	).Scan(&latest)
+	if err != nil {
+		return summary, fmt.Errorf("failed to retrieve latest ratings for ticker %s: %w", ticker, err)
+	}
+
+	applyLatestRatings(&summary, latest)
+
+	return summary, nil
+}
+
+// applyLatestRatings fills the consensus, distribution and target statistics
+// of the summary from the latest call of each brokerage
+func applyLatestRatings(summary *models.TickerSummary, latest []models.Stock) {
+	var categoryTotal float64
+	var targets []float64
+	var newest time.Time
+
+	for _, stock := range latest {
+		level := models.GetRatingLevel(stock.Brokerage, stock.RatingTo)
+		category := level.Category()
+
+		summary.LatestRatings = append(summary.LatestRatings, models.BrokerageRating{
+			Brokerage:      stock.Brokerage,
+			Action:         stock.Action,
+			RatingFrom:     stock.RatingFrom,
+			RatingTo:       stock.RatingTo,
+			RatingCategory: category,
+			Rating:         level,
+			TargetFrom:     stock.TargetFrom,
+			TargetTo:       stock.TargetTo,
+			Time:           stock.Time,
+		})
+
+		summary.RatingDistribution[category]++
+		categoryTotal += categoryValue(category)
+
+		if stock.TargetTo > 0 {
+			targets = append(targets, stock.TargetTo)
+		}
+
+		// Report the company name as of the most recent event
+		if stock.Time.After(newest) {
+			newest = stock.Time
+			summary.Company = stock.Company
+		}
+	}
+
+	// Most recent calls first
+	sort.Slice(summary.LatestRatings, func(i, j int) bool {
+		return summary.LatestRatings[i].Time.After(summary.LatestRatings[j].Time)
+	})
+
+	summary.CoveringBrokerages = len(latest)
+	if len(latest) > 0 {
+		summary.ConsensusScore = categoryTotal / float64(len(latest))
+	}
+	summary.ConsensusRating = consensusCategory(summary.ConsensusScore)
+	summary.Target = models.NewTargetStats(targets)
+}
+
+// categoryValue maps a rating category onto -1, 0 or 1
+func categoryValue(category string) float64 {
+	switch category {
+	case models.RatingCategoryPositive:
+		return 1
+	case models.RatingCategoryNegative:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// consensusCategory turns a mean category value back into a category
+func consensusCategory(score float64) string {
+	switch {
+	case score >= consensusThreshold:
+		return models.RatingCategoryPositive
+	case score <= -consensusThreshold:
+		return models.RatingCategoryNegative
+	default:
+		return models.RatingCategoryNeutral
+	}
+}
+
+// GetTargetEvents returns the price target events of a ticker between since
+// and until in ascending time order, preceded by the last target each
+// brokerage set before since so step series can start at the window edge
+func (r *StockRepository) GetTargetEvents(ticker string, since, until time.Time) ([]models.Stock, error) {
+	var events []models.Stock
+
+	err := r.db.Raw(`
+		SELECT brokerage, target_to, time FROM (
+			(
+				SELECT DISTINCT ON (brokerage) brokerage, target_to, time
+				FROM stocks
+				WHERE ticker = ? AND time < ? AND target_to > 0
+				ORDER BY brokerage, time DESC
+			)
+			UNION ALL
+			(
+				SELECT brokerage, target_to, time
+				FROM stocks
+				WHERE ticker = ? AND time >= ? AND time < ? AND target_to > 0
+			)
+		) events
+		ORDER BY time, brokerage`,
+		ticker, since.UTC(), ticker, since.UTC(), until.UTC(),
+	).Scan(&events)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve target events for ticker %s: %w", ticker, err)
+	}
+
+	return events, nil
+}
+
+// tickerEventCounts holds the per-action event counts of one ticker
+type tickerEventCounts struct {
+	Ticker string
+	eventCounts
+}
+
+// GetTickerSummaries computes the summaries of several tickers at once, see
+// GetTickerSummary. Tickers without events in the window are omitted.
+func (r *StockRepository) GetTickerSummaries(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error) {
+	summaries := make(map[string]models.TickerSummary, len(tickers))
+	if len(tickers) == 0 {
+		return summaries, nil
+	}
+
+	var counts []tickerEventCounts
+	err := r.db.Raw(`
+		SELECT
+			ticker,
+			count(*) FILTER (WHERE action = ?) AS upgrades,
+			count(*) FILTER (WHERE action = ?) AS downgrades,
+			count(*) AS total_events
+		FROM stocks
+		WHERE ticker IN ? AND time >= ? AND time < ?
+		GROUP BY ticker`,
+		models.ActionUpgraded, models.ActionDowngraded, tickers, since.UTC(), until.UTC(),
+	).Scan(&counts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events for tickers: %w", err)
+	}
+
+	if len(counts) == 0 {
+		return summaries, nil
+	}
+
+	var latest []models.Stock
+	err = r.db.Raw(`
+		SELECT DISTINCT ON (ticker, brokerage)
+			ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, time
+		FROM stocks
+		WHERE ticker IN ? AND time >= ? AND time < ?
+		ORDER BY ticker, brokerage, time DESC`,
+		tickers, since.UTC(), until.UTC(),
+	).Scan(&latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve latest ratings for tickers: %w", err)
+	}
+
+	latestByTicker := make(map[string][]models.Stock)
+	for _, stock := range latest {
+		latestByTicker[stock.Ticker] = append(latestByTicker[stock.Ticker], stock)
+	}
+
+	for _, c := range counts {
+		summary := models.TickerSummary{
+			Ticker:             c.Ticker,
+			WindowStart:        since.UTC(),
+			WindowEnd:          until.UTC(),
+			LatestRatings:      []models.BrokerageRating{},
+			RatingDistribution: map[string]int{},
+			Upgrades:           c.Upgrades,
+			Downgrades:         c.Downgrades,
+			TotalEvents:        c.TotalEvents,
+		}
+		applyLatestRatings(&summary, latestByTicker[c.Ticker])
+		summaries[c.Ticker] = summary
+	}
+
+	return summaries, nil
+}
+
+// GetLatestStocksForTickers returns up to perTicker most recent events of each
+// ticker, grouped by ticker and newest first
+func (r *StockRepository) GetLatestStocksForTickers(tickers []string, perTicker int) ([]models.Stock, error) {
+	if len(tickers) == 0 {
+		return []models.Stock{}, nil
+	}
+
+	var stocks []models.Stock
+	err := r.db.Raw(`
+		SELECT `+stockListColumns+`
+		FROM (
+			SELECT `+stockListColumns+`,
+				row_number() OVER (PARTITION BY ticker ORDER BY time DESC, id DESC) AS rank
+			FROM stocks
+			WHERE ticker IN ?
+		) ranked
+		WHERE rank <= ?
+		ORDER BY ticker, time DESC, id DESC`,
+		tickers, perTicker,
+	).Scan(&stocks)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve latest stocks for tickers: %w", err)
+	}
+
+	return stocks, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/models"
+	"strings"
+	"time"
+)
+
+// RatingRepository stores the rating mappings added at runtime and the
+// record of the ratings missing from the ladder
+type RatingRepository interface {
+	GetRatingMappings() ([]models.RatingMapping, error)
+	SaveRatingMapping(mapping models.RatingMapping) error
+	DeleteRatingMapping(rating string) error
+	InvalidateRatings()
+	RefreshUnmappedRatings() error
+	RecordUnmappedRatings(ratings []string) error
+	GetUnmappedRatings(limit int) ([]models.UnmappedRating, error)
+}
+
+// SetRatingRepository sets where rating mappings and unmapped ratings are stored
+func (s *StockService) SetRatingRepository(ratingRepository RatingRepository) {
+	s.ratingRepository = ratingRepository
+}
+
+// SetRatingLadder sets the ladder the stored mappings are merged over, the
+// built-in one unless a ladder file is configured, and puts it in use
+func (s *StockService) SetRatingLadder(ladder models.RatingLadder) {
+	s.ratingMu.Lock()
+	defer s.ratingMu.Unlock()
+
+	s.ratingLadder = ladder
+	models.SetRatingLadder(ladder)
+}
+
+// LoadRatingMappings merges the stored rating mappings over the ladder set
+// with SetRatingLadder and puts the result in use
+func (s *StockService) LoadRatingMappings() ([]models.RatingMapping, error) {
+	s.ratingMu.Lock()
+	defer s.ratingMu.Unlock()
+
+	return s.loadRatingMappings()
+}
+
+// loadRatingMappings does the work of LoadRatingMappings, s.ratingMu must be held
+func (s *StockService) loadRatingMappings() ([]models.RatingMapping, error) {
+	mappings, err := s.ratingRepository.GetRatingMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[string]models.RatingLevel, len(mappings))
+	for _, mapping := range mappings {
+		ratings[mapping.Rating] = mapping.Level
+	}
+
+	base := s.ratingLadder
+	if base.Ratings == nil {
+		base = models.DefaultRatingLadder()
+	}
+	models.SetRatingLadder(base.WithRatings(ratings))
+
+	// Only now do reads see the new levels
+	s.ratingRepository.InvalidateRatings()
+
+	return mappings, nil
+}
+
+// GetRatingMappings returns the rating mappings added at runtime, by rating
+func (s *StockService) GetRatingMappings() ([]models.RatingMapping, error) {
+	return s.ratingRepository.GetRatingMappings()
+}
+
+// MapRating places a rating string on the ladder, replacing its previous
+// mapping, and puts it in use immediately
+func (s *StockService) MapRating(rating string, level models.RatingLevel) (models.RatingMapping, error) {
+	rating = strings.TrimSpace(rating)
+	mapping := models.RatingMapping{
+		Rating:    rating,
+		Level:     level,
+		Category:  level.Category(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	s.ratingMu.Lock()
+	defer s.ratingMu.Unlock()
+
+	if err := s.ratingRepository.SaveRatingMapping(mapping); err != nil {
+		return models.RatingMapping{}, err
+	}
+
+	if _, err := s.loadRatingMappings(); err != nil {
+		return models.RatingMapping{}, err
+	}
+
+	return mapping, nil
+}
+
+// UnmapRating removes the mapping of a rating string. The rating falls back
+// to the built-in ladder, and is recorded as unmapped again if it is not on it.
+func (s *StockService) UnmapRating(rating string) error {
+	rating = strings.TrimSpace(rating)
+
+	s.ratingMu.Lock()
+	defer s.ratingMu.Unlock()
+
+	mappings, err := s.ratingRepository.GetRatingMappings()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, mapping := range mappings {
+		if mapping.Rating == rating {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return apierrors.NotFound(fmt.Sprintf("No mapping for rating %q", rating))
+	}
+
+	if err := s.ratingRepository.DeleteRatingMapping(rating); err != nil {
+		return err
+	}
+
+	if _, err := s.loadRatingMappings(); err != nil {
+		return err
+	}
+
+	return s.ratingRepository.RecordUnmappedRatings([]string{rating})
+}
+
+// RefreshUnmappedRatings records the ratings of all stored events missing from
+// the ladder, dropping the ones mapped since. It reads every stored event, so
+// it runs once at startup, after a ladder file may have changed.
+func (s *StockService) RefreshUnmappedRatings() error {
+	return s.ratingRepository.RefreshUnmappedRatings()
+}
+
+// GetUnmappedRatings returns up to limit ratings of the stored events missing
+// from the ladder, most used first, as of the last sync or mapping change
+func (s *StockService) GetUnmappedRatings(limit int) ([]models.UnmappedRating, error) {
+	return s.ratingRepository.GetUnmappedRatings(limit)
+}
+
+// recordUnmappedRatings records the unmapped ratings of the synced events,
+// failures are logged since the sync itself succeeded
+func (s *StockService) recordUnmappedRatings(unmapped map[string]bool) {
+	if s.ratingRepository == nil || len(unmapped) == 0 {
+		return
+	}
+
+	ratings := make([]string, 0, len(unmapped))
+	for rating := range unmapped {
+		ratings = append(ratings, rating)
+	}
+	sort.Strings(ratings)
+
+	if err := s.ratingRepository.RecordUnmappedRatings(ratings); err != nil {
+		fmt.Printf("Recording unmapped ratings failed: %v\n", err)
+	}
+}
+
+// collectUnmappedRatings adds the ratings of the stocks missing from the
+// ladder in use to unmapped
+func collectUnmappedRatings(unmapped map[string]bool, stocks []models.Stock) {
+	ladder := models.CurrentRatingLadder()
+	for _, stock := range stocks {
+		for _, rating := range []string{stock.RatingFrom, stock.RatingTo} {
+			if rating != "" && ladder.Level(stock.Brokerage, rating) == models.RatingUnknown {
+				unmapped[rating] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"stonks-api/internal/stocks/models"
+	"time"
+)
+
+const (
+	// defaultFeedPollInterval is how often watchers look for stocks saved by
+	// other instances
+	defaultFeedPollInterval = 5 * time.Second
+
+	// feedBatchSize bounds the stocks read per query
+	feedBatchSize = 500
+
+	// feedSafetyWindow is how far behind its position a watcher reads again.
+	// updated_at is stamped before the saving transaction commits, so a row
+	// can become visible behind a position already passed. The window must
+	// outlast the longest save.
+	feedSafetyWindow = 2 * time.Minute
+)
+
+// NewFeedPosition returns the feed position right after the stock, it can be
+// encoded as a resume token
+func NewFeedPosition(stock models.Stock) models.Cursor {
+	return models.Cursor{Time: stock.UpdatedAt.UTC(), ID: stock.ID}
+}
+
+// SetFeedPollInterval sets how often WatchStocks polls for new stocks
+func (s *StockService) SetFeedPollInterval(interval time.Duration) {
+	s.feedPollInterval = interval
+}
+
+// WatchStocks calls fn for every stock matching the filter inserted or changed
+// after the position, in the order the changes were stored, until ctx is done
+// or fn fails. Stocks committed late, behind positions already passed, are
+// delivered when found within feedSafetyWindow; the position given to fn
+// never moves back. Saves made by this instance wake watchers immediately,
+// saves made elsewhere are picked up by polling.
+func (s *StockService) WatchStocks(ctx context.Context, after models.Cursor, filter models.StockFilter, fn func(stock models.Stock, position models.Cursor) error) error {
+	interval := s.feedPollInterval
+	if interval <= 0 {
+		interval = defaultFeedPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// sent holds the update time of the stocks sent within the window, by ID.
+	// The window behind the starting position counts as sent, by the resumed
+	// watch or before the watch started.
+	sent := make(map[string]time.Time)
+	first := true
+
+	for {
+		// Subscribe before reading so a save in between is not missed
+		saved := s.savedSignal()
+		start := after
+
+		for {
+			stocks, err := s.repository.GetStocksChangedAfter(after, filter, feedBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, stock := range stocks {
+				after = NewFeedPosition(stock)
+				sent[stock.ID] = stock.UpdatedAt
+				if err := fn(stock, after); err != nil {
+					return err
+				}
+			}
+
+			// A full batch means more stocks are waiting
+			if len(stocks) < feedBatchSize {
+				break
+			}
+		}
+
+		err := s.catchUpFeed(start, filter, sent, !first, func(stock models.Stock) error {
+			return fn(stock, after)
+		})
+		if err != nil {
+			return err
+		}
+		first = false
+
+		horizon := after.Time.Add(-feedSafetyWindow)
+		for id, updatedAt := range sent {
+			if updatedAt.Before(horizon) {
+				delete(sent, id)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-saved:
+		case <-ticker.C:
+		}
+	}
+}
+
+// catchUpFeed reads the window behind position again and calls fn for the
+// stocks missing from sent, or only records them when deliver is false
+func (s *StockService) catchUpFeed(position models.Cursor, filter models.StockFilter, sent map[string]time.Time, deliver bool, fn func(stock models.Stock) error) error {
+	scan := models.Cursor{Time: position.Time.Add(-feedSafetyWindow)}
+	for {
+		stocks, err := s.repository.GetStocksChangedAfter(scan, filter, feedBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, stock := range stocks {
+			scan = NewFeedPosition(stock)
+			if feedPositionAfter(scan, position) {
+				return nil
+			}
+
+			if updatedAt, ok := sent[stock.ID]; ok && updatedAt.Equal(stock.UpdatedAt) {
+				continue
+			}
+			sent[stock.ID] = stock.UpdatedAt
+
+			if deliver {
+				if err := fn(stock); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(stocks) < feedBatchSize {
+			return nil
+		}
+	}
+}
+
+// feedPositionAfter reports whether position a comes after b. A position
+// without an ID is right after its time, like in GetStocksChangedAfter.
+func feedPositionAfter(a, b models.Cursor) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.After(b.Time)
+	}
+	return b.ID != "" && a.ID > b.ID
+}
+
+// savedSignal returns a channel closed by the next notifySaved
+func (s *StockService) savedSignal() <-chan struct{} {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+
+	if s.feedSaved == nil {
+		s.feedSaved = make(chan struct{})
+	}
+	return s.feedSaved
+}
+
+// notifySaved wakes every watcher after new stocks were saved
+func (s *StockService) notifySaved() {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+
+	if s.feedSaved != nil {
+		close(s.feedSaved)
+		s.feedSaved = nil
+	}
+}
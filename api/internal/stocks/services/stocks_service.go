@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"stonks-api/cmd/apierrors"
 	"stonks-api/internal/stocks/models"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,7 +19,17 @@ type StockRepository interface {
 	SaveStocks(stocks []models.Stock) error
 	GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error)
 	GetStocksByTicker(ticker string) ([]models.Stock, error)
-	GetRecentStocks(limit int) ([]models.Stock, error)
+	GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error)
+	SearchStocks(query string, limit int) ([]models.StockSearchResult, error)
+	GetTickerSummary(ticker string, since, until time.Time) (models.TickerSummary, error)
+	GetBrokerageStats(query models.BrokerageQuery) ([]models.BrokerageStats, error)
+	GetSentimentSeries(query models.SentimentQuery) ([]models.SentimentBucket, error)
+	SetTickerSector(ticker, sector string) error
+	GetTargetEvents(ticker string, since, until time.Time) ([]models.Stock, error)
+	GetTickerSummaries(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error)
+	GetLatestStocksForTickers(tickers []string, perTicker int) ([]models.Stock, error)
+	StreamStocks(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error
+	GetStocksChangedAfter(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error)
 }
 
 // APIConfig holds the configuration for the external API
@@ -54,6 +67,16 @@ type StockService struct {
 	httpClient        HTTPClient
 	repository        StockRepository
 	externalAPIConfig ExternalAPIConfig
+	syncMu            sync.Mutex
+	afterSync         func(saved int)
+
+	ratingRepository RatingRepository
+	ratingMu         sync.Mutex
+	ratingLadder     models.RatingLadder
+
+	feedPollInterval time.Duration
+	feedMu           sync.Mutex
+	feedSaved        chan struct{}
 }
 
 // NewStockService creates a new instance of StockService
@@ -71,6 +94,12 @@ func (s *StockService) SetHTTPClient(client HTTPClient) {
 	s.httpClient = client
 }
 
+// SetAfterSync sets a function called after every successful sync with the
+// number of stocks saved
+func (s *StockService) SetAfterSync(fn func(saved int)) {
+	s.afterSync = fn
+}
+
 // FetchStocks retrieves stock data from the API
 func (s *StockService) FetchStocks(nextPage string) (*StockResponse, error) {
 	url := s.externalAPIConfig.URL
@@ -122,7 +151,6 @@ func (s *StockService) ConvertToStocks(items []StockItem) []models.Stock {
 			TargetFrom: parsedItem.TargetFrom,
 			TargetTo:   parsedItem.TargetTo,
 			Time:       parsedItem.Time,
-			UpdatedAt:  time.Now(),
 		}
 
 		stocks = append(stocks, stock)
@@ -144,7 +172,8 @@ type ParsedStockItem struct {
 	Time       time.Time `json:"time"`
 }
 
-// parseStockItem converts string target values to float64
+// parseStockItem converts string target values to float64 and normalizes the
+// event time to UTC
 func (s *StockService) parseStockItem(item StockItem) ParsedStockItem {
 	return ParsedStockItem{
 		Ticker:     item.Ticker,
@@ -155,7 +184,7 @@ func (s *StockService) parseStockItem(item StockItem) ParsedStockItem {
 		RatingTo:   item.RatingTo,
 		TargetFrom: parseTargetValue(item.TargetFrom),
 		TargetTo:   parseTargetValue(item.TargetTo),
-		Time:       item.Time,
+		Time:       item.Time.UTC(),
 	}
 }
 
@@ -176,23 +205,31 @@ func parseTargetValue(val string) float64 {
 // SyncStocks fetches stocks from API and saves them in batches
 func (s *StockService) SyncStocks() (int, error) {
 	if s.externalAPIConfig.URL == "" {
-		return 0, fmt.Errorf("external API URL not configured")
+		return 0, apierrors.UpstreamUnavailable("The external stocks API is not configured", nil)
 	}
 
+	// Overlapping syncs would fetch and save the same pages twice
+	if !s.syncMu.TryLock() {
+		return 0, apierrors.Conflict("A sync is already running")
+	}
+	defer s.syncMu.Unlock()
+
 	totalCount := 0
 	batchSize := 100
 	batch := make([]models.Stock, 0, batchSize)
 	nextPage := ""
+	unmapped := make(map[string]bool)
 
 	fmt.Println("Starting to sync stocks from external API")
 
 	for {
 		response, err := s.FetchStocks(nextPage)
 		if err != nil {
-			return totalCount, fmt.Errorf("error fetching stocks: %w", err)
+			return totalCount, apierrors.UpstreamUnavailable("The external stocks API is unavailable", fmt.Errorf("error fetching stocks: %w", err))
 		}
 
 		stocks := s.ConvertToStocks(response.Items)
+		collectUnmappedRatings(unmapped, stocks)
 
 		batch = append(batch, stocks...)
 
@@ -201,6 +238,7 @@ func (s *StockService) SyncStocks() (int, error) {
 			if err := s.repository.SaveStocks(batch[:batchSize]); err != nil {
 				return totalCount, fmt.Errorf("error saving stocks batch: %w", err)
 			}
+			s.notifySaved()
 
 			totalCount += batchSize
 			batch = batch[batchSize:]
@@ -219,27 +257,297 @@ func (s *StockService) SyncStocks() (int, error) {
 		if err := s.repository.SaveStocks(batch); err != nil {
 			return totalCount, fmt.Errorf("error saving final batch: %w", err)
 		}
+		s.notifySaved()
 		totalCount += len(batch)
 	}
 
 	fmt.Printf("Successfully synced %d stocks from external API\n", totalCount)
+
+	s.recordUnmappedRatings(unmapped)
+
+	if s.afterSync != nil {
+		s.afterSync(totalCount)
+	}
+
 	return totalCount, nil
 }
 
-// GetAllStocks retrieves all stocks with pagination
-func (s *StockService) GetAllStocks(page, pageSize int) (models.PaginatedStocks, error) {
-	params := models.PaginationParams{
-		Page:     page,
-		PageSize: pageSize,
-	}
+// GetAllStocks retrieves all stocks matching the filter, paginated by page
+// number or cursor
+func (s *StockService) GetAllStocks(params models.PaginationParams) (models.PaginatedStocks, error) {
 	return s.repository.GetAllStocks(params)
 }
 
+// StreamStocks passes every stock matching the filter to fn, batchSize rows at a time
+func (s *StockService) StreamStocks(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+	return s.repository.StreamStocks(filter, batchSize, fn)
+}
+
 // GetStocksByTicker retrieves stocks for a specific ticker
 func (s *StockService) GetStocksByTicker(ticker string) ([]models.Stock, error) {
 	return s.repository.GetStocksByTicker(ticker)
 }
 
+// SearchStocks finds tickers matching a ticker prefix or company name
+func (s *StockService) SearchStocks(query string, limit int) ([]models.StockSearchResult, error) {
+	return s.repository.SearchStocks(strings.TrimSpace(query), limit)
+}
+
+// GetTickerSummary computes the analyst consensus on a ticker over the window
+// ending now
+func (s *StockService) GetTickerSummary(ticker string, window time.Duration) (models.TickerSummary, error) {
+	until := time.Now().UTC()
+	return s.repository.GetTickerSummary(strings.ToUpper(ticker), until.Add(-window), until)
+}
+
+// GetBrokerageLeaderboard ranks brokerages by their activity over the window
+// ending now
+func (s *StockService) GetBrokerageLeaderboard(window time.Duration, sortField string, sortAsc bool, limit int) (models.BrokerageLeaderboard, error) {
+	until := time.Now().UTC()
+	query := models.BrokerageQuery{
+		Since:     until.Add(-window),
+		Until:     until,
+		SortField: sortField,
+		SortAsc:   sortAsc,
+		Limit:     limit,
+	}
+
+	stats, err := s.repository.GetBrokerageStats(query)
+	if err != nil {
+		return models.BrokerageLeaderboard{}, err
+	}
+
+	if stats == nil {
+		stats = []models.BrokerageStats{}
+	}
+
+	return models.BrokerageLeaderboard{
+		Brokerages:  stats,
+		WindowStart: query.Since,
+		WindowEnd:   query.Until,
+	}, nil
+}
+
+// GetSentimentSeries aggregates analyst actions into time buckets. Buckets
+// without events are filled with zeros so charts get a continuous series.
+func (s *StockService) GetSentimentSeries(query models.SentimentQuery) (models.SentimentSeries, error) {
+	if query.Location == nil {
+		query.Location = time.UTC
+	}
+
+	buckets, err := s.repository.GetSentimentSeries(query)
+	if err != nil {
+		return models.SentimentSeries{}, err
+	}
+
+	byStart := make(map[int64]models.SentimentBucket, len(buckets))
+	for _, bucket := range buckets {
+		byStart[bucket.Bucket.Unix()] = bucket
+	}
+
+	series := models.SentimentSeries{
+		Interval:    query.Interval,
+		WindowStart: query.Since,
+		WindowEnd:   query.Until,
+		Buckets:     make([]models.SentimentBucket, 0, len(buckets)),
+	}
+
+	start := models.TruncateToInterval(query.Since, query.Interval, query.Location)
+	for t := start; t.Before(query.Until); t = models.AddInterval(t, query.Interval) {
+		bucket, ok := byStart[t.Unix()]
+		if !ok {
+			bucket = models.SentimentBucket{}
+		}
+		bucket.Bucket = t
+		series.Buckets = append(series.Buckets, bucket)
+	}
+
+	return series, nil
+}
+
+// GetTargetHistory builds the price target step series of every brokerage
+// covering the ticker and the consensus target sampled at the end of each
+// interval bucket
+func (s *StockService) GetTargetHistory(ticker string, since, until time.Time, interval string, loc *time.Location) (models.TargetHistory, error) {
+	ticker = strings.ToUpper(ticker)
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	events, err := s.repository.GetTargetEvents(ticker, since, until)
+	if err != nil {
+		return models.TargetHistory{}, err
+	}
+
+	history := models.TargetHistory{
+		Ticker:      ticker,
+		Interval:    interval,
+		WindowStart: since,
+		WindowEnd:   until,
+		Brokerages:  []models.BrokerageTargetSeries{},
+		Consensus:   []models.ConsensusTargetPoint{},
+	}
+
+	// Step series, a new point only when the target changes. Targets set
+	// before the window start at the window edge.
+	seriesByBrokerage := make(map[string]*models.BrokerageTargetSeries)
+	for _, event := range events {
+		series, ok := seriesByBrokerage[event.Brokerage]
+		if !ok {
+			series = &models.BrokerageTargetSeries{Brokerage: event.Brokerage}
+			seriesByBrokerage[event.Brokerage] = series
+		}
+
+		pointTime := event.Time
+		if pointTime.Before(since) {
+			pointTime = since
+		}
+
+		if n := len(series.Points); n > 0 && series.Points[n-1].Target == event.TargetTo {
+			continue
+		}
+		series.Points = append(series.Points, models.TargetPoint{Time: pointTime, Target: event.TargetTo})
+	}
+
+	for _, series := range seriesByBrokerage {
+		history.Brokerages = append(history.Brokerages, *series)
+	}
+	sort.Slice(history.Brokerages, func(i, j int) bool {
+		return history.Brokerages[i].Brokerage < history.Brokerages[j].Brokerage
+	})
+
+	// Consensus over the latest target of each brokerage at each bucket end
+	current := make(map[string]float64)
+	next := 0
+	start := models.TruncateToInterval(since, interval, loc)
+	for t := start; t.Before(until); t = models.AddInterval(t, interval) {
+		end := models.AddInterval(t, interval)
+		if end.After(until) {
+			end = until
+		}
+
+		for ; next < len(events) && events[next].Time.Before(end); next++ {
+			current[events[next].Brokerage] = events[next].TargetTo
+		}
+
+		if len(current) == 0 {
+			continue
+		}
+
+		targets := make([]float64, 0, len(current))
+		for _, target := range current {
+			targets = append(targets, target)
+		}
+		stats := models.NewTargetStats(targets)
+
+		history.Consensus = append(history.Consensus, models.ConsensusTargetPoint{
+			Time:       end,
+			Mean:       stats.Mean,
+			Median:     stats.Median,
+			Brokerages: stats.Count,
+		})
+	}
+
+	return history, nil
+}
+
+// LookupTickers returns the latest events and the summary over the window of
+// each requested ticker, in request order with duplicates removed
+func (s *StockService) LookupTickers(tickers []string, eventsPerTicker int, window time.Duration) ([]models.TickerLookup, error) {
+	tickers = normalizeTickers(tickers)
+
+	until := time.Now().UTC()
+	summaries, err := s.repository.GetTickerSummaries(tickers, until.Add(-window), until)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.Stock{}
+	if eventsPerTicker > 0 {
+		events, err = s.repository.GetLatestStocksForTickers(tickers, eventsPerTicker)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	eventsByTicker := make(map[string][]models.Stock, len(tickers))
+	for _, event := range events {
+		eventsByTicker[event.Ticker] = append(eventsByTicker[event.Ticker], event)
+	}
+
+	results := make([]models.TickerLookup, 0, len(tickers))
+	for _, ticker := range tickers {
+		lookup := models.TickerLookup{
+			Ticker:       ticker,
+			LatestEvents: eventsByTicker[ticker],
+		}
+		if lookup.LatestEvents == nil {
+			lookup.LatestEvents = []models.Stock{}
+		}
+		if summary, ok := summaries[ticker]; ok {
+			lookup.Summary = &summary
+		}
+		lookup.Found = lookup.Summary != nil || len(lookup.LatestEvents) > 0
+		results = append(results, lookup)
+	}
+
+	return results, nil
+}
+
+// CompareTickers lines up the consensus, target range and most recent actions
+// of the tickers side by side
+func (s *StockService) CompareTickers(tickers []string, recentActions int, window time.Duration) ([]models.TickerComparison, error) {
+	lookups, err := s.LookupTickers(tickers, recentActions, window)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := make([]models.TickerComparison, len(lookups))
+	for i, lookup := range lookups {
+		comparison := models.TickerComparison{
+			Ticker:        lookup.Ticker,
+			Found:         lookup.Found,
+			RecentActions: lookup.LatestEvents,
+		}
+		if len(lookup.LatestEvents) > 0 {
+			comparison.Company = lookup.LatestEvents[0].Company
+		}
+		if summary := lookup.Summary; summary != nil {
+			comparison.Company = summary.Company
+			comparison.ConsensusRating = summary.ConsensusRating
+			comparison.ConsensusScore = summary.ConsensusScore
+			comparison.CoveringBrokerages = summary.CoveringBrokerages
+			comparison.Target = summary.Target
+			comparison.Upgrades = summary.Upgrades
+			comparison.Downgrades = summary.Downgrades
+		}
+		comparisons[i] = comparison
+	}
+
+	return comparisons, nil
+}
+
+// normalizeTickers upper-cases and trims the tickers, dropping blanks and
+// duplicates while keeping the original order
+func normalizeTickers(tickers []string) []string {
+	seen := make(map[string]bool, len(tickers))
+	normalized := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+		seen[ticker] = true
+		normalized = append(normalized, ticker)
+	}
+	return normalized
+}
+
+// SetTickerSector assigns a ticker to a sector for sector filters
+func (s *StockService) SetTickerSector(ticker, sector string) error {
+	return s.repository.SetTickerSector(strings.ToUpper(strings.TrimSpace(ticker)), strings.TrimSpace(sector))
+}
+
 // SetExternalAPIConfig sets the external API configuration
 func (s *StockService) SetExternalAPIConfig(config ExternalAPIConfig) {
 	s.externalAPIConfig = config
@@ -1,10 +1,14 @@
 package services_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"stonks-api/cmd/apierrors"
+	"stonks-api/internal/stocks/mocks"
 	"stonks-api/internal/stocks/models"
 	"stonks-api/internal/stocks/services"
 	"testing"
@@ -13,10 +17,20 @@ import (
 
 // MockRepository implements the Repository interface for testing
 type MockRepository struct {
-	SaveStocksFn        func(stocks []models.Stock) error
-	GetAllStocksFn      func(params models.PaginationParams) (models.PaginatedStocks, error)
-	GetStocksByTickerFn func(ticker string) ([]models.Stock, error)
-	GetRecentStocksFn   func(limit int) ([]models.Stock, error)
+	SaveStocksFn            func(stocks []models.Stock) error
+	GetAllStocksFn          func(params models.PaginationParams) (models.PaginatedStocks, error)
+	GetStocksByTickerFn     func(ticker string) ([]models.Stock, error)
+	GetRecentStocksFn       func(filter models.StockFilter, limit int) ([]models.Stock, error)
+	SearchStocksFn          func(query string, limit int) ([]models.StockSearchResult, error)
+	GetTickerSummaryFn      func(ticker string, since, until time.Time) (models.TickerSummary, error)
+	GetBrokerageStatsFn     func(query models.BrokerageQuery) ([]models.BrokerageStats, error)
+	GetSentimentFn          func(query models.SentimentQuery) ([]models.SentimentBucket, error)
+	SetTickerSectorFn       func(ticker, sector string) error
+	GetTargetEventsFn       func(ticker string, since, until time.Time) ([]models.Stock, error)
+	GetTickerSummariesFn    func(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error)
+	GetLatestForTickersFn   func(tickers []string, perTicker int) ([]models.Stock, error)
+	StreamStocksFn          func(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error
+	GetStocksChangedAfterFn func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error)
 }
 
 func (m *MockRepository) SaveStocks(stocks []models.Stock) error {
@@ -40,9 +54,79 @@ func (m *MockRepository) GetStocksByTicker(ticker string) ([]models.Stock, error
 	return []models.Stock{}, nil
 }
 
-func (m *MockRepository) GetRecentStocks(limit int) ([]models.Stock, error) {
+func (m *MockRepository) GetRecentStocks(filter models.StockFilter, limit int) ([]models.Stock, error) {
 	if m.GetRecentStocksFn != nil {
-		return m.GetRecentStocksFn(limit)
+		return m.GetRecentStocksFn(filter, limit)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) SearchStocks(query string, limit int) ([]models.StockSearchResult, error) {
+	if m.SearchStocksFn != nil {
+		return m.SearchStocksFn(query, limit)
+	}
+	return []models.StockSearchResult{}, nil
+}
+
+func (m *MockRepository) GetTickerSummary(ticker string, since, until time.Time) (models.TickerSummary, error) {
+	if m.GetTickerSummaryFn != nil {
+		return m.GetTickerSummaryFn(ticker, since, until)
+	}
+	return models.TickerSummary{Ticker: ticker}, nil
+}
+
+func (m *MockRepository) GetBrokerageStats(query models.BrokerageQuery) ([]models.BrokerageStats, error) {
+	if m.GetBrokerageStatsFn != nil {
+		return m.GetBrokerageStatsFn(query)
+	}
+	return []models.BrokerageStats{}, nil
+}
+
+func (m *MockRepository) GetSentimentSeries(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+	if m.GetSentimentFn != nil {
+		return m.GetSentimentFn(query)
+	}
+	return []models.SentimentBucket{}, nil
+}
+
+func (m *MockRepository) SetTickerSector(ticker, sector string) error {
+	if m.SetTickerSectorFn != nil {
+		return m.SetTickerSectorFn(ticker, sector)
+	}
+	return nil
+}
+
+func (m *MockRepository) GetTargetEvents(ticker string, since, until time.Time) ([]models.Stock, error) {
+	if m.GetTargetEventsFn != nil {
+		return m.GetTargetEventsFn(ticker, since, until)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) GetTickerSummaries(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error) {
+	if m.GetTickerSummariesFn != nil {
+		return m.GetTickerSummariesFn(tickers, since, until)
+	}
+	return map[string]models.TickerSummary{}, nil
+}
+
+func (m *MockRepository) GetLatestStocksForTickers(tickers []string, perTicker int) ([]models.Stock, error) {
+	if m.GetLatestForTickersFn != nil {
+		return m.GetLatestForTickersFn(tickers, perTicker)
+	}
+	return []models.Stock{}, nil
+}
+
+func (m *MockRepository) StreamStocks(filter models.StockFilter, batchSize int, fn func(batch []models.Stock) error) error {
+	if m.StreamStocksFn != nil {
+		return m.StreamStocksFn(filter, batchSize, fn)
+	}
+	return nil
+}
+
+func (m *MockRepository) GetStocksChangedAfter(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+	if m.GetStocksChangedAfterFn != nil {
+		return m.GetStocksChangedAfterFn(after, filter, limit)
 	}
 	return []models.Stock{}, nil
 }
@@ -124,8 +208,8 @@ func TestSyncStocks(t *testing.T) {
 		// Create mock response with 2 items
 		mockResp := services.StockResponse{
 			Items: []services.StockItem{
-				{Ticker: "AAPL", TargetFrom: "$150.00", Time: time.Now()},
-				{Ticker: "MSFT", TargetFrom: "$200.00", Time: time.Now()},
+				{Ticker: "AAPL", TargetFrom: "$150.00", RatingTo: "Speculative Buy", Time: time.Now()},
+				{Ticker: "MSFT", TargetFrom: "$200.00", RatingFrom: "Hold", RatingTo: "Buy", Time: time.Now()},
 			},
 		}
 
@@ -146,6 +230,18 @@ func TestSyncStocks(t *testing.T) {
 
 		service := services.NewStockService(mockRepo)
 		service.SetHTTPClient(mockClient)
+		service.SetExternalAPIConfig(services.ExternalAPIConfig{URL: "https://api.example.com/stocks"})
+
+		afterSync := -1
+		service.SetAfterSync(func(saved int) { afterSync = saved })
+
+		var recorded []string
+		service.SetRatingRepository(&mocks.MockRatingRepository{
+			RecordUnmappedRatingsFn: func(ratings []string) error {
+				recorded = ratings
+				return nil
+			},
+		})
 
 		count, err := service.SyncStocks()
 
@@ -156,6 +252,14 @@ func TestSyncStocks(t *testing.T) {
 		if count != 2 {
 			t.Errorf("Expected count 2 but got %d", count)
 		}
+
+		if afterSync != 2 {
+			t.Errorf("Expected the after sync hook to get 2 but got %d", afterSync)
+		}
+
+		if len(recorded) != 1 || recorded[0] != "Speculative Buy" {
+			t.Errorf("Expected the unmapped rating of the sync to be recorded but got %v", recorded)
+		}
 	})
 
 	// Database error
@@ -183,6 +287,7 @@ func TestSyncStocks(t *testing.T) {
 
 		service := services.NewStockService(mockRepo)
 		service.SetHTTPClient(mockClient)
+		service.SetExternalAPIConfig(services.ExternalAPIConfig{URL: "https://api.example.com/stocks"})
 
 		_, err := service.SyncStocks()
 
@@ -190,6 +295,97 @@ func TestSyncStocks(t *testing.T) {
 			t.Errorf("Expected error but got nil")
 		}
 	})
+
+	// Upstream failures are reported as such
+	t.Run("upstream unavailable", func(t *testing.T) {
+		mockClient := &MockHTTPClient{
+			DoFn: func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		service := services.NewStockService(&MockRepository{})
+		service.SetHTTPClient(mockClient)
+		service.SetExternalAPIConfig(services.ExternalAPIConfig{URL: "https://api.example.com/stocks"})
+
+		_, err := service.SyncStocks()
+
+		apiErr, ok := apierrors.As(err)
+		if !ok || apiErr.Code != apierrors.CodeUpstreamUnavailable {
+			t.Errorf("Expected an upstream_unavailable error but got %v", err)
+		}
+	})
+
+	// Only one sync runs at a time
+	t.Run("sync already running", func(t *testing.T) {
+		fetching := make(chan struct{})
+		release := make(chan struct{})
+		mockClient := &MockHTTPClient{
+			DoFn: func(req *http.Request) (*http.Response, error) {
+				close(fetching)
+				<-release
+
+				rec := httptest.NewRecorder()
+				json.NewEncoder(rec).Encode(services.StockResponse{})
+				return rec.Result(), nil
+			},
+		}
+
+		service := services.NewStockService(&MockRepository{})
+		service.SetHTTPClient(mockClient)
+		service.SetExternalAPIConfig(services.ExternalAPIConfig{URL: "https://api.example.com/stocks"})
+
+		done := make(chan error)
+		go func() {
+			_, err := service.SyncStocks()
+			done <- err
+		}()
+		<-fetching
+
+		_, err := service.SyncStocks()
+		apiErr, ok := apierrors.As(err)
+		if !ok || apiErr.Code != apierrors.CodeConflict {
+			t.Errorf("Expected a conflict error but got %v", err)
+		}
+
+		close(release)
+		if err := <-done; err != nil {
+			t.Errorf("Expected the first sync to succeed, but got %v", err)
+		}
+	})
+}
+
+func TestConvertToStocks(t *testing.T) {
+	t.Run("normalizes times to UTC", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skipf("time zone data not available: %v", err)
+		}
+
+		eventTime := time.Date(2025, 1, 15, 19, 30, 0, 0, newYork)
+		items := []services.StockItem{
+			{Ticker: "AAPL", TargetFrom: "$150.00", TargetTo: "$200.00", Time: eventTime},
+		}
+
+		service := services.NewStockService(&MockRepository{})
+		stocks := service.ConvertToStocks(items)
+
+		if len(stocks) != 1 {
+			t.Fatalf("Expected 1 stock but got %d", len(stocks))
+		}
+
+		if stocks[0].Time.Location() != time.UTC {
+			t.Errorf("Expected time in UTC but got %s", stocks[0].Time.Location())
+		}
+
+		if !stocks[0].Time.Equal(eventTime) {
+			t.Errorf("Expected time %v but got %v", eventTime, stocks[0].Time)
+		}
+
+		if stocks[0].TargetTo != 200.0 {
+			t.Errorf("Expected target_to 200 but got %f", stocks[0].TargetTo)
+		}
+	})
 }
 
 func TestGetStocksByTicker(t *testing.T) {
@@ -244,3 +440,486 @@ func TestGetStocksByTicker(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchStocks(t *testing.T) {
+	// Query is trimmed before reaching the repository
+	t.Run("trims query", func(t *testing.T) {
+		var gotQuery string
+		mockRepo := &MockRepository{
+			SearchStocksFn: func(query string, limit int) ([]models.StockSearchResult, error) {
+				gotQuery = query
+				return []models.StockSearchResult{{Ticker: "AAPL", Company: "Apple Inc."}}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		results, err := service.SearchStocks("  apple ", 10)
+
+		if err != nil {
+			t.Errorf("Expected no error but got: %v", err)
+		}
+
+		if gotQuery != "apple" {
+			t.Errorf("Expected trimmed query apple but got %q", gotQuery)
+		}
+
+		if len(results) != 1 || results[0].Ticker != "AAPL" {
+			t.Errorf("Expected AAPL result but got %v", results)
+		}
+	})
+}
+
+func TestGetSentimentSeries(t *testing.T) {
+	// Missing buckets are filled with zeros
+	t.Run("fills gaps", func(t *testing.T) {
+		since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		until := time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC)
+
+		mockRepo := &MockRepository{
+			GetSentimentFn: func(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+				return []models.SentimentBucket{
+					{Bucket: since.AddDate(0, 0, 1), Upgrades: 3, Downgrades: 1, TotalEvents: 4, NetSentiment: 0.5},
+				}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		series, err := service.GetSentimentSeries(models.SentimentQuery{
+			Interval: models.IntervalDay,
+			Since:    since,
+			Until:    until,
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(series.Buckets) != 3 {
+			t.Fatalf("Expected 3 daily buckets but got %d", len(series.Buckets))
+		}
+
+		if series.Buckets[0].TotalEvents != 0 || series.Buckets[1].Upgrades != 3 || series.Buckets[2].TotalEvents != 0 {
+			t.Errorf("Unexpected buckets: %+v", series.Buckets)
+		}
+
+		if !series.Buckets[2].Bucket.Equal(since.AddDate(0, 0, 2)) {
+			t.Errorf("Expected last bucket on Jan 3 but got %v", series.Buckets[2].Bucket)
+		}
+	})
+
+	// Repository error
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := &MockRepository{
+			GetSentimentFn: func(query models.SentimentQuery) ([]models.SentimentBucket, error) {
+				return nil, errors.New("repository error")
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		_, err := service.GetSentimentSeries(models.SentimentQuery{Interval: models.IntervalWeek})
+
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestGetTargetHistory(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	// Step series and daily consensus
+	t.Run("series built", func(t *testing.T) {
+		mockRepo := &MockRepository{
+			GetTargetEventsFn: func(ticker string, from, to time.Time) ([]models.Stock, error) {
+				return []models.Stock{
+					// Set before the window
+					{Brokerage: "A", TargetTo: 100, Time: since.AddDate(0, 0, -10)},
+					{Brokerage: "B", TargetTo: 120, Time: since.Add(12 * time.Hour)},
+					// Reiterated target adds no step
+					{Brokerage: "A", TargetTo: 100, Time: since.Add(30 * time.Hour)},
+					{Brokerage: "A", TargetTo: 140, Time: since.Add(50 * time.Hour)},
+				}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		history, err := service.GetTargetHistory("aapl", since, until, models.IntervalDay, time.UTC)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if history.Ticker != "AAPL" || len(history.Brokerages) != 2 {
+			t.Fatalf("Unexpected history: %+v", history)
+		}
+
+		a := history.Brokerages[0]
+		if a.Brokerage != "A" || len(a.Points) != 2 || !a.Points[0].Time.Equal(since) || a.Points[1].Target != 140 {
+			t.Errorf("Unexpected series for A: %+v", a.Points)
+		}
+
+		if len(history.Consensus) != 3 {
+			t.Fatalf("Expected 3 consensus points but got %d", len(history.Consensus))
+		}
+
+		if history.Consensus[0].Mean != 110 || history.Consensus[0].Brokerages != 2 {
+			t.Errorf("Unexpected first consensus point: %+v", history.Consensus[0])
+		}
+
+		if history.Consensus[2].Mean != 130 {
+			t.Errorf("Expected mean 130 after the raise but got %f", history.Consensus[2].Mean)
+		}
+	})
+}
+
+func TestLookupTickers(t *testing.T) {
+	// Tickers are normalized and results keep the request order
+	t.Run("normalizes and orders", func(t *testing.T) {
+		var gotTickers []string
+		mockRepo := &MockRepository{
+			GetTickerSummariesFn: func(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error) {
+				gotTickers = tickers
+				return map[string]models.TickerSummary{
+					"MSFT": {Ticker: "MSFT", Company: "Microsoft", ConsensusRating: "Positive", TotalEvents: 2},
+				}, nil
+			},
+			GetLatestForTickersFn: func(tickers []string, perTicker int) ([]models.Stock, error) {
+				return []models.Stock{
+					{Ticker: "AAPL", Company: "Apple Inc.", Action: models.ActionUpgraded},
+					{Ticker: "MSFT", Company: "Microsoft", Action: models.ActionReiterated},
+				}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		results, err := service.LookupTickers([]string{" msft", "AAPL", "MSFT", "", "zzzz"}, 5, 24*time.Hour)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(gotTickers) != 3 || gotTickers[0] != "MSFT" || gotTickers[1] != "AAPL" || gotTickers[2] != "ZZZZ" {
+			t.Errorf("Expected tickers [MSFT AAPL ZZZZ] but got %v", gotTickers)
+		}
+
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results but got %d", len(results))
+		}
+
+		if results[0].Summary == nil || results[0].Summary.ConsensusRating != "Positive" || len(results[0].LatestEvents) != 1 {
+			t.Errorf("Expected MSFT summary and one event but got %+v", results[0])
+		}
+
+		if !results[1].Found || results[1].Summary != nil {
+			t.Errorf("Expected AAPL found without summary but got %+v", results[1])
+		}
+
+		if results[2].Found || len(results[2].LatestEvents) != 0 {
+			t.Errorf("Expected ZZZZ not found but got %+v", results[2])
+		}
+	})
+
+	// Compare view lines up the summary fields
+	t.Run("compare", func(t *testing.T) {
+		mockRepo := &MockRepository{
+			GetTickerSummariesFn: func(tickers []string, since, until time.Time) (map[string]models.TickerSummary, error) {
+				return map[string]models.TickerSummary{
+					"AAPL": {Ticker: "AAPL", Company: "Apple Inc.", CoveringBrokerages: 3, Upgrades: 2,
+						Target: &models.TargetStats{Low: 150, High: 250, Count: 3}},
+				}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+
+		comparisons, err := service.CompareTickers([]string{"aapl", "tsla"}, 3, 24*time.Hour)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if len(comparisons) != 2 {
+			t.Fatalf("Expected 2 comparisons but got %d", len(comparisons))
+		}
+
+		if comparisons[0].Company != "Apple Inc." || comparisons[0].Target == nil || comparisons[0].Target.High != 250 || comparisons[0].Upgrades != 2 {
+			t.Errorf("Expected AAPL comparison but got %+v", comparisons[0])
+		}
+
+		if comparisons[1].Found || comparisons[1].RecentActions == nil {
+			t.Errorf("Expected TSLA not found with empty actions but got %+v", comparisons[1])
+		}
+	})
+}
+
+func TestWatchStocks(t *testing.T) {
+	// changedAfter serves the stocks positioned after the cursor, like the
+	// repository
+	changedAfter := func(stocks *[]models.Stock) func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+		return func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+			var changed []models.Stock
+			for _, stock := range *stocks {
+				if stock.UpdatedAt.After(after.Time) || stock.UpdatedAt.Equal(after.Time) && after.ID != "" && stock.ID > after.ID {
+					changed = append(changed, stock)
+				}
+			}
+			sort.Slice(changed, func(i, j int) bool {
+				if !changed[i].UpdatedAt.Equal(changed[j].UpdatedAt) {
+					return changed[i].UpdatedAt.Before(changed[j].UpdatedAt)
+				}
+				return changed[i].ID < changed[j].ID
+			})
+			if len(changed) > limit {
+				changed = changed[:limit]
+			}
+			return changed, nil
+		}
+	}
+
+	// Stocks are delivered in order and the position advances past each one
+	t.Run("delivers and advances", func(t *testing.T) {
+		updated := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stocks := []models.Stock{
+			{ID: "1", Ticker: "AAPL", UpdatedAt: updated},
+			{ID: "2", Ticker: "MSFT", UpdatedAt: updated},
+		}
+		serve := changedAfter(&stocks)
+		var positions []models.Cursor
+		mockRepo := &MockRepository{
+			GetStocksChangedAfterFn: func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+				positions = append(positions, after)
+				// Stop after the follow-up poll
+				if after.ID == "2" {
+					cancel()
+				}
+				return serve(after, filter, limit)
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+		service.SetFeedPollInterval(time.Millisecond)
+
+		var received []string
+		err := service.WatchStocks(ctx, models.Cursor{Time: updated.Add(-time.Hour)}, models.StockFilter{}, func(stock models.Stock, position models.Cursor) error {
+			received = append(received, stock.Ticker)
+			return nil
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the watch to end with the context but got %v", err)
+		}
+
+		if len(received) != 2 || received[0] != "AAPL" || received[1] != "MSFT" {
+			t.Errorf("Expected AAPL then MSFT but got %v", received)
+		}
+
+		followed := false
+		for _, position := range positions {
+			if position.ID == "2" && position.Time.Equal(updated) {
+				followed = true
+			}
+		}
+		if !followed {
+			t.Errorf("Expected a poll to start after stock 2 but got %+v", positions)
+		}
+	})
+
+	// Stocks committed behind the position are delivered once, without moving
+	// the position back, and the window before the start is not replayed
+	t.Run("late commits", func(t *testing.T) {
+		updated := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+		startAt := updated.Add(-time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stocks := []models.Stock{
+			{ID: "0", Ticker: "TSLA", UpdatedAt: startAt.Add(-time.Minute)},
+			{ID: "1", Ticker: "AAPL", UpdatedAt: updated},
+		}
+		mockRepo := &MockRepository{GetStocksChangedAfterFn: changedAfter(&stocks)}
+
+		service := services.NewStockService(mockRepo)
+		service.SetFeedPollInterval(time.Millisecond)
+
+		var received []string
+		var positions []models.Cursor
+		err := service.WatchStocks(ctx, models.Cursor{Time: startAt}, models.StockFilter{}, func(stock models.Stock, position models.Cursor) error {
+			received = append(received, stock.Ticker)
+			positions = append(positions, position)
+			switch len(received) {
+			case 1:
+				// MSFT commits late, behind AAPL
+				stocks = append(stocks,
+					models.Stock{ID: "2", Ticker: "MSFT", UpdatedAt: updated.Add(-30 * time.Second)},
+					models.Stock{ID: "3", Ticker: "NVDA", UpdatedAt: updated.Add(time.Second)},
+				)
+			case 3:
+				cancel()
+			}
+			return nil
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the watch to end with the context but got %v", err)
+		}
+
+		if len(received) != 3 || received[0] != "AAPL" || received[1] != "NVDA" || received[2] != "MSFT" {
+			t.Fatalf("Expected AAPL, NVDA then MSFT but got %v", received)
+		}
+
+		if positions[2] != positions[1] {
+			t.Errorf("Expected the late stock at position %+v but got %+v", positions[1], positions[2])
+		}
+	})
+
+	// Callback errors end the watch
+	t.Run("callback error", func(t *testing.T) {
+		mockRepo := &MockRepository{
+			GetStocksChangedAfterFn: func(after models.Cursor, filter models.StockFilter, limit int) ([]models.Stock, error) {
+				return []models.Stock{{ID: "1"}}, nil
+			},
+		}
+
+		service := services.NewStockService(mockRepo)
+		sendErr := errors.New("client went away")
+
+		err := service.WatchStocks(context.Background(), models.Cursor{}, models.StockFilter{}, func(stock models.Stock, position models.Cursor) error {
+			return sendErr
+		})
+
+		if !errors.Is(err, sendErr) {
+			t.Errorf("Expected the callback error but got %v", err)
+		}
+	})
+}
+
+func TestRatingMappings(t *testing.T) {
+	defer models.SetRatingLadder(models.DefaultRatingLadder())
+
+	newService := func(repo *mocks.MockRatingRepository) *services.StockService {
+		service := services.NewStockService(&MockRepository{})
+		service.SetRatingRepository(repo)
+		return service
+	}
+
+	// Stored mappings are merged over the configured ladder
+	t.Run("load", func(t *testing.T) {
+		ladder := models.DefaultRatingLadder()
+		ladder.BrokerageOverrides["Oppenheimer"] = map[string]models.RatingLevel{"Perform": models.RatingHold}
+
+		service := newService(&mocks.MockRatingRepository{
+			GetRatingMappingsFn: func() ([]models.RatingMapping, error) {
+				return []models.RatingMapping{
+					{Rating: "Accumulate", Level: models.RatingBuy},
+					{Rating: "Outperform", Level: models.RatingStrongBuy},
+				}, nil
+			},
+		})
+		service.SetRatingLadder(ladder)
+
+		if _, err := service.LoadRatingMappings(); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if level := models.GetRatingLevel("", "Accumulate"); level != models.RatingBuy {
+			t.Errorf("Expected the mapped rating to be a buy but got %v", level)
+		}
+
+		if level := models.GetRatingLevel("", "Outperform"); level != models.RatingStrongBuy {
+			t.Errorf("Expected the mapping to replace the built-in level but got %v", level)
+		}
+
+		if level := models.GetRatingLevel("Oppenheimer", "Perform"); level != models.RatingHold {
+			t.Errorf("Expected the ladder overrides to be kept but got %v", level)
+		}
+	})
+
+	// A new mapping is saved and put in use
+	t.Run("map", func(t *testing.T) {
+		var stored []models.RatingMapping
+		service := newService(&mocks.MockRatingRepository{
+			GetRatingMappingsFn: func() ([]models.RatingMapping, error) {
+				return stored, nil
+			},
+			SaveRatingMappingFn: func(mapping models.RatingMapping) error {
+				stored = append(stored, mapping)
+				return nil
+			},
+		})
+		service.SetRatingLadder(models.DefaultRatingLadder())
+
+		mapping, err := service.MapRating(" Speculative Buy ", models.RatingBuy)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if mapping.Rating != "Speculative Buy" || mapping.Category != models.RatingCategoryPositive {
+			t.Errorf("Expected a positive mapping of the trimmed rating but got %+v", mapping)
+		}
+
+		if level := models.GetRatingLevel("", "Speculative Buy"); level != models.RatingBuy {
+			t.Errorf("Expected the mapping to be in use but got %v", level)
+		}
+	})
+
+	// Unmapping falls back to the ladder and records the rating again
+	t.Run("unmap", func(t *testing.T) {
+		stored := []models.RatingMapping{{Rating: "Accumulate", Level: models.RatingBuy}}
+		var recorded []string
+		invalidated := 0
+		service := newService(&mocks.MockRatingRepository{
+			GetRatingMappingsFn: func() ([]models.RatingMapping, error) {
+				return stored, nil
+			},
+			DeleteRatingMappingFn: func(rating string) error {
+				stored = nil
+				return nil
+			},
+			InvalidateRatingsFn: func() {
+				// Caches are dropped once the new ladder is in use
+				if models.GetRatingLevel("", "Accumulate") == models.RatingUnknown {
+					invalidated++
+				}
+			},
+			RecordUnmappedRatingsFn: func(ratings []string) error {
+				recorded = ratings
+				return nil
+			},
+		})
+		service.SetRatingLadder(models.DefaultRatingLadder())
+		if _, err := service.LoadRatingMappings(); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if err := service.UnmapRating("Accumulate"); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if level := models.GetRatingLevel("", "Accumulate"); level != models.RatingUnknown {
+			t.Errorf("Expected the rating to be unmapped but got %v", level)
+		}
+
+		if invalidated != 1 {
+			t.Errorf("Expected the caches to be dropped after the ladder changed")
+		}
+
+		if len(recorded) != 1 || recorded[0] != "Accumulate" {
+			t.Errorf("Expected the unmapped rating to be recorded but got %v", recorded)
+		}
+
+		err := service.UnmapRating("Accumulate")
+		if apiErr, ok := apierrors.As(err); !ok || apiErr.Status() != http.StatusNotFound {
+			t.Errorf("Expected a not found error but got %v", err)
+		}
+	})
+}
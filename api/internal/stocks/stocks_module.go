@@ -1,6 +1,7 @@
 package stocks
 
 import (
+	"stonks-api/cmd/cache"
 	"stonks-api/cmd/database"
 	"stonks-api/internal/stocks/handlers"
 	repository "stonks-api/internal/stocks/repositories"
@@ -14,9 +15,11 @@ type Module struct {
 	StockService *services.StockService
 }
 
-func NewModule(db database.Database) *Module {
+func NewModule(db database.Database, readCache *cache.ReadCache) *Module {
 	stockRepo := repository.NewStockRepository(db)
+	stockRepo.SetReadCache(readCache)
 	stockService := services.NewStockService(stockRepo)
+	stockService.SetRatingRepository(stockRepo)
 	stockHandler := handlers.NewStockHandler(stockService)
 
 	return &Module{
@@ -28,3 +31,7 @@ func NewModule(db database.Database) *Module {
 func (m *Module) RegisterRoutes(e *echo.Group) {
 	m.StockHandler.RegisterRoutes(e)
 }
+
+func (m *Module) RegisterAdminRoutes(e *echo.Group) {
+	m.StockHandler.RegisterAdminRoutes(e)
+}
@@ -12,6 +12,8 @@ const apiClient = axios.create({
   }
 });
 
+export type Rating = 'strong_sell' | 'sell' | 'hold' | 'buy' | 'strong_buy';
+
 export interface Stock {
   id: string;
   ticker: string;
@@ -20,15 +22,40 @@ export interface Stock {
   action: string;
   rating_from: string;
   rating_to: string;
+  rating?: Rating;
   target_from: number;
   target_to: number;
   time: string;
 }
 
+export interface ScoreFactor {
+  id: string;
+  description: string;
+  inputs: Record<string, string | number>;
+  contribution: number;
+}
+
 export interface StockRecommendation {
   stock: Stock;
   score: number;
   reason: string;
+  factors: ScoreFactor[];
+}
+
+export interface Recommendations {
+  scoring: { version: string };
+  recommendations: StockRecommendation[];
+}
+
+export interface StockSearchResult {
+  ticker: string;
+  company: string;
+  brokerage: string;
+  action: string;
+  rating_to: string;
+  target_to: number;
+  time: string;
+  score: number;
 }
 
 export interface PaginatedStocks {
@@ -50,12 +77,19 @@ export const stockService = {
   async getStockByTicker(ticker: string): Promise<Stock[]> {
     const response = await apiClient.get<Stock[]>(`/stock/${ticker}`);
     return response.data;
+  },
+
+  async searchStocks(query: string, limit = 10): Promise<StockSearchResult[]> {
+    const response = await apiClient.get<StockSearchResult[]>('/stocks/search', {
+      params: { q: query, limit }
+    });
+    return response.data;
   }
 };
 
 export const recommendationService = {
   async getRecommendations(): Promise<StockRecommendation[]> {
-    const response = await apiClient.get<StockRecommendation[]>('/recommendations');
-    return response.data;
+    const response = await apiClient.get<Recommendations>('/recommendations');
+    return response.data.recommendations;
   }
 };
\ No newline at end of file